@@ -0,0 +1,10 @@
+// Package assets embeds files shipped inside the backend binary
+package assets
+
+import "embed"
+
+// Fonts holds the fonts in fonts/; placeholder covers render with the first one found,
+// the bundled Noto Sans SC subset
+//
+//go:embed fonts
+var Fonts embed.FS
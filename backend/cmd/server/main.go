@@ -11,6 +11,7 @@ import (
 	"github.com/whitecat/go-reader/internal/api"
 	"github.com/whitecat/go-reader/internal/api/handlers"
 	"github.com/whitecat/go-reader/internal/config"
+	"github.com/whitecat/go-reader/internal/cover"
 	"github.com/whitecat/go-reader/internal/repository"
 	"github.com/whitecat/go-reader/internal/service"
 )
@@ -41,23 +42,29 @@ func main() {
 	tagRepo := repository.NewTagRepository(db)
 	progressRepo := repository.NewProgressRepository(db)
 	bookmarkRepo := repository.NewBookmarkRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+
+	// Initialize cover storage
+	coverStore := cover.NewStoreWithFont(cfg.Storage.CoversDir, cfg.Storage.CoverFont)
 
 	// Initialize services
-	bookService := service.NewBookService(bookRepo, chapterRepo, tagRepo)
+	settingsService := service.NewSettingsService(settingsRepo)
+	bookService := service.NewBookServiceWithSettings(bookRepo, chapterRepo, tagRepo, coverStore, settingsService)
 	tagService := service.NewTagService(tagRepo)
 	progressService := service.NewProgressService(progressRepo, bookmarkRepo)
-	crawlerService := service.NewCrawlerServiceWithCoverDir(bookRepo, chapterRepo, cfg.Storage.CoversDir)
+	crawlerService := service.NewCrawlerServiceWithCovers(bookRepo, chapterRepo, coverStore)
 
 	// Initialize handlers
 	bookHandler := handlers.NewBookHandler(bookService)
 	tagHandler := handlers.NewTagHandler(tagService)
 	progressHandler := handlers.NewProgressHandler(progressService)
 	crawlerHandler := handlers.NewCrawlerHandler(crawlerService)
+	coverHandler := handlers.NewCoverHandler(coverStore)
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
 
 	// Setup router
-	router := api.NewRouter(bookHandler, tagHandler, progressHandler, crawlerHandler)
+	router := api.NewRouter(bookHandler, tagHandler, progressHandler, crawlerHandler, coverHandler, settingsHandler)
 	r := router.SetupRoutes()
-	r.Handle("/covers/*", http.StripPrefix("/covers/", http.FileServer(http.Dir(cfg.Storage.CoversDir))))
 
 	// Start server
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/whitecat/go-reader/internal/models"
@@ -55,8 +62,14 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	book, err := h.bookService.CreateBook(&req)
-	if err != nil {
+	// A partial import still created the book; it carries the reason as a warning
+	book, err := h.bookService.ImportBook(r.Context(), &req, nil)
+	var partial *service.PartialImportError
+	switch {
+	case errors.Is(err, service.ErrUnsupportedFormat):
+		utils.WriteError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	case err != nil && !errors.As(err, &partial):
 		utils.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -64,6 +77,99 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 	utils.WriteCreated(w, book)
 }
 
+// StartImport handles POST /api/books/import/start, importing a local file in the background
+func (h *BookHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateBookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.FilePath == "" {
+		utils.WriteError(w, http.StatusBadRequest, "file_path is required")
+		return
+	}
+
+	jobID := h.bookService.StartImport(&req)
+	utils.WriteSuccess(w, map[string]string{"job_id": jobID})
+}
+
+// ImportStatus handles GET /api/books/import/status?id=xxx
+func (h *BookHandler) ImportStatus(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("id")
+	if jobID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	job, err := h.bookService.GetImportJob(jobID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	utils.WriteSuccess(w, job)
+}
+
+// maxPreviewUpload bounds files uploaded to PreviewBook
+const maxPreviewUpload = 256 << 20
+
+// PreviewBook handles POST /api/books/preview. It takes the CreateBook fields as JSON, or a
+// multipart form with the file in "file"; uploads are deleted once the preview is built.
+func (h *BookHandler) PreviewBook(w http.ResponseWriter, r *http.Request) {
+	var req models.PreviewBookRequest
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxPreviewUpload)
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Missing file")
+			return
+		}
+		defer file.Close()
+
+		// Keep the upload's name so it titles the preview like a local file would
+		dir, err := os.MkdirTemp("", "preview-")
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer os.RemoveAll(dir)
+		req.FilePath = filepath.Join(dir, filepath.Base(header.Filename))
+		if err := saveUpload(req.FilePath, file); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Failed to read upload")
+			return
+		}
+
+		req.FileFormat = r.FormValue("file_format")
+		req.ChapterRuleSet = r.FormValue("chapter_rule_set")
+		fmt.Sscanf(r.FormValue("split_chapter_words"), "%d", &req.SplitChapterWords)
+		req.MarkdownHeadings = r.FormValue("markdown_headings")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	preview, err := h.bookService.PreviewBook(&req)
+	switch {
+	case errors.Is(err, service.ErrFileNotFound):
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, service.ErrUnsupportedFormat):
+		utils.WriteError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	case err != nil:
+		utils.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, preview)
+}
+
+func saveUpload(filePath string, r io.Reader) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // UpdateBook handles PUT /api/books/:id
 func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -121,7 +227,20 @@ func (h *BookHandler) GetBookChapters(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, chapters)
 }
 
-// GetChapter handles GET /api/books/:id/chapters/:number
+// GetNumberingReport handles GET /api/books/:id/numbering
+func (h *BookHandler) GetNumberingReport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	report, err := h.bookService.GetNumberingReport(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, report)
+}
+
+// GetChapter handles GET /api/books/:id/chapters/:number; ?format=html or ?format=text returns only that rendering
 func (h *BookHandler) GetChapter(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	var chapterNumber int
@@ -130,7 +249,13 @@ func (h *BookHandler) GetChapter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chapter, err := h.bookService.GetChapter(id, chapterNumber)
+	format := r.URL.Query().Get("format")
+	if format != "" && format != service.ChapterFormatHTML && format != service.ChapterFormatText {
+		utils.WriteError(w, http.StatusBadRequest, "format must be html or text")
+		return
+	}
+
+	chapter, err := h.bookService.GetChapterAs(id, chapterNumber, format)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, err.Error())
 		return
@@ -138,3 +263,54 @@ func (h *BookHandler) GetChapter(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteSuccess(w, chapter)
 }
+
+// GetBookResource handles GET /api/books/:id/resources/*, streaming an image or other file bundled in the book
+func (h *BookHandler) GetBookResource(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "*")
+
+	rc, err := h.bookService.OpenBookResource(id, name)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer rc.Close()
+
+	writeResource(w, rc, name)
+}
+
+// GetBookPage handles GET /api/books/:id/pages/:page, streaming one page image of an image-based book
+func (h *BookHandler) GetBookPage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var page int
+	if _, err := fmt.Sscanf(chi.URLParam(r, "page"), "%d", &page); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid page number")
+		return
+	}
+
+	rc, err := h.bookService.OpenBookPage(id, page)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer rc.Close()
+
+	writeResource(w, rc, "")
+}
+
+// writeResource streams a bundled file, typed by the extension of name or, failing that, by its content
+func writeResource(w http.ResponseWriter, r io.Reader, name string) {
+	body := bufio.NewReader(r)
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		head, _ := body.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	// Resources never change for a given book; the sandbox keeps bundled SVG/HTML from running scripts
+	w.Header().Set("Cache-Control", "private, max-age=604800")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, body)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/whitecat/go-reader/internal/cover"
+	"github.com/whitecat/go-reader/pkg/utils"
+)
+
+// CoverHandler serves stored cover images and their thumbnails
+type CoverHandler struct {
+	covers *cover.Store
+}
+
+// NewCoverHandler creates a new CoverHandler
+func NewCoverHandler(covers *cover.Store) *CoverHandler {
+	return &CoverHandler{
+		covers: covers,
+	}
+}
+
+// ServeCover handles GET /covers/:id?size=small|medium
+func (h *CoverHandler) ServeCover(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	size := r.URL.Query().Get("size")
+
+	path, err := h.covers.Resolve(id, size)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, cover.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, cover.ErrUnsupportedSize):
+			status = http.StatusBadRequest
+		}
+		utils.WriteError(w, status, err.Error())
+		return
+	}
+
+	// Covers are named after their content hash, so a given URL never changes
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFile(w, r, path)
+}
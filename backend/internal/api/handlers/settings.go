@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/service"
+	"github.com/whitecat/go-reader/pkg/utils"
+)
+
+// SettingsHandler handles settings-related HTTP requests
+type SettingsHandler struct {
+	settingsService *service.SettingsService
+}
+
+// NewSettingsHandler creates a new SettingsHandler
+func NewSettingsHandler(settingsService *service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// GetChapterRules handles GET /api/settings/chapter-rules
+func (h *SettingsHandler) GetChapterRules(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.settingsService.GetChapterRuleSettings()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, settings)
+}
+
+// UpdateChapterRules handles PUT /api/settings/chapter-rules
+func (h *SettingsHandler) UpdateChapterRules(w http.ResponseWriter, r *http.Request) {
+	var req models.ChapterRuleSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.settingsService.UpdateChapterRuleSettings(&req)
+	switch {
+	case errors.Is(err, service.ErrInvalidChapterRules):
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, settings)
+}
@@ -15,6 +15,8 @@ type Router struct {
 	TagHandler      *handlers.TagHandler
 	ProgressHandler *handlers.ProgressHandler
 	CrawlerHandler  *handlers.CrawlerHandler
+	CoverHandler    *handlers.CoverHandler
+	SettingsHandler *handlers.SettingsHandler
 }
 
 // NewRouter creates a new API router
@@ -23,12 +25,16 @@ func NewRouter(
 	tagHandler *handlers.TagHandler,
 	progressHandler *handlers.ProgressHandler,
 	crawlerHandler *handlers.CrawlerHandler,
+	coverHandler *handlers.CoverHandler,
+	settingsHandler *handlers.SettingsHandler,
 ) *Router {
 	return &Router{
 		BookHandler:     bookHandler,
 		TagHandler:      tagHandler,
 		ProgressHandler: progressHandler,
 		CrawlerHandler:  crawlerHandler,
+		CoverHandler:    coverHandler,
+		SettingsHandler: settingsHandler,
 	}
 }
 
@@ -48,18 +54,27 @@ func (router *Router) SetupRoutes() *chi.Mux {
 		w.Write([]byte("OK"))
 	})
 
+	// Covers
+	r.Get("/covers/{id}", router.CoverHandler.ServeCover)
+
 	// API routes
 	r.Route("/api", func(r chi.Router) {
 		// Books
 		r.Route("/books", func(r chi.Router) {
 			r.Get("/", router.BookHandler.GetAllBooks)
 			r.Post("/", router.BookHandler.CreateBook)
+			r.Post("/preview", router.BookHandler.PreviewBook)
+			r.Post("/import/start", router.BookHandler.StartImport)
+			r.Get("/import/status", router.BookHandler.ImportStatus)
 			r.Get("/{id}", router.BookHandler.GetBook)
 			r.Put("/{id}", router.BookHandler.UpdateBook)
 			r.Delete("/{id}", router.BookHandler.DeleteBook)
 			r.Get("/{id}/content", router.BookHandler.GetBookContent)
 			r.Get("/{id}/chapters", router.BookHandler.GetBookChapters)
 			r.Get("/{id}/chapters/{number}", router.BookHandler.GetChapter)
+			r.Get("/{id}/numbering", router.BookHandler.GetNumberingReport)
+			r.Get("/{id}/resources/*", router.BookHandler.GetBookResource)
+			r.Get("/{id}/pages/{page}", router.BookHandler.GetBookPage)
 		})
 
 		// Tags
@@ -83,6 +98,12 @@ func (router *Router) SetupRoutes() *chi.Mux {
 			r.Delete("/{id}", router.ProgressHandler.DeleteBookmark)
 		})
 
+		// Settings
+		r.Route("/settings", func(r chi.Router) {
+			r.Get("/chapter-rules", router.SettingsHandler.GetChapterRules)
+			r.Put("/chapter-rules", router.SettingsHandler.UpdateChapterRules)
+		})
+
 		// Crawler
 		r.Route("/crawler", func(r chi.Router) {
 			r.Post("/search", router.CrawlerHandler.Search)
@@ -30,6 +30,7 @@ type DatabaseConfig struct {
 type StorageConfig struct {
 	BooksDir  string `mapstructure:"books_dir"`
 	CoversDir string `mapstructure:"covers_dir"`
+	CoverFont string `mapstructure:"cover_font"`
 }
 
 // Load loads configuration from file and environment variables
@@ -46,6 +47,7 @@ func Load() (*Config, error) {
 	viper.SetDefault("database.path", "./data/database.db")
 	viper.SetDefault("storage.books_dir", "./data/books")
 	viper.SetDefault("storage.covers_dir", "./data/covers")
+	viper.SetDefault("storage.cover_font", "")
 
 	// Allow overriding with environment variables
 	viper.SetEnvPrefix("GOREADER")
@@ -56,6 +58,7 @@ func Load() (*Config, error) {
 	viper.BindEnv("database.path", "GOREADER_DATABASE_PATH")
 	viper.BindEnv("storage.books_dir", "GOREADER_STORAGE_BOOKS_DIR")
 	viper.BindEnv("storage.covers_dir", "GOREADER_STORAGE_COVERS_DIR")
+	viper.BindEnv("storage.cover_font", "GOREADER_STORAGE_COVER_FONT")
 
 	// Read config file (ignore error if file doesn't exist)
 	if err := viper.ReadInConfig(); err != nil {
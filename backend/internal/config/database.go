@@ -10,6 +10,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/sirupsen/logrus"
+	"github.com/whitecat/go-reader/internal/textstat"
 )
 
 // InitDatabase initializes the database connection and runs migrations
@@ -36,30 +37,54 @@ func InitDatabase(dbPath string) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// migration describes a SQL migration file. Migrations that only add columns are skipped
+// when the guard column already exists, since SQLite has no "ADD COLUMN IF NOT EXISTS".
+// Data migrations that need Go run a function instead of a file and are recorded in the
+// settings table under guardSetting.
+type migration struct {
+	file         string
+	guardTable   string
+	guardColumn  string
+	run          func(db *sql.DB) error
+	guardSetting string
+}
+
+var migrations = []migration{
+	{file: "001_initial.sql"},
+	{file: "002_add_volume_columns.sql", guardTable: "chapters", guardColumn: "volume_number"},
+	{file: "003_add_book_metadata.sql", guardTable: "books", guardColumn: "language"},
+	{file: "004_add_chapter_html.sql", guardTable: "chapters", guardColumn: "content_html"},
+	{file: "005_add_chapter_footnotes.sql", guardTable: "chapters", guardColumn: "footnotes"},
+	{file: "006_recount_words", run: recountWords, guardSetting: "migration_006_recount_words"},
+}
+
 // runMigrations runs database migrations
 func runMigrations(db *sql.DB) error {
-	migrationFiles := []string{
-		"001_initial.sql",
-		"002_add_volume_columns.sql",
-	}
-
 	pathsToTry := []string{
 		"./backend/migrations",
 		"./migrations",
 	}
 
-	for _, file := range migrationFiles {
-		if file == "002_add_volume_columns.sql" {
-			hasVolumeCols, err := columnExists(db, "chapters", "volume_number")
+	for _, m := range migrations {
+		file := m.file
+		if m.guardColumn != "" {
+			exists, err := columnExists(db, m.guardTable, m.guardColumn)
 			if err != nil {
 				return fmt.Errorf("failed to inspect schema before %s: %w", file, err)
 			}
-			if hasVolumeCols {
+			if exists {
 				logrus.Infof("Skipping migration %s (columns already exist)", file)
 				continue
 			}
 		}
 
+		if m.run != nil {
+			if err := runDataMigration(db, m); err != nil {
+				return fmt.Errorf("failed to execute migration %s: %w", file, err)
+			}
+			continue
+		}
+
 		var migrationSQL []byte
 		var readErr error
 
@@ -84,6 +109,66 @@ func runMigrations(db *sql.DB) error {
 	return nil
 }
 
+// runDataMigration runs m unless its guard setting shows it already ran
+func runDataMigration(db *sql.DB, m migration) error {
+	var done int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM settings WHERE key = ?`, m.guardSetting).Scan(&done); err != nil {
+		return err
+	}
+	if done > 0 {
+		logrus.Infof("Skipping migration %s (already applied)", m.file)
+		return nil
+	}
+
+	if err := m.run(db); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`INSERT INTO settings (key, value, updated_at) VALUES (?, 'done', CURRENT_TIMESTAMP)`, m.guardSetting); err != nil {
+		return err
+	}
+	logrus.Infof("Migration %s executed successfully", m.file)
+	return nil
+}
+
+// recountWords recomputes chapter word counts with textstat, replacing the whitespace and rune
+// counts earlier versions stored
+func recountWords(db *sql.DB) error {
+	rows, err := db.Query(`SELECT id, COALESCE(content, '') FROM chapters`)
+	if err != nil {
+		return err
+	}
+	counts := make(map[string]int)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		counts[id] = textstat.WordCount(content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`UPDATE chapters SET word_count = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for id, count := range counts {
+		if _, err := stmt.Exec(count, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func columnExists(db *sql.DB, tableName, columnName string) (bool, error) {
 	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", tableName))
 	if err != nil {
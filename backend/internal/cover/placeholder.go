@@ -0,0 +1,375 @@
+package cover
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"github.com/whitecat/go-reader/assets"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Placeholder cover dimensions (2:3, the usual book cover ratio)
+const (
+	placeholderWidth  = 600
+	placeholderHeight = 900
+	placeholderMargin = 60
+	titleFontSize     = 60
+	authorFontSize    = 32
+	maxTitleLines     = 5
+)
+
+// defaultPalette is used when the book has no tag color; the title picks a stable entry
+var defaultPalette = []string{
+	"#3b5b92", "#8c4a2f", "#2f6f5e", "#6b3f7a",
+	"#a23b3b", "#3f6b8c", "#7a6a2f", "#4f5d6b",
+}
+
+// bundledFontDirs are searched for a CJK-capable font shipped next to the app when the binary
+// embeds none. Paths mirror the migrations lookup: repo root in development, resources dir when packaged.
+var bundledFontDirs = []string{
+	"./backend/assets/fonts",
+	"./assets/fonts",
+	"./fonts",
+}
+
+// systemFontPaths are common CJK fonts tried when no font is bundled
+var systemFontPaths = []string{
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wenquanyi/wqy-microhei/wqy-microhei.ttc",
+	"/System/Library/Fonts/PingFang.ttc",
+	"/System/Library/Fonts/STHeiti Medium.ttc",
+	`C:\Windows\Fonts\msyh.ttc`,
+	`C:\Windows\Fonts\simhei.ttf`,
+}
+
+// SavePlaceholder renders a generated cover from the book's title, author and tag color
+// and stores it like any other cover. tagColor is a "#rrggbb" string and may be empty.
+func (s *Store) SavePlaceholder(title, author, tagColor string) (string, error) {
+	data, err := renderPlaceholder(s.loadFont(), title, author, tagColor)
+	if err != nil {
+		return "", err
+	}
+	return s.Save(data)
+}
+
+// goFont is the built-in Go font, parsed once; it renders Latin text missing from the primary font
+var goFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(goregular.TTF)
+})
+
+// loadFont returns the CJK-capable font used for placeholders, or nil if none can be found.
+// The configured font wins, then the font embedded in the binary, then fonts on disk.
+// The lookup runs once per store; Latin text still renders through the built-in Go font.
+func (s *Store) loadFont() *opentype.Font {
+	s.fontOnce.Do(func() {
+		if s.fontPath != "" {
+			if s.font = loadFontFile(os.DirFS(filepath.Dir(s.fontPath)), filepath.Base(s.fontPath)); s.font != nil {
+				return
+			}
+			logrus.Warnf("cover: configured font %s could not be loaded", s.fontPath)
+		}
+		if s.font = loadFontDir(assets.Fonts, "fonts"); s.font != nil {
+			return
+		}
+		for _, dir := range bundledFontDirs {
+			if s.font = loadFontDir(os.DirFS(dir), "."); s.font != nil {
+				return
+			}
+		}
+		for _, path := range systemFontPaths {
+			if s.font = loadFontFile(os.DirFS(filepath.Dir(path)), filepath.Base(path)); s.font != nil {
+				return
+			}
+		}
+		logrus.Warn("cover: no CJK font found, placeholder covers will only render Latin text")
+	})
+	return s.font
+}
+
+// loadFontDir returns the first font in dir that parses, or nil
+func loadFontDir(fsys fs.FS, dir string) *opentype.Font {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".ttf", ".otf", ".ttc", ".otc":
+			if f := loadFontFile(fsys, dir+"/"+e.Name()); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// loadFontFile parses the font at name, logging which font placeholders use; it returns nil
+// if the file is missing or not a font
+func loadFontFile(fsys fs.FS, name string) *opentype.Font {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil
+	}
+	f, err := parseFont(data)
+	if err != nil {
+		logrus.Warnf("cover: failed to parse font %s: %v", name, err)
+		return nil
+	}
+	logrus.Infof("cover: using font %s for placeholder covers", name)
+	return f
+}
+
+// parseFont loads a TrueType/OpenType font or the first font of a collection
+func parseFont(data []byte) (*opentype.Font, error) {
+	if f, err := opentype.Parse(data); err == nil {
+		return f, nil
+	}
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	return collection.Font(0)
+}
+
+// renderPlaceholder draws the cover and encodes it as PNG
+func renderPlaceholder(primary *opentype.Font, title, author, tagColor string) ([]byte, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = "Untitled"
+	}
+	author = strings.TrimSpace(author)
+
+	bg, ok := parseHexColor(tagColor)
+	if !ok {
+		h := fnv.New32a()
+		h.Write([]byte(title))
+		bg, _ = parseHexColor(defaultPalette[h.Sum32()%uint32(len(defaultPalette))])
+	}
+	band := shade(bg, 0.7)
+	fg := color.RGBA{R: 250, G: 250, B: 250, A: 255}
+	if luminance(bg) > 0.6 {
+		fg = color.RGBA{R: 30, G: 30, B: 30, A: 255}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, placeholderWidth, placeholderHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	bandTop := placeholderHeight * 3 / 4
+	draw.Draw(img, image.Rect(0, bandTop, placeholderWidth, placeholderHeight), image.NewUniform(band), image.Point{}, draw.Src)
+	rule := image.Rect(placeholderMargin, placeholderHeight/6, placeholderWidth-placeholderMargin, placeholderHeight/6+3)
+	draw.Draw(img, rule, image.NewUniform(fg), image.Point{}, draw.Over)
+
+	titleFace, err := newFace(primary, titleFontSize)
+	if err != nil {
+		return nil, err
+	}
+	defer titleFace.Close()
+
+	maxWidth := fixed.I(placeholderWidth - 2*placeholderMargin)
+	lines := wrapText(titleFace, title, maxWidth, maxTitleLines)
+	lineHeight := titleFace.Metrics().Height.Ceil() + 12
+	y := placeholderHeight/6 + 40 + titleFace.Metrics().Ascent.Ceil()
+	for _, line := range lines {
+		drawCentered(img, titleFace, fg, line, y)
+		y += lineHeight
+	}
+
+	if author != "" {
+		authorFace, err := newFace(primary, authorFontSize)
+		if err != nil {
+			return nil, err
+		}
+		defer authorFace.Close()
+
+		authorLines := wrapText(authorFace, author, maxWidth, 1)
+		bandHeight := placeholderHeight - bandTop
+		ay := bandTop + (bandHeight+authorFace.Metrics().Ascent.Ceil())/2
+		drawCentered(img, authorFace, fg, authorLines[0], ay)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode placeholder: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newFace builds a face that uses the primary font and falls back to the Go font for missing glyphs
+func newFace(primary *opentype.Font, size float64) (font.Face, error) {
+	opts := &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull}
+
+	builtin, err := goFont()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse built-in font: %w", err)
+	}
+	fallback, err := opentype.NewFace(builtin, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	if primary == nil {
+		return fallback, nil
+	}
+
+	face, err := opentype.NewFace(primary, opts)
+	if err != nil {
+		fallback.Close()
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return &fallbackFace{faces: []font.Face{face, fallback}}, nil
+}
+
+// wrapText breaks text into lines no wider than maxWidth. CJK text has no spaces,
+// so lines break between any two runes; Latin words are kept together when possible.
+// Text beyond maxLines is cut and marked with an ellipsis.
+func wrapText(face font.Face, text string, maxWidth fixed.Int26_6, maxLines int) []string {
+	var lines []string
+	var current []rune
+	lastSpace := -1
+
+	for _, r := range text {
+		if r == '\n' {
+			r = ' '
+		}
+		current = append(current, r)
+		if unicode.IsSpace(r) {
+			lastSpace = len(current) - 1
+		}
+		if font.MeasureString(face, string(current)) <= maxWidth || len(current) == 1 {
+			continue
+		}
+
+		// Line is too wide: break at the last space if there is one, otherwise before this rune
+		var rest []rune
+		if lastSpace > 0 && !isCJK(r) {
+			rest = append(rest, current[lastSpace+1:]...)
+			current = current[:lastSpace]
+		} else {
+			rest = []rune{r}
+			current = current[:len(current)-1]
+		}
+		lines = append(lines, strings.TrimSpace(string(current)))
+		current = rest
+		lastSpace = -1
+	}
+	if s := strings.TrimSpace(string(current)); s != "" || len(lines) == 0 {
+		lines = append(lines, s)
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := []rune(lines[maxLines-1])
+		for len(last) > 0 && font.MeasureString(face, string(last)+"…") > maxWidth {
+			last = last[:len(last)-1]
+		}
+		lines[maxLines-1] = string(last) + "…"
+	}
+	return lines
+}
+
+// drawCentered draws a single line of text horizontally centered at baseline y
+func drawCentered(img *image.RGBA, face font.Face, c color.Color, text string, y int) {
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+	width := d.MeasureString(text)
+	d.Dot = fixed.Point26_6{X: (fixed.I(placeholderWidth) - width) / 2, Y: fixed.I(y)}
+	d.DrawString(text)
+}
+
+// parseHexColor parses "#rgb" or "#rrggbb"
+func parseHexColor(s string) (color.RGBA, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, true
+}
+
+// shade darkens a color by factor (0..1)
+func shade(c color.RGBA, factor float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * factor),
+		G: uint8(float64(c.G) * factor),
+		B: uint8(float64(c.B) * factor),
+		A: c.A,
+	}
+}
+
+// luminance returns the relative brightness of a color in [0, 1]
+func luminance(c color.RGBA) float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// fallbackFace renders each rune with the first face that has a glyph for it
+type fallbackFace struct {
+	faces []font.Face
+}
+
+func (f *fallbackFace) pick(r rune) font.Face {
+	for _, face := range f.faces {
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+	return f.faces[0]
+}
+
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.pick(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.pick(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.pick(r).GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.pick(r0)
+	if face != f.pick(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
@@ -0,0 +1,106 @@
+package cover
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestStore_SavePlaceholder(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	coverPath, err := store.SavePlaceholder("The Long Way Home", "Jane Doe", "#ff8800")
+	require.NoError(t, err)
+	id, ok := IDFromPath(coverPath)
+	require.True(t, ok)
+	assert.Regexp(t, `\.png$`, id)
+
+	data, err := os.ReadFile(filepath.Join(store.Dir(), id))
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, placeholderWidth, placeholderHeight), img.Bounds())
+
+	// Background uses the tag color
+	r, g, b, _ := img.At(5, 5).RGBA()
+	assert.Equal(t, []uint32{0xff, 0x88, 0x00}, []uint32{r >> 8, g >> 8, b >> 8})
+
+	// Same input renders the same image and is deduplicated
+	again, err := store.SavePlaceholder("The Long Way Home", "Jane Doe", "#ff8800")
+	assert.NoError(t, err)
+	assert.Equal(t, coverPath, again)
+}
+
+func TestRenderPlaceholder_CJK(t *testing.T) {
+	// Traditional characters outside GB2312 are covered as well
+	title := "在大宋破碎虚空 書劍恩仇錄"
+	primary := NewStore(t.TempDir()).loadFont()
+	require.NotNil(t, primary, "no font embedded in assets/fonts")
+
+	// Every Han rune of the title has a real glyph, not .notdef
+	var buf sfnt.Buffer
+	for _, r := range title {
+		index, err := primary.GlyphIndex(&buf, r)
+		require.NoError(t, err)
+		assert.NotZero(t, index, "missing glyph for %q", r)
+	}
+
+	// and the glyphs are drawn: the title area is no longer plain background
+	data, err := renderPlaceholder(primary, title, "", "#000000")
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	inked := 0
+	for y := placeholderHeight/6 + 4; y < placeholderHeight*3/4; y++ {
+		for x := 0; x < placeholderWidth; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r > 0 {
+				inked++
+			}
+		}
+	}
+	assert.Greater(t, inked, 1000)
+}
+
+func TestWrapText(t *testing.T) {
+	face, err := newFace(nil, 20)
+	require.NoError(t, err)
+	defer face.Close()
+
+	lines := wrapText(face, "one two three four five six seven eight nine ten", fixed.I(120), 10)
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.NotEmpty(t, line)
+		assert.Equal(t, strings.TrimSpace(line), line)
+	}
+
+	truncated := wrapText(face, "one two three four five six seven eight nine ten", fixed.I(120), 2)
+	assert.Len(t, truncated, 2)
+	assert.Contains(t, truncated[1], "…")
+
+	assert.Equal(t, []string{"short"}, wrapText(face, "short", fixed.I(500), 3))
+}
+
+func TestParseHexColor(t *testing.T) {
+	c, ok := parseHexColor("#102030")
+	assert.True(t, ok)
+	assert.Equal(t, uint8(0x10), c.R)
+	assert.Equal(t, uint8(0x30), c.B)
+
+	c, ok = parseHexColor("#fff")
+	assert.True(t, ok)
+	assert.Equal(t, uint8(0xff), c.G)
+
+	_, ok = parseHexColor("blue")
+	assert.False(t, ok)
+	_, ok = parseHexColor("")
+	assert.False(t, ok)
+}
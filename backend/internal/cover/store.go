@@ -0,0 +1,201 @@
+package cover
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/font/opentype"
+)
+
+// URLPrefix is the public path under which stored covers are served
+const URLPrefix = "/covers/"
+
+// Errors returned by Resolve
+var (
+	// ErrNotFound is returned for a cover ID that is malformed or not in the store
+	ErrNotFound = errors.New("cover not found")
+	// ErrUnsupportedSize is returned for a thumbnail size other than those in thumbnailWidths
+	ErrUnsupportedSize = errors.New("unsupported cover size")
+)
+
+// imageTypes maps sniffed MIME types to the extension used on disk
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// Store keeps cover images in a single directory.
+// Files are named after the SHA-256 of their content so identical covers are only stored once.
+type Store struct {
+	dir string
+
+	// fontPath optionally points at the font used for placeholder covers
+	fontPath string
+	fontOnce sync.Once
+	font     *opentype.Font
+}
+
+// NewStore creates a new Store rooted at dir
+func NewStore(dir string) *Store {
+	if dir == "" {
+		dir = "./data/covers"
+	}
+	return &Store{dir: dir}
+}
+
+// NewStoreWithFont creates a Store that renders placeholder covers with the given font file.
+// An empty fontPath falls back to the bundled and system font lookup.
+func NewStoreWithFont(dir, fontPath string) *Store {
+	s := NewStore(dir)
+	s.fontPath = fontPath
+	return s
+}
+
+// Dir returns the directory covers are stored in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save stores an image and returns its public path ("/covers/<hash>.<ext>").
+// Thumbnails are generated eagerly; failing to generate them does not fail the save.
+func (s *Store) Save(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("empty image data")
+	}
+
+	ext, err := sniffExtension(data)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create covers directory: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + ext
+	path := filepath.Join(s.dir, name)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := writeFileAtomic(path, data); err != nil {
+			return "", fmt.Errorf("failed to write cover: %w", err)
+		}
+	}
+
+	for size := range thumbnailWidths {
+		if _, err := s.thumbnail(name, size); err != nil {
+			logrus.Warnf("cover: thumbnail %s for %s failed: %v", size, name, err)
+		}
+	}
+
+	return URLPrefix + name, nil
+}
+
+// Resolve returns the file path for a cover ID, optionally at a thumbnail size.
+// Missing thumbnails (e.g. for covers saved before thumbnails existed) are generated on demand.
+func (s *Store) Resolve(id, size string) (string, error) {
+	if !validID(id) {
+		return "", fmt.Errorf("%w: invalid cover id", ErrNotFound)
+	}
+
+	path := filepath.Join(s.dir, id)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+
+	if size == "" || size == "original" {
+		return path, nil
+	}
+	if _, ok := thumbnailWidths[size]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedSize, size)
+	}
+
+	return s.thumbnail(id, size)
+}
+
+// Remove deletes a stored cover and all of its thumbnails.
+// Paths that do not point into the store (e.g. remote URLs) are ignored.
+func (s *Store) Remove(coverPath string) error {
+	id, ok := IDFromPath(coverPath)
+	if !ok {
+		return nil
+	}
+
+	paths := []string{filepath.Join(s.dir, id)}
+	for size := range thumbnailWidths {
+		paths = append(paths, filepath.Join(s.dir, thumbnailName(id, size)))
+	}
+
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cover %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// IDFromPath extracts the cover ID from a public cover path.
+// Returns false if the path is not served from the store.
+func IDFromPath(coverPath string) (string, bool) {
+	if !strings.HasPrefix(coverPath, URLPrefix) {
+		return "", false
+	}
+	id := strings.TrimPrefix(coverPath, URLPrefix)
+	if i := strings.IndexAny(id, "?#"); i >= 0 {
+		id = id[:i]
+	}
+	if !validID(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// sniffExtension detects the image type from its content rather than trusting headers or file names
+func sniffExtension(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if ext, ok := imageTypes[contentType]; ok {
+		return ext, nil
+	}
+	return "", fmt.Errorf("unsupported image type: %s", contentType)
+}
+
+// validID rejects anything that could escape the covers directory
+func validID(id string) bool {
+	if id == "" || id == "." || id == ".." {
+		return false
+	}
+	return !strings.ContainsAny(id, `/\`) && filepath.Base(id) == id
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so concurrent readers never observe a partially written cover
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cover-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
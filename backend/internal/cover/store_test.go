@@ -0,0 +1,109 @@
+package cover
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestPNG encodes a solid-color PNG of the given size
+func createTestPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestStore_SaveSniffsAndDeduplicates(t *testing.T) {
+	store := NewStore(t.TempDir())
+	data := createTestPNG(t, 600, 900)
+
+	first, err := store.Save(data)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^/covers/[0-9a-f]{64}\.png$`, first)
+
+	second, err := store.Save(data)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second, "identical content should map to the same cover")
+
+	entries, err := os.ReadDir(store.Dir())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1+len(thumbnailWidths), "original plus one file per thumbnail size")
+}
+
+func TestStore_SaveRejectsNonImages(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	_, err := store.Save([]byte("<html>not found</html>"))
+	assert.Error(t, err)
+
+	_, err = store.Save(nil)
+	assert.Error(t, err)
+}
+
+func TestStore_ResolveThumbnail(t *testing.T) {
+	store := NewStore(t.TempDir())
+	path, err := store.Save(createTestPNG(t, 600, 900))
+	require.NoError(t, err)
+	id, ok := IDFromPath(path)
+	require.True(t, ok)
+
+	thumbPath, err := store.Resolve(id, "small")
+	assert.NoError(t, err)
+
+	f, err := os.Open(thumbPath)
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 160, cfg.Width)
+	assert.Equal(t, 240, cfg.Height)
+
+	original, err := store.Resolve(id, "")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(store.Dir(), id), original)
+
+	_, err = store.Resolve(id, "huge")
+	assert.ErrorIs(t, err, ErrUnsupportedSize)
+	_, err = store.Resolve("../secret.png", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStore_ResolveGeneratesMissingThumbnail(t *testing.T) {
+	store := NewStore(t.TempDir())
+	// Covers saved before thumbnails existed use random names
+	legacy := "legacy-cover.png"
+	require.NoError(t, os.WriteFile(filepath.Join(store.Dir(), legacy), createTestPNG(t, 100, 150), 0644))
+
+	thumbPath, err := store.Resolve(legacy, "medium")
+	assert.NoError(t, err)
+	assert.FileExists(t, thumbPath)
+}
+
+func TestStore_Remove(t *testing.T) {
+	store := NewStore(t.TempDir())
+	path, err := store.Save(createTestPNG(t, 50, 50))
+	require.NoError(t, err)
+
+	assert.NoError(t, store.Remove(path))
+	entries, err := os.ReadDir(store.Dir())
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Remote covers are not managed by the store
+	assert.NoError(t, store.Remove("https://example.com/cover.jpg"))
+}
@@ -0,0 +1,104 @@
+package cover
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// thumbnailWidths lists the supported thumbnail sizes for library grids (width in pixels)
+var thumbnailWidths = map[string]int{
+	"small":  160,
+	"medium": 320,
+}
+
+// maxImagePixels bounds the covers resizeToJPEG decodes; a small file can declare huge
+// dimensions, and decoding allocates memory for all of its pixels up front
+const maxImagePixels = 32 << 20
+
+// thumbnailName returns the file name of a cover's thumbnail, e.g. "<hash>_small.jpg"
+func thumbnailName(id, size string) string {
+	base := strings.TrimSuffix(id, filepath.Ext(id))
+	return base + "_" + size + ".jpg"
+}
+
+// thumbnail returns the path of a cover's thumbnail, generating it if it does not exist yet
+func (s *Store) thumbnail(id, size string) (string, error) {
+	width, ok := thumbnailWidths[size]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedSize, size)
+	}
+
+	path := filepath.Join(s.dir, thumbnailName(id, size))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id))
+	if err != nil {
+		return "", fmt.Errorf("failed to read cover: %w", err)
+	}
+
+	thumb, err := resizeToJPEG(data, width)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeFileAtomic(path, thumb); err != nil {
+		return "", fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	return path, nil
+}
+
+// resizeToJPEG scales an image down to the given width, keeping its aspect ratio.
+// Images narrower than width are re-encoded at their original size.
+// Transparent areas are flattened onto white since JPEG has no alpha channel.
+func resizeToJPEG(data []byte, width int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("image is too large: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > width {
+		h = h * width / w
+		w = width
+		if h < 1 {
+			h = 1
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,23 @@
+package cover
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResizeToJPEG_RejectsHugeImages(t *testing.T) {
+	// Claim 100000x100000 pixels in the IHDR chunk of a tiny PNG; the chunk starts at
+	// offset 8, its data (width, height, ...) at 16 and its CRC at 29
+	data := createTestPNG(t, 1, 1)
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := resizeToJPEG(data, thumbnailWidths["small"])
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too large")
+}
@@ -4,27 +4,45 @@ import "time"
 
 // Book represents a book in the library
 type Book struct {
-	ID          string    `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title" validate:"required"`
-	Author      string    `json:"author" db:"author"`
-	Description string    `json:"description" db:"description"`
-	CoverPath   string    `json:"cover_path" db:"cover_path"`
-	FilePath    string    `json:"file_path" db:"file_path" validate:"required"`
-	FileFormat  string    `json:"file_format" db:"file_format" validate:"required,oneof=txt md epub web"`
-	FileSize    int64     `json:"file_size" db:"file_size"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-	Tags        []Tag     `json:"tags,omitempty" db:"-"`
+	ID            string    `json:"id" db:"id"`
+	Title         string    `json:"title" db:"title" validate:"required"`
+	Author        string    `json:"author" db:"author"`
+	Description   string    `json:"description" db:"description"`
+	CoverPath     string    `json:"cover_path" db:"cover_path"`
+	FilePath      string    `json:"file_path" db:"file_path" validate:"required"`
+	FileFormat    string    `json:"file_format" db:"file_format" validate:"required,oneof=txt md markdown epub pdf mobi azw azw3 fb2 zip docx odt html htm mhtml mht cbz cbr web"`
+	FileSize      int64     `json:"file_size" db:"file_size"`
+	Language      string    `json:"language" db:"language"`
+	Publisher     string    `json:"publisher" db:"publisher"`
+	PublishedDate string    `json:"published_date" db:"published_date"`
+	ISBN          string    `json:"isbn" db:"isbn"`
+	Identifier    string    `json:"identifier" db:"identifier"`
+	Series        string    `json:"series" db:"series"`
+	SeriesIndex   float64   `json:"series_index" db:"series_index"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	Tags          []Tag     `json:"tags,omitempty" db:"-"`
+	// Warnings reports problems noticed while importing (e.g. a file_format that didn't match the content)
+	Warnings []string `json:"warnings,omitempty" db:"-"`
 }
 
-// CreateBookRequest represents the request to create a new book
+// CreateBookRequest represents the request to create a new book.
+// Empty title, author and description are filled from the file's embedded metadata when available.
+// FileFormat is a hint: the format detected from the file's content takes precedence.
 type CreateBookRequest struct {
-	Title       string   `json:"title" validate:"required"`
+	Title       string   `json:"title"`
 	Author      string   `json:"author"`
 	Description string   `json:"description"`
 	FilePath    string   `json:"file_path" validate:"required"`
-	FileFormat  string   `json:"file_format" validate:"required,oneof=txt md epub"`
+	FileFormat  string   `json:"file_format" validate:"omitempty,oneof=txt md markdown epub pdf mobi azw azw3 fb2 zip docx odt html htm mhtml mht cbz cbr"`
 	TagIDs      []string `json:"tag_ids"`
+	// ChapterRuleSet selects the rule set TXT files are split into chapters with; empty uses the default set
+	ChapterRuleSet string `json:"chapter_rule_set"`
+	// SplitChapterWords is the size, in words, oversized chapters are split into; 0 uses the default, -1 keeps them whole
+	SplitChapterWords int `json:"split_chapter_words"`
+	// MarkdownHeadings maps markdown headings: "volumes" makes "#" volumes and "##" chapters, "chapters"
+	// makes both chapters and empty picks volumes only when the file uses both levels
+	MarkdownHeadings string `json:"markdown_headings"`
 }
 
 // CreateRemoteBookRequest represents creating a book from scraped chapters (no local file)
@@ -37,9 +55,15 @@ type CreateRemoteBookRequest struct {
 
 // UpdateBookRequest represents the request to update a book
 type UpdateBookRequest struct {
-	Title       *string  `json:"title"`
-	Author      *string  `json:"author"`
-	Description *string  `json:"description"`
-	CoverPath   *string  `json:"cover_path"`
-	TagIDs      []string `json:"tag_ids"`
+	Title         *string  `json:"title"`
+	Author        *string  `json:"author"`
+	Description   *string  `json:"description"`
+	CoverPath     *string  `json:"cover_path"`
+	Language      *string  `json:"language"`
+	Publisher     *string  `json:"publisher"`
+	PublishedDate *string  `json:"published_date"`
+	ISBN          *string  `json:"isbn"`
+	Series        *string  `json:"series"`
+	SeriesIndex   *float64 `json:"series_index"`
+	TagIDs        []string `json:"tag_ids"`
 }
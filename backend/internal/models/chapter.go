@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Chapter represents a chapter in a book
 type Chapter struct {
@@ -11,6 +16,8 @@ type Chapter struct {
 	VolumeChapterNumber int       `json:"volume_chapter_number" db:"volume_chapter_number"`
 	Title               string    `json:"title" db:"title"`
 	Content             string    `json:"content,omitempty" db:"content"`
+	ContentHTML         string    `json:"content_html,omitempty" db:"content_html"`
+	Footnotes           Footnotes `json:"footnotes,omitempty" db:"footnotes"`
 	WordCount           int       `json:"word_count" db:"word_count"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
 }
@@ -26,3 +33,49 @@ type ChapterSummary struct {
 	WordCount           int       `json:"word_count" db:"word_count"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
 }
+
+// Footnote is a footnote or endnote referenced from a chapter.
+// ContentHTML links to it with <a data-footnote="ID">.
+type Footnote struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Content     string `json:"content"`
+	ContentHTML string `json:"content_html,omitempty"`
+}
+
+// Footnotes is stored as a JSON array in a single column
+type Footnotes []Footnote
+
+// Value implements driver.Valuer
+func (f Footnotes) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode footnotes: %w", err)
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (f *Footnotes) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported footnotes type: %T", src)
+	}
+
+	if len(data) == 0 {
+		*f = nil
+		return nil
+	}
+	return json.Unmarshal(data, f)
+}
@@ -0,0 +1,28 @@
+package models
+
+// Kinds of lines a ChapterRule can mark
+const (
+	ChapterRuleChapter = "chapter"
+	ChapterRuleVolume  = "volume"
+)
+
+// ChapterRule is a regular expression that marks a TXT line as a chapter or volume title
+type ChapterRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Kind    string `json:"kind" validate:"oneof=chapter volume"`
+}
+
+// ChapterRuleSet is an ordered list of rules; the first rule matching a line decides its kind
+type ChapterRuleSet struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	BuiltIn bool          `json:"built_in"`
+	Rules   []ChapterRule `json:"rules"`
+}
+
+// ChapterRuleSettings holds the rule sets offered for TXT imports and the one used by default
+type ChapterRuleSettings struct {
+	DefaultSet string           `json:"default_set"`
+	Sets       []ChapterRuleSet `json:"sets"`
+}
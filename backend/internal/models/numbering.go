@@ -0,0 +1,30 @@
+package models
+
+// Kinds of NumberingIssue
+const (
+	NumberingMissing    = "missing"
+	NumberingDuplicate  = "duplicate"
+	NumberingOutOfOrder = "out_of_order"
+)
+
+// NumberingReport compares the numbers chapter titles declare ("第十二章", "Chapter 12") with their order
+type NumberingReport struct {
+	BookID        string           `json:"book_id"`
+	ChapterCount  int              `json:"chapter_count"`
+	NumberedCount int              `json:"numbered_count"`
+	Issues        []NumberingIssue `json:"issues"`
+}
+
+// NumberingIssue is a gap, repeat or step back in declared chapter numbers.
+// ChapterNumber is the stored chapter the issue shows at; for missing chapters,
+// the one following the gap.
+type NumberingIssue struct {
+	Kind          string `json:"kind"`
+	ChapterNumber int    `json:"chapter_number"`
+	VolumeNumber  int    `json:"volume_number"`
+	Title         string `json:"title"`
+	Declared      int    `json:"declared,omitempty"`
+	MissingFrom   int    `json:"missing_from,omitempty"`
+	MissingTo     int    `json:"missing_to,omitempty"`
+	Message       string `json:"message"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// PreviewBookRequest asks how a file would be imported, without storing anything
+type PreviewBookRequest struct {
+	FilePath          string `json:"file_path" validate:"required"`
+	FileFormat        string `json:"file_format"`
+	ChapterRuleSet    string `json:"chapter_rule_set"`
+	SplitChapterWords int    `json:"split_chapter_words"`
+	MarkdownHeadings  string `json:"markdown_headings"`
+}
+
+// BookPreview describes how a file would be split into volumes and chapters
+type BookPreview struct {
+	Title        string          `json:"title"`
+	Author       string          `json:"author"`
+	FileFormat   string          `json:"file_format"`
+	Encoding     string          `json:"encoding,omitempty"`
+	ChapterCount int             `json:"chapter_count"`
+	WordCount    int             `json:"word_count"`
+	Volumes      []PreviewVolume `json:"volumes"`
+	Warnings     []string        `json:"warnings"`
+}
+
+// PreviewVolume groups the chapters of one volume; Title is empty for books without volume titles
+type PreviewVolume struct {
+	Number   int              `json:"number"`
+	Title    string           `json:"title"`
+	Chapters []PreviewChapter `json:"chapters"`
+}
+
+// PreviewChapter is a chapter as it would be stored, without its content
+type PreviewChapter struct {
+	ChapterNumber       int    `json:"chapter_number"`
+	VolumeChapterNumber int    `json:"volume_chapter_number"`
+	Title               string `json:"title"`
+	WordCount           int    `json:"word_count"`
+}
@@ -0,0 +1,242 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// comicDocPath is the pseudo path pages are rendered under; they resolve to "pages/<n>"
+const comicDocPath = "comic"
+
+// comicImageExts lists the page image types read from comic archives
+var comicImageExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true, ".avif": true,
+}
+
+// CbzParser parses comic book archives (.cbz, and .cbr files that are really zips) as image-only books
+type CbzParser struct {
+	opts Options
+}
+
+// NewCbzParser creates a new CbzParser
+func NewCbzParser() *CbzParser {
+	return &CbzParser{}
+}
+
+// NewCbzParserWithOptions creates a CbzParser that renders content according to opts
+func NewCbzParserWithOptions(opts Options) *CbzParser {
+	return &CbzParser{opts: opts}
+}
+
+// ComicInfo is the ComicRack metadata file (ComicInfo.xml) bundled in many comic archives
+type ComicInfo struct {
+	Title       string `xml:"Title"`
+	Series      string `xml:"Series"`
+	Number      string `xml:"Number"`
+	Summary     string `xml:"Summary"`
+	Year        int    `xml:"Year"`
+	Month       int    `xml:"Month"`
+	Day         int    `xml:"Day"`
+	Writer      string `xml:"Writer"`
+	Penciller   string `xml:"Penciller"`
+	Publisher   string `xml:"Publisher"`
+	Genre       string `xml:"Genre"`
+	Tags        string `xml:"Tags"`
+	LanguageISO string `xml:"LanguageISO"`
+	GTIN        string `xml:"GTIN"`
+}
+
+// Parse turns every page image into an <img> and groups the pages into one chapter per folder
+func (p *CbzParser) Parse(filePath string) ([]models.Chapter, error) {
+	reader, err := openComic(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	pages := comicPages(&reader.Reader)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no page images found in comic archive")
+	}
+
+	// Folders shared by every page (e.g. a single top-level folder) don't name chapters
+	prefix := path.Dir(pages[0].Name)
+	for _, page := range pages {
+		for prefix != "." && !strings.HasPrefix(page.Name, prefix+"/") {
+			prefix = path.Dir(prefix)
+		}
+	}
+
+	var sections []*epubSection
+	byFolder := make(map[string]*epubSection)
+	for i, page := range pages {
+		folder := strings.TrimPrefix(strings.TrimPrefix(path.Dir(page.Name), prefix), "/")
+		sec, ok := byFolder[folder]
+		if !ok {
+			sec = &epubSection{Title: strings.ReplaceAll(folder, "/", " / ")}
+			byFolder[folder] = sec
+			sections = append(sections, sec)
+		}
+		sec.add(comicDocPath, `<img src="pages/`+strconv.Itoa(i+1)+`" alt="">`)
+	}
+
+	result := make([]epubSection, 0, len(sections))
+	for _, sec := range sections {
+		if sec.Title == "" {
+			sec.Title = frontMatterTitle
+			if len(sections) == 1 {
+				sec.Title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+			}
+		}
+		result = append(result, *sec)
+	}
+
+	chapters := sectionsToChapters(result, p.opts.ResourceBaseURL, nil)
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no page images found in comic archive")
+	}
+	return chapters, nil
+}
+
+// ExtractMetadata reads ComicInfo.xml
+func (p *CbzParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	reader, err := openComic(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	f := findFileInZip(&reader.Reader, "ComicInfo.xml")
+	if f == nil {
+		return nil, fmt.Errorf("ComicInfo.xml not found")
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+	var info ComicInfo
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charset.NewReaderLabel
+	if err := dec.Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to parse ComicInfo.xml: %w", err)
+	}
+
+	meta := &BookMetadata{
+		Title:       strings.TrimSpace(info.Title),
+		Author:      firstNonEmpty([]string{info.Writer, info.Penciller}),
+		Description: cleanDescription(info.Summary),
+		Language:    strings.TrimSpace(info.LanguageISO),
+		Publisher:   strings.TrimSpace(info.Publisher),
+		Series:      strings.TrimSpace(info.Series),
+		Subjects:    splitSubjects([]string{info.Genre, info.Tags}),
+	}
+	if isbn, ok := normalizeISBN(info.GTIN, ""); ok {
+		meta.ISBN = isbn
+	}
+	if meta.Title == "" && meta.Series != "" {
+		meta.Title = meta.Series
+		if info.Number != "" {
+			meta.Title += " " + strings.TrimSpace(info.Number)
+		}
+	}
+	if n, err := strconv.ParseFloat(strings.TrimSpace(info.Number), 64); err == nil {
+		meta.SeriesIndex = n
+	}
+	if info.Year > 0 {
+		meta.PublishedDate = strconv.Itoa(info.Year)
+		if info.Month > 0 {
+			meta.PublishedDate += fmt.Sprintf("-%02d", info.Month)
+			if info.Day > 0 {
+				meta.PublishedDate += fmt.Sprintf("-%02d", info.Day)
+			}
+		}
+	}
+	return meta, nil
+}
+
+// ExtractCover returns the first page
+func (p *CbzParser) ExtractCover(filePath string) ([]byte, error) {
+	rc, err := p.OpenPage(filePath, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// OpenResource opens a page by the name chapters refer to it with ("pages/<n>")
+func (p *CbzParser) OpenResource(filePath, name string) (io.ReadCloser, error) {
+	dir, file := path.Split(path.Clean("/" + name))
+	page, err := strconv.Atoi(file)
+	if dir != "/pages/" || err != nil {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+	return p.OpenPage(filePath, page)
+}
+
+// OpenPage opens the page image with the given 1-based number
+func (p *CbzParser) OpenPage(filePath string, page int) (io.ReadCloser, error) {
+	reader, err := openComic(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	pages := comicPages(&reader.Reader)
+	if page < 1 || page > len(pages) {
+		return nil, fmt.Errorf("page not found: %d", page)
+	}
+	data, err := readZipFile(pages[page-1])
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// openComic opens a comic archive; RAR-based .cbr files are rejected with a clear error
+func openComic(filePath string) (*zip.ReadCloser, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		if strings.EqualFold(filepath.Ext(filePath), ".cbr") {
+			return nil, fmt.Errorf("only zip-based cbr archives are supported: %w", err)
+		}
+		return nil, fmt.Errorf("failed to open comic archive: %w", err)
+	}
+	return reader, nil
+}
+
+// comicPages returns the page images of the archive in natural order, skipping
+// hidden files and macOS resource forks
+func comicPages(r *zip.Reader) []*zip.File {
+	var pages []*zip.File
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !comicImageExts[strings.ToLower(path.Ext(f.Name))] {
+			continue
+		}
+		hidden := false
+		for _, part := range strings.Split(f.Name, "/") {
+			if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+				hidden = true
+			}
+		}
+		if !hidden {
+			pages = append(pages, f)
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return naturalLess(strings.ToLower(pages[i].Name), strings.ToLower(pages[j].Name))
+	})
+	return pages
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testComicInfo = `<?xml version="1.0" encoding="utf-8"?>
+<ComicInfo xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+  <Series>Test Saga</Series>
+  <Number>3</Number>
+  <Summary>Dragons return.</Summary>
+  <Year>2019</Year>
+  <Month>7</Month>
+  <Writer>Jane Doe</Writer>
+  <Penciller>John Roe</Penciller>
+  <Publisher>Test House</Publisher>
+  <Genre>Fantasy, Adventure</Genre>
+  <LanguageISO>en</LanguageISO>
+</ComicInfo>`
+
+func TestCbzParser_Parse(t *testing.T) {
+	filePath := writeTestZip(t, "saga.cbz", map[string]string{
+		"Saga/cover.jpg":             "cover",
+		"Saga/Chapter 2/page10.png":  "c2p10",
+		"Saga/Chapter 2/page9.png":   "c2p9",
+		"Saga/Chapter 1/page1.png":   "c1p1",
+		"Saga/Chapter 10/page1.png":  "c10p1",
+		"Saga/Chapter 1/.hidden.png": "hidden",
+		"__MACOSX/Saga/._cover.jpg":  "fork",
+		"Saga/ComicInfo.xml":         testComicInfo,
+		"Saga/Chapter 1/notes.txt":   "not a page",
+	})
+
+	p := NewCbzParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"})
+	chapters, err := p.Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 4)
+
+	assert.Equal(t, "Chapter 1", chapters[0].Title)
+	assert.Equal(t, `<img alt="" src="/api/books/b1/resources/pages/1">`, chapters[0].ContentHTML)
+	assert.Equal(t, "Chapter 2", chapters[1].Title)
+	assert.Equal(t, `<img alt="" src="/api/books/b1/resources/pages/2">`+"\n"+`<img alt="" src="/api/books/b1/resources/pages/3">`, chapters[1].ContentHTML)
+	assert.Equal(t, "Chapter 10", chapters[2].Title)
+	assert.Equal(t, frontMatterTitle, chapters[3].Title)
+
+	pages := map[int]string{1: "c1p1", 2: "c2p9", 3: "c2p10", 4: "c10p1", 5: "cover"}
+	for page, want := range pages {
+		rc, err := p.OpenPage(filePath, page)
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		assert.Equal(t, want, string(data), "page %d", page)
+	}
+	_, err = p.OpenPage(filePath, 6)
+	assert.Error(t, err)
+
+	rc, err := p.OpenResource(filePath, "pages/2")
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "c2p9", string(data))
+}
+
+func TestCbzParser_FlatArchive(t *testing.T) {
+	filePath := writeTestZip(t, "One Shot.cbz", map[string]string{
+		"002.jpg": "b",
+		"001.jpg": "a",
+	})
+
+	chapters, err := NewCbzParserWithOptions(Options{ResourceBaseURL: "/r/"}).Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Equal(t, "One Shot", chapters[0].Title)
+
+	cover, err := NewCbzParser().ExtractCover(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(cover))
+}
+
+func TestCbzParser_ExtractMetadata(t *testing.T) {
+	filePath := writeTestZip(t, "saga.cbz", map[string]string{
+		"001.jpg":       "a",
+		"ComicInfo.xml": testComicInfo,
+	})
+
+	meta, err := NewCbzParser().ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Saga 3", meta.Title)
+	assert.Equal(t, "Test Saga", meta.Series)
+	assert.Equal(t, 3.0, meta.SeriesIndex)
+	assert.Equal(t, "Jane Doe", meta.Author)
+	assert.Equal(t, "Dragons return.", meta.Description)
+	assert.Equal(t, "Test House", meta.Publisher)
+	assert.Equal(t, "2019-07", meta.PublishedDate)
+	assert.Equal(t, "en", meta.Language)
+	assert.Equal(t, []string{"Fantasy", "Adventure"}, meta.Subjects)
+}
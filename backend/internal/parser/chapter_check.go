@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// hugeChapterWords is the word count above which a chapter probably swallowed missed titles
+const hugeChapterWords = 30000
+
+// numberedTitlePattern captures the number a chapter title declares ("第十二章", "Chapter 12", "12.")
+var numberedTitlePattern = regexp.MustCompile(`(?i)^[【\[]?(?:第\s*(` + cjkNumber + `)\s*[章回节節话話]|(?:chapter|ch\.)\s*(\d+)\b|(\d{1,5})\s*[.．、])`)
+
+// ChapterWarnings lists likely splitting mistakes: oversized chapters, numbering issues
+// and repeated titles
+func ChapterWarnings(chapters []models.Chapter) []string {
+	var warnings []string
+	summaries := make([]models.ChapterSummary, len(chapters))
+	for i, ch := range chapters {
+		summaries[i] = models.ChapterSummary{
+			ChapterNumber:       ch.ChapterNumber,
+			VolumeNumber:        ch.VolumeNumber,
+			VolumeChapterNumber: ch.VolumeChapterNumber,
+			Title:               ch.Title,
+			WordCount:           ch.WordCount,
+		}
+		if ch.WordCount > hugeChapterWords {
+			warnings = append(warnings, fmt.Sprintf("chapter %d %q has %d words; a chapter title may have been missed", ch.ChapterNumber, strings.TrimSpace(ch.Title), ch.WordCount))
+		}
+	}
+
+	for _, issue := range CheckNumbering(summaries).Issues {
+		warnings = append(warnings, issue.Message)
+	}
+
+	// Numbered titles were covered above
+	seen := make(map[string]int)
+	for _, ch := range summaries {
+		title := strings.TrimSpace(ch.Title)
+		if title == "" || ch.VolumeChapterNumber == 0 || ChapterTitleNumber(title) > 0 {
+			continue
+		}
+		if first, ok := seen[title]; ok {
+			warnings = append(warnings, fmt.Sprintf("chapter %d repeats the title of chapter %d: %q", ch.ChapterNumber, first, title))
+		} else {
+			seen[title] = ch.ChapterNumber
+		}
+	}
+	return warnings
+}
+
+// ChapterTitleNumber returns the number a chapter title declares, or 0 if it declares none
+func ChapterTitleNumber(title string) int {
+	m := numberedTitlePattern.FindStringSubmatch(strings.TrimSpace(title))
+	if m == nil {
+		return 0
+	}
+	for _, group := range m[1:] {
+		if group != "" {
+			return parseNumeral(group)
+		}
+	}
+	return 0
+}
+
+// numberedChapter is a chapter whose title declares a number
+type numberedChapter struct {
+	chapter  models.ChapterSummary
+	declared int
+}
+
+// CheckNumbering reports missing, repeated and out-of-order chapter numbers. Numbering is checked
+// per run: a volume page, or a chapter declaring 1 again, starts a new run, since many books
+// number chapters per volume.
+func CheckNumbering(chapters []models.ChapterSummary) *models.NumberingReport {
+	report := &models.NumberingReport{ChapterCount: len(chapters), Issues: []models.NumberingIssue{}}
+
+	var run []numberedChapter
+	flush := func() {
+		report.Issues = append(report.Issues, checkRun(run)...)
+		run = nil
+	}
+	for _, ch := range chapters {
+		if ch.VolumeChapterNumber == 0 {
+			flush()
+			continue
+		}
+		n := ChapterTitleNumber(ch.Title)
+		if n == 0 {
+			continue
+		}
+		report.NumberedCount++
+		if n == 1 && len(run) > 0 {
+			flush()
+		}
+		run = append(run, numberedChapter{chapter: ch, declared: n})
+	}
+	flush()
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].ChapterNumber < report.Issues[j].ChapterNumber
+	})
+	return report
+}
+
+// checkRun finds the issues within one run of consecutively numbered chapters
+func checkRun(run []numberedChapter) []models.NumberingIssue {
+	var issues []models.NumberingIssue
+	first := make(map[int]models.ChapterSummary)
+	highest := 0
+
+	issue := func(kind string, nc numberedChapter, message string) models.NumberingIssue {
+		return models.NumberingIssue{
+			Kind:          kind,
+			ChapterNumber: nc.chapter.ChapterNumber,
+			VolumeNumber:  nc.chapter.VolumeNumber,
+			Title:         nc.chapter.Title,
+			Declared:      nc.declared,
+			Message:       message,
+		}
+	}
+
+	for _, nc := range run {
+		if prev, ok := first[nc.declared]; ok {
+			issues = append(issues, issue(models.NumberingDuplicate, nc,
+				fmt.Sprintf("chapter %d %q repeats number %d of chapter %d %q", nc.chapter.ChapterNumber, nc.chapter.Title, nc.declared, prev.ChapterNumber, prev.Title)))
+			continue
+		}
+		first[nc.declared] = nc.chapter
+		if nc.declared < highest {
+			issues = append(issues, issue(models.NumberingOutOfOrder, nc,
+				fmt.Sprintf("chapter %d %q (number %d) comes after number %d", nc.chapter.ChapterNumber, nc.chapter.Title, nc.declared, highest)))
+			continue
+		}
+		highest = nc.declared
+	}
+
+	if len(run) == 0 {
+		return issues
+	}
+	// Gaps between neighbouring numbers from the run's first to its highest
+	var numbers []int
+	for n := range first {
+		if n >= run[0].declared && n <= highest {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	for i := 1; i < len(numbers); i++ {
+		from, to := numbers[i-1]+1, numbers[i]-1
+		if from > to {
+			continue
+		}
+		// The gap shows at the first chapter numbered past it
+		var next numberedChapter
+		for _, nc := range run {
+			if nc.declared > to {
+				next = nc
+				break
+			}
+		}
+		missing := issue(models.NumberingMissing, next, missingMessage(from, to, next))
+		missing.MissingFrom, missing.MissingTo = from, to
+		issues = append(issues, missing)
+	}
+	return issues
+}
+
+func missingMessage(from, to int, next numberedChapter) string {
+	if from == to {
+		return fmt.Sprintf("chapter number %d is missing before chapter %d %q", from, next.chapter.ChapterNumber, next.chapter.Title)
+	}
+	return fmt.Sprintf("chapter numbers %d-%d are missing before chapter %d %q", from, to, next.chapter.ChapterNumber, next.chapter.Title)
+}
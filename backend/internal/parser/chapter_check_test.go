@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+func TestChapterTitleNumber(t *testing.T) {
+	tests := map[string]int{
+		"第十二章 重逢":         12,
+		"【第3回】":           3,
+		"第１２章":            12,
+		"第一〇二章":           102,
+		"第一千零一章":          1001,
+		"第一万零五章":          10005,
+		"第两百章":            200,
+		"第贰拾壹章":           21,
+		"Chapter 7: Home": 7,
+		"Ch.8":            8,
+		"042. Answer":     42,
+		"序章":              0,
+		"Prologue":        0,
+		// Numbers past maxNumeral are not chapter numbers
+		"Chapter 99999999999":             0,
+		"Chapter 99999999999999999999999": 0,
+		"第一二三四五六七八九一二三四五六七八九章":            0,
+	}
+	for title, want := range tests {
+		assert.Equal(t, want, ChapterTitleNumber(title), title)
+	}
+}
+
+func TestChineseNumeralToInt(t *testing.T) {
+	assert.Equal(t, 10, chineseNumeralToInt("十"))
+	assert.Equal(t, 15, chineseNumeralToInt("十五"))
+	assert.Equal(t, 103, chineseNumeralToInt("一百零三"))
+	assert.Equal(t, 1010, chineseNumeralToInt("一千零十"))
+	assert.Equal(t, 32000, chineseNumeralToInt("三万二千"))
+	assert.Equal(t, 120000000, chineseNumeralToInt("一亿二千万"))
+	assert.Equal(t, 2024, chineseNumeralToInt("二〇二四"))
+}
+
+func TestCheckNumbering(t *testing.T) {
+	titles := []string{"第一章", "第二章", "第五章", "第五章", "第四章", "第七章"}
+	var chapters []models.ChapterSummary
+	for i, title := range titles {
+		chapters = append(chapters, models.ChapterSummary{ChapterNumber: i + 1, VolumeNumber: 1, VolumeChapterNumber: i + 1, Title: title})
+	}
+
+	report := CheckNumbering(chapters)
+	assert.Equal(t, 6, report.NumberedCount)
+	require.Len(t, report.Issues, 4)
+
+	assert.Equal(t, models.NumberingMissing, report.Issues[0].Kind)
+	assert.Equal(t, 3, report.Issues[0].ChapterNumber)
+	assert.Equal(t, 3, report.Issues[0].MissingFrom)
+	assert.Equal(t, 3, report.Issues[0].MissingTo)
+	assert.Equal(t, models.NumberingDuplicate, report.Issues[1].Kind)
+	assert.Equal(t, 4, report.Issues[1].ChapterNumber)
+	assert.Equal(t, models.NumberingOutOfOrder, report.Issues[2].Kind)
+	assert.Equal(t, 4, report.Issues[2].Declared)
+	assert.Equal(t, models.NumberingMissing, report.Issues[3].Kind)
+	assert.Equal(t, 6, report.Issues[3].MissingFrom)
+}
+
+func TestCheckNumbering_WideGap(t *testing.T) {
+	chapters := []models.ChapterSummary{
+		{ChapterNumber: 1, VolumeNumber: 1, VolumeChapterNumber: 1, Title: "Chapter 1"},
+		{ChapterNumber: 2, VolumeNumber: 1, VolumeChapterNumber: 2, Title: "Chapter 99999"},
+		{ChapterNumber: 3, VolumeNumber: 1, VolumeChapterNumber: 3, Title: "Chapter 99999999999"},
+	}
+
+	report := CheckNumbering(chapters)
+	assert.Equal(t, 2, report.NumberedCount)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, models.NumberingMissing, report.Issues[0].Kind)
+	assert.Equal(t, 2, report.Issues[0].ChapterNumber)
+	assert.Equal(t, 2, report.Issues[0].MissingFrom)
+	assert.Equal(t, 99998, report.Issues[0].MissingTo)
+}
+
+func TestCheckNumbering_Volumes(t *testing.T) {
+	chapters := []models.ChapterSummary{
+		{ChapterNumber: 1, VolumeNumber: 1, Title: "第一卷"},
+		{ChapterNumber: 2, VolumeNumber: 1, VolumeChapterNumber: 1, Title: "第一章"},
+		{ChapterNumber: 3, VolumeNumber: 1, VolumeChapterNumber: 2, Title: "第二章"},
+		{ChapterNumber: 4, VolumeNumber: 2, Title: "第二卷"},
+		{ChapterNumber: 5, VolumeNumber: 2, VolumeChapterNumber: 1, Title: "第一章"},
+		{ChapterNumber: 6, VolumeNumber: 2, VolumeChapterNumber: 2, Title: "番外"},
+		{ChapterNumber: 7, VolumeNumber: 2, VolumeChapterNumber: 3, Title: "第二章"},
+	}
+
+	report := CheckNumbering(chapters)
+	assert.Equal(t, 4, report.NumberedCount)
+	assert.Empty(t, report.Issues)
+}
+
+func TestChapterWarnings(t *testing.T) {
+	chapters := []models.Chapter{
+		{ChapterNumber: 1, VolumeChapterNumber: 1, Title: "第一章", Content: "a", WordCount: 10},
+		{ChapterNumber: 2, VolumeChapterNumber: 2, Title: "第二章", Content: "b", WordCount: hugeChapterWords + 1},
+		{ChapterNumber: 3, VolumeChapterNumber: 3, Title: "第五章", Content: "c", WordCount: 10},
+		{ChapterNumber: 4, VolumeChapterNumber: 4, Title: "番外", Content: "d", WordCount: 10},
+		{ChapterNumber: 5, VolumeChapterNumber: 5, Title: "番外", Content: "e", WordCount: 10},
+	}
+
+	warnings := ChapterWarnings(chapters)
+	require.Len(t, warnings, 3)
+	assert.Contains(t, warnings[0], "has 30001 words")
+	assert.Contains(t, warnings[1], "numbers 3-4 are missing")
+	assert.Contains(t, warnings[2], "repeats the title of chapter 4")
+
+	assert.Empty(t, ChapterWarnings(chapters[:1]))
+}
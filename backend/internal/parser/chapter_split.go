@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	xhtml "golang.org/x/net/html"
+
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/textstat"
+)
+
+// DefaultSplitChapterWords is the part size oversized chapters are split into when Options leaves it unset
+const DefaultSplitChapterWords = 10000
+
+// splitWords returns the part size chapters are split into; 0 means chapters are never split
+func (o Options) splitWords() int {
+	switch {
+	case o.SplitChapterWords < 0:
+		return 0
+	case o.SplitChapterWords == 0:
+		return DefaultSplitChapterWords
+	}
+	return o.SplitChapterWords
+}
+
+// chapterPiece is a paragraph (or other top-level block) a chapter can be split after
+type chapterPiece struct {
+	text  string
+	html  string
+	words int
+}
+
+// splitChapter splits a chapter of more than 1.5 times target words at paragraph boundaries
+// into parts of about target words, titled "<title> (1/3)" and so on. A chapter made of a
+// single paragraph is kept whole.
+func splitChapter(ch models.Chapter, target int) []models.Chapter {
+	var pieces []chapterPiece
+	if ch.ContentHTML != "" {
+		pieces = htmlPieces(ch.ContentHTML)
+	} else {
+		for _, line := range strings.SplitAfter(ch.Content, "\n") {
+			if line != "" {
+				pieces = append(pieces, chapterPiece{text: line, words: textstat.WordCount(line)})
+			}
+		}
+	}
+
+	groups := groupPieces(pieces, target)
+	if len(groups) < 2 {
+		return []models.Chapter{ch}
+	}
+
+	parts := make([]models.Chapter, len(groups))
+	for i, group := range groups {
+		part := ch
+		part.ID = uuid.New().String()
+		if ch.Title != "" {
+			part.Title = fmt.Sprintf("%s (%d/%d)", ch.Title, i+1, len(groups))
+		}
+		if ch.ContentHTML != "" {
+			var texts, htmls []string
+			for _, p := range group {
+				if p.text != "" {
+					texts = append(texts, p.text)
+				}
+				htmls = append(htmls, p.html)
+			}
+			part.Content = strings.Join(texts, "\n")
+			part.ContentHTML = strings.Join(htmls, "")
+			part.Footnotes = nil
+			for _, fn := range ch.Footnotes {
+				if strings.Contains(part.ContentHTML, `data-footnote="`+fn.ID+`"`) {
+					part.Footnotes = append(part.Footnotes, fn)
+				}
+			}
+		} else {
+			var sb strings.Builder
+			for _, p := range group {
+				sb.WriteString(p.text)
+			}
+			part.Content = sb.String()
+		}
+		part.WordCount = textstat.WordCount(part.Content)
+		parts[i] = part
+	}
+	return parts
+}
+
+// groupPieces fills groups up to target words; a short remainder joins the last full group
+func groupPieces(pieces []chapterPiece, target int) [][]chapterPiece {
+	var groups [][]chapterPiece
+	var current []chapterPiece
+	words := 0
+	for _, p := range pieces {
+		current = append(current, p)
+		words += p.words
+		if words >= target {
+			groups = append(groups, current)
+			current, words = nil, 0
+		}
+	}
+	if len(current) > 0 {
+		if len(groups) > 0 && words < target/3 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], current...)
+		} else {
+			groups = append(groups, current)
+		}
+	}
+	return groups
+}
+
+// htmlPieces splits sanitized chapter HTML into its top-level blocks, looking through
+// a <div> that wraps the whole chapter
+func htmlPieces(fragment string) []chapterPiece {
+	nodes := parseHTMLFragment(fragment)
+	for {
+		var elements []*xhtml.Node
+		for _, n := range nodes {
+			if n.Type != xhtml.TextNode || strings.TrimSpace(n.Data) != "" {
+				elements = append(elements, n)
+			}
+		}
+		if len(elements) != 1 || elements[0].Type != xhtml.ElementNode || elements[0].Data != "div" {
+			break
+		}
+		nodes = childNodes(elements[0])
+	}
+
+	pieces := make([]chapterPiece, 0, len(nodes))
+	for _, n := range nodes {
+		var buf bytes.Buffer
+		if err := xhtml.Render(&buf, n); err != nil {
+			continue
+		}
+		text := renderText(buf.String(), nil)
+		pieces = append(pieces, chapterPiece{text: text, html: buf.String(), words: textstat.WordCount(text)})
+	}
+	return pieces
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+func TestTxtParser_SplitsLongChapters(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&sb, "Paragraph %d has exactly six words.\n", i)
+	}
+	filePath := writeTestFile(t, "book.txt", []byte(sb.String()))
+
+	chapters, err := NewTxtParserWithOptions(Options{SplitChapterWords: 100}).Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 3)
+	for i, ch := range chapters {
+		assert.Equal(t, fmt.Sprintf("Chapter %d", i+1), ch.Title)
+		assert.Equal(t, i+1, ch.ChapterNumber)
+		assert.Equal(t, i+1, ch.VolumeChapterNumber)
+	}
+	assert.Equal(t, 102, chapters[0].WordCount)
+	assert.True(t, strings.HasPrefix(chapters[1].Content, "Paragraph 17 "))
+	assert.Equal(t, sb.String(), chapters[0].Content+chapters[1].Content+chapters[2].Content)
+
+	chapters, err = NewTxtParserWithOptions(Options{SplitChapterWords: -1}).Parse(filePath)
+	require.NoError(t, err)
+	assert.Len(t, chapters, 1)
+}
+
+// sinkChapters passes chapters through a chapterSink splitting at target words
+func sinkChapters(t *testing.T, chapters []models.Chapter, target int) []models.Chapter {
+	t.Helper()
+	var result []models.Chapter
+	sink := newChapterSink(context.Background(), target, func(ch models.Chapter, _ Progress) error {
+		result = append(result, ch)
+		return nil
+	})
+	for _, ch := range chapters {
+		require.NoError(t, sink.add(ch, Progress{}))
+	}
+	return result
+}
+
+func TestChapterSink_SplitsHTML(t *testing.T) {
+	paragraph := "<p>" + strings.Repeat("word ", 40) + "</p>"
+	chapters := []models.Chapter{
+		{ChapterNumber: 1, VolumeNumber: 1, VolumeChapterNumber: 0, Title: "Part One"},
+		{
+			ChapterNumber: 2, VolumeNumber: 1, VolumeChapterNumber: 1, Title: "Long",
+			ContentHTML: `<div>` + paragraph + paragraph + `<p>See<a data-footnote="n1">1</a></p>` + paragraph + `</div>`,
+			Footnotes:   models.Footnotes{{ID: "n1", ContentHTML: "<p>A note.</p>"}},
+			WordCount:   121,
+		},
+		{ChapterNumber: 3, VolumeNumber: 1, VolumeChapterNumber: 2, Title: "Short", Content: "Tiny.", WordCount: 1},
+	}
+
+	result := sinkChapters(t, chapters, 80)
+	require.Len(t, result, 4)
+	assert.Equal(t, "Part One", result[0].Title)
+	assert.Equal(t, "Long (1/2)", result[1].Title)
+	assert.Equal(t, 80, result[1].WordCount)
+	assert.Empty(t, result[1].Footnotes)
+	assert.Equal(t, "Long (2/2)", result[2].Title)
+	assert.Contains(t, result[2].ContentHTML, `data-footnote="n1"`)
+	require.Len(t, result[2].Footnotes, 1)
+	assert.Equal(t, 2, result[2].VolumeChapterNumber)
+	assert.Equal(t, "Short", result[3].Title)
+	assert.Equal(t, 4, result[3].ChapterNumber)
+	assert.Equal(t, 3, result[3].VolumeChapterNumber)
+
+	assert.Equal(t, chapters, sinkChapters(t, chapters, 200))
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// sniffLength is how much of a file DetectFormat looks at for signatures and text heuristics
+const sniffLength = 8192
+
+// markdownSignals match lines that are typical of Markdown and rare in plain text
+var markdownSignals = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^#{1,6}\s+\S`),
+	regexp.MustCompile("(?m)^```"),
+	regexp.MustCompile(`(?m)^\s*[-*+]\s+\S`),
+	regexp.MustCompile(`(?m)^>\s`),
+	regexp.MustCompile(`\[[^\]\n]+\]\([^)\s]+\)`),
+	regexp.MustCompile(`\*\*[^*\n]+\*\*|__[^_\n]+__`),
+	regexp.MustCompile(`(?m)^(?:-{3,}|={3,})\s*$`),
+}
+
+// formatFamilies maps format names and aliases to the format whose parser reads them.
+// Keep it in step with the file_format lists in models.Book and models.CreateBookRequest
+// and the file extensions the desktop app's open dialog offers.
+var formatFamilies = map[string]string{
+	"markdown": "md", "azw": "mobi", "azw3": "mobi", "htm": "html", "mht": "mhtml", "cbr": "cbz", "zip": "fb2",
+}
+
+// DetectFormat identifies a book file by its content: magic bytes, the entries of zip
+// containers, XML roots and, for text, Markdown heuristics. It returns "" if the content
+// matches none of the supported formats.
+func DetectFormat(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return detectZipFormat(filePath)
+	case bytes.Contains(head[:min(len(head), 1024)], []byte("%PDF-")):
+		return "pdf", nil
+	case len(head) >= 68 && (string(head[60:68]) == "BOOKMOBI" || string(head[60:68]) == "TEXtREAd"):
+		return "mobi", nil
+	case isMHTML(head):
+		return "mhtml", nil
+	}
+
+	if bytes.IndexByte(head, 0) >= 0 && !hasUTF16BOM(head) {
+		return "", nil
+	}
+	return detectTextFormat(head), nil
+}
+
+// SameFormat reports whether two format names are read by the same parser (e.g. "azw3" and "mobi").
+// Plain text and Markdown count as the same: telling them apart is only a heuristic.
+func SameFormat(a, b string) bool {
+	a, b = formatFamily(a), formatFamily(b)
+	if a == b {
+		return true
+	}
+	text := map[string]bool{"txt": true, "md": true}
+	return text[a] && text[b]
+}
+
+func formatFamily(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if family, ok := formatFamilies[format]; ok {
+		return family
+	}
+	return format
+}
+
+func detectZipFormat(filePath string) (string, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	if f := findFileInZip(&reader.Reader, "mimetype"); f != nil {
+		data, err := readZipFile(f)
+		if err == nil {
+			switch strings.TrimSpace(string(data)) {
+			case "application/epub+zip":
+				return "epub", nil
+			case "application/vnd.oasis.opendocument.text":
+				return "odt", nil
+			}
+		}
+	}
+	if findFileInZip(&reader.Reader, "META-INF/container.xml") != nil {
+		return "epub", nil
+	}
+	if findFileInZip(&reader.Reader, "word/document.xml") != nil {
+		return "docx", nil
+	}
+	for _, f := range reader.File {
+		if strings.EqualFold(path.Ext(f.Name), ".fb2") {
+			return "fb2", nil
+		}
+	}
+	if len(comicPages(&reader.Reader)) > 0 {
+		return "cbz", nil
+	}
+	return "", nil
+}
+
+// detectTextFormat tells markup documents from plain text and Markdown
+func detectTextFormat(head []byte) string {
+	text := decodeHead(head)
+	trimmed := strings.ToLower(strings.TrimSpace(text))
+
+	if strings.HasPrefix(trimmed, "<") {
+		switch {
+		case strings.Contains(trimmed, "<fictionbook"):
+			return "fb2"
+		case strings.HasPrefix(trimmed, "<!doctype html") || strings.Contains(trimmed, "<html") || strings.Contains(trimmed, "<body"):
+			return "html"
+		}
+	}
+
+	signals := 0
+	for _, pattern := range markdownSignals {
+		if pattern.MatchString(text) {
+			signals++
+		}
+	}
+	if signals >= 2 {
+		return "md"
+	}
+	return "txt"
+}
+
+// decodeHead converts a sample to UTF-8 (honouring a UTF-16 BOM) so the heuristics can read it
+func decodeHead(head []byte) string {
+	if hasUTF16BOM(head) {
+		head = head[:len(head)&^1]
+	}
+	decoded, _, err := transform.Bytes(unicode.BOMOverride(unicode.UTF8.NewDecoder()), head)
+	if err != nil {
+		return string(head)
+	}
+	return string(decoded)
+}
+
+func hasUTF16BOM(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0xFF, 0xFE}) || bytes.HasPrefix(head, []byte{0xFE, 0xFF})
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+func TestDetectFormat(t *testing.T) {
+	mobi := make([]byte, 100)
+	copy(mobi[60:], "BOOKMOBI")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"epub", writeTestZip(t, "book.bin", map[string]string{"mimetype": "application/epub+zip", "OEBPS/a.xhtml": "<html/>"}), "epub"},
+		{"odt", writeTestZip(t, "book.zip", map[string]string{"mimetype": "application/vnd.oasis.opendocument.text", "content.xml": "<x/>"}), "odt"},
+		{"docx", writeTestZip(t, "book", map[string]string{"word/document.xml": "<x/>"}), "docx"},
+		{"zipped fb2", writeTestZip(t, "book.zip", map[string]string{"book.fb2": testFB2}), "fb2"},
+		{"comic", writeTestZip(t, "book.zip", map[string]string{"01.jpg": "a"}), "cbz"},
+		{"unknown zip", writeTestZip(t, "book.zip", map[string]string{"readme.txt": "a"}), ""},
+		{"pdf", writeTestFile(t, "book.txt", []byte("%PDF-1.7\n%âãÏÓ\n1 0 obj")), "pdf"},
+		{"mobi", writeTestFile(t, "book", mobi), "mobi"},
+		{"fb2", writeTestFile(t, "book.xml", []byte(testFB2)), "fb2"},
+		{"html", writeTestFile(t, "book.txt", []byte("<!DOCTYPE html>\n<html><body><p>Hi</p></body></html>")), "html"},
+		{"markdown", writeTestFile(t, "book", []byte("# Title\n\nSome **bold** text.\n\n- item\n- item\n")), "md"},
+		{"text", writeTestFile(t, "book.md", []byte("第一章 开始\n\n天色微明，村庄还在沉睡之中。\n")), "txt"},
+		{"binary", writeTestFile(t, "book.txt", []byte{0x89, 'P', 'N', 'G', 0, 0, 0, 0x0d}), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSameFormat(t *testing.T) {
+	assert.True(t, SameFormat("azw3", "mobi"))
+	assert.True(t, SameFormat("HTM", "html"))
+	assert.True(t, SameFormat("txt", "md"))
+	assert.False(t, SameFormat("epub", "docx"))
+	assert.False(t, SameFormat("txt", "html"))
+}
+
+func TestFileFormatsHaveParsers(t *testing.T) {
+	field, ok := reflect.TypeOf(models.CreateBookRequest{}).FieldByName("FileFormat")
+	require.True(t, ok)
+	_, list, ok := strings.Cut(field.Tag.Get("validate"), "oneof=")
+	require.True(t, ok)
+	formats := strings.Fields(list)
+
+	for _, format := range formats {
+		_, err := GetParser(format)
+		assert.NoError(t, err, format)
+	}
+	// Every alias a parser accepts can be sent as file_format
+	for alias := range formatFamilies {
+		assert.Contains(t, formats, alias)
+	}
+}
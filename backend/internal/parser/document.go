@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// docBlock is a top-level block of a word-processor document: a paragraph, a heading
+// (Level 1-9) or a table, already rendered as HTML
+type docBlock struct {
+	Level int
+	Title string // plain text of a heading
+	HTML  string
+}
+
+// textFormat is the character formatting of a run of text
+type textFormat struct {
+	bold, italic, underline, strike bool
+}
+
+// wrap encloses HTML in the elements for the format
+func (f textFormat) wrap(html string) string {
+	if html == "" {
+		return ""
+	}
+	if f.strike {
+		html = "<s>" + html + "</s>"
+	}
+	if f.underline {
+		html = "<u>" + html + "</u>"
+	}
+	if f.italic {
+		html = "<em>" + html + "</em>"
+	}
+	if f.bold {
+		html = "<strong>" + html + "</strong>"
+	}
+	return html
+}
+
+// headingBlock renders a heading paragraph; Level 0 is a normal paragraph
+func headingBlock(level int, inner string) docBlock {
+	title := strings.Join(strings.Fields(htmlToText(inner)), " ")
+	if level <= 0 || title == "" {
+		return docBlock{HTML: "<p>" + inner + "</p>"}
+	}
+	tag := "h" + strconv.Itoa(min(level, 6))
+	return docBlock{Level: level, Title: title, HTML: "<" + tag + ">" + inner + "</" + tag + ">"}
+}
+
+// headingSections groups document blocks into chapters: Heading 1 starts a volume and
+// Heading 2 a chapter. Documents without any Heading 2 use Heading 1 for chapters.
+// Deeper headings stay in the chapter text; text before the first heading is front matter,
+// or, in a document without headings, a single chapter named title.
+func headingSections(blocks []docBlock, docPath, title string) []epubSection {
+	volumeLevel, chapterLevel := 1, 2
+	hasHeading, hasChapterHeading := false, false
+	for _, b := range blocks {
+		hasHeading = hasHeading || b.Level > 0
+		hasChapterHeading = hasChapterHeading || b.Level == 2
+	}
+	if !hasChapterHeading {
+		volumeLevel, chapterLevel = 0, 1
+	}
+	leadTitle := frontMatterTitle
+	if !hasHeading && title != "" {
+		leadTitle = title
+	}
+
+	var sections []*epubSection
+	var current *epubSection
+	for _, b := range blocks {
+		switch {
+		case b.Level > 0 && b.Level == volumeLevel:
+			current = &epubSection{Title: b.Title, Volume: true}
+			sections = append(sections, current)
+		case b.Level > 0 && b.Level == chapterLevel:
+			current = &epubSection{Title: b.Title}
+			sections = append(sections, current)
+		default:
+			if current == nil || current.Volume {
+				if isBlankHTML(b.HTML) {
+					continue
+				}
+				if current == nil {
+					current = &epubSection{Title: leadTitle}
+					sections = append(sections, current)
+				}
+			}
+			current.add(docPath, b.HTML)
+		}
+	}
+
+	result := make([]epubSection, 0, len(sections))
+	for _, sec := range sections {
+		result = append(result, *sec)
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// DocxParser parses Word (.docx) documents
+type DocxParser struct{}
+
+// NewDocxParser creates a new DocxParser
+func NewDocxParser() *DocxParser {
+	return &DocxParser{}
+}
+
+// Parse reads word/document.xml and splits it into chapters at heading-styled paragraphs
+func (p *DocxParser) Parse(filePath string) ([]models.Chapter, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open docx: %w", err)
+	}
+	defer reader.Close()
+
+	doc, err := readZipXML(&reader.Reader, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	// Styles are optional; without them only direct outline levels mark headings
+	styles, _ := readZipXML(&reader.Reader, "word/styles.xml")
+
+	d := &docxReader{headingLevels: docxHeadingLevels(styles)}
+	blocks := d.blocks(doc.child("document").child("body"))
+
+	title := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	chapters := sectionsToChapters(headingSections(blocks, "document.xml", title), "", nil)
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no text found in docx")
+	}
+	return chapters, nil
+}
+
+// ExtractMetadata reads the document properties (docProps/core.xml)
+func (p *DocxParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open docx: %w", err)
+	}
+	defer reader.Close()
+
+	core, err := readZipXML(&reader.Reader, "docProps/core.xml")
+	if err != nil {
+		return nil, err
+	}
+	props := core.child("coreProperties")
+
+	return &BookMetadata{
+		Title:         strings.TrimSpace(props.child("title").text()),
+		Author:        strings.TrimSpace(props.child("creator").text()),
+		Description:   strings.TrimSpace(props.child("description").text()),
+		Language:      strings.TrimSpace(props.child("language").text()),
+		PublishedDate: normalizeDate(strings.TrimSpace(props.child("created").text())),
+		Subjects:      splitSubjects([]string{props.child("subject").text(), props.child("keywords").text()}),
+	}, nil
+}
+
+// readZipXML parses an XML file inside a zip container
+func readZipXML(r *zip.Reader, name string) (*xmlNode, error) {
+	f := findFileInZip(r, name)
+	if f == nil {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return root, nil
+}
+
+// docxHeadingLevels maps paragraph style IDs to heading levels. Style IDs are localized
+// ("Heading1", "Titre1", "1"), so levels come from the style name or outline level,
+// inherited through basedOn.
+func docxHeadingLevels(styles *xmlNode) map[string]int {
+	type styleInfo struct {
+		level   int
+		basedOn string
+	}
+	infos := make(map[string]styleInfo)
+	for _, s := range styles.child("styles").children("style") {
+		if s.attr("type") != "paragraph" {
+			continue
+		}
+		info := styleInfo{basedOn: s.child("basedOn").attr("val")}
+		name := strings.ToLower(s.child("name").attr("val"))
+		if n, err := strconv.Atoi(strings.TrimPrefix(name, "heading ")); err == nil && strings.HasPrefix(name, "heading ") {
+			info.level = n
+		} else if lvl, err := strconv.Atoi(s.child("pPr").child("outlineLvl").attr("val")); err == nil && lvl < 9 {
+			info.level = lvl + 1
+		}
+		infos[s.attr("styleId")] = info
+	}
+
+	levels := make(map[string]int)
+	for id := range infos {
+		for cur, depth := id, 0; cur != "" && depth < 16; depth++ {
+			info, ok := infos[cur]
+			if !ok {
+				break
+			}
+			if info.level > 0 {
+				levels[id] = info.level
+				break
+			}
+			cur = info.basedOn
+		}
+	}
+	return levels
+}
+
+type docxReader struct {
+	headingLevels map[string]int
+}
+
+// blocks renders the paragraphs and tables of the document body
+func (d *docxReader) blocks(body *xmlNode) []docBlock {
+	var blocks []docBlock
+	for _, c := range body.Children {
+		switch c.Name {
+		case "p":
+			blocks = append(blocks, headingBlock(d.paragraphLevel(c), d.inline(c)))
+		case "tbl":
+			blocks = append(blocks, docBlock{HTML: d.table(c)})
+		case "sdt":
+			blocks = append(blocks, d.blocks(c.child("sdtContent"))...)
+		}
+	}
+	return blocks
+}
+
+func (d *docxReader) paragraphLevel(p *xmlNode) int {
+	pPr := p.child("pPr")
+	if lvl, err := strconv.Atoi(pPr.child("outlineLvl").attr("val")); err == nil && lvl < 9 {
+		return lvl + 1
+	}
+	return d.headingLevels[pPr.child("pStyle").attr("val")]
+}
+
+func (d *docxReader) table(tbl *xmlNode) string {
+	var sb strings.Builder
+	sb.WriteString("<table>")
+	for _, tr := range tbl.children("tr") {
+		sb.WriteString("<tr>")
+		for _, tc := range tr.children("tc") {
+			sb.WriteString("<td>")
+			for _, b := range d.blocks(tc) {
+				sb.WriteString(b.HTML)
+			}
+			sb.WriteString("</td>")
+		}
+		sb.WriteString("</tr>")
+	}
+	sb.WriteString("</table>")
+	return sb.String()
+}
+
+// inline renders the runs of a paragraph, keeping bold, italic, underline and strikethrough
+func (d *docxReader) inline(n *xmlNode) string {
+	var sb strings.Builder
+	for _, c := range n.Children {
+		switch c.Name {
+		case "r":
+			sb.WriteString(docxRunFormat(c.child("rPr")).wrap(docxRunText(c)))
+		case "hyperlink", "ins", "smartTag", "fldSimple", "customXml":
+			sb.WriteString(d.inline(c))
+		case "sdt":
+			sb.WriteString(d.inline(c.child("sdtContent")))
+		}
+	}
+	return sb.String()
+}
+
+func docxRunText(r *xmlNode) string {
+	var sb strings.Builder
+	for _, c := range r.Children {
+		switch c.Name {
+		case "t":
+			sb.WriteString(html.EscapeString(c.text()))
+		case "tab":
+			sb.WriteString(" ")
+		case "br", "cr":
+			if c.attr("type") != "page" {
+				sb.WriteString("<br>")
+			}
+		case "noBreakHyphen":
+			sb.WriteString("-")
+		}
+	}
+	return sb.String()
+}
+
+func docxRunFormat(rPr *xmlNode) textFormat {
+	on := func(name string) bool {
+		n := rPr.child(name)
+		if n == nil {
+			return false
+		}
+		switch strings.ToLower(n.attr("val")) {
+		case "0", "false", "off", "none":
+			return false
+		}
+		return true
+	}
+	return textFormat{
+		bold:      on("b"),
+		italic:    on("i"),
+		underline: on("u"),
+		strike:    on("strike") || on("dstrike"),
+	}
+}
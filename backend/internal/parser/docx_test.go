@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocxDocument = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:r><w:t>Foreword text.</w:t></w:r></w:p>
+    <w:p><w:pPr><w:pStyle w:val="Titre1"/></w:pPr><w:r><w:t>Part One</w:t></w:r></w:p>
+    <w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Chapter 1</w:t></w:r></w:p>
+    <w:p><w:r><w:t xml:space="preserve">It was a </w:t></w:r><w:r><w:rPr><w:i/></w:rPr><w:t>cold</w:t></w:r><w:r><w:t xml:space="preserve"> and </w:t></w:r><w:r><w:rPr><w:b/><w:i w:val="0"/></w:rPr><w:t>dark</w:t></w:r><w:r><w:t xml:space="preserve"> morning.</w:t></w:r></w:p>
+    <w:p><w:r><w:t>Second paragraph.</w:t></w:r></w:p>
+    <w:p><w:pPr><w:outlineLvl w:val="1"/></w:pPr><w:r><w:t>Chapter 2</w:t></w:r></w:p>
+    <w:p><w:r><w:t>The end.</w:t></w:r></w:p>
+  </w:body>
+</w:document>`
+
+const testDocxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:style w:type="paragraph" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+  <w:style w:type="paragraph" w:styleId="Titre1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="Custom Chapter"/><w:pPr><w:outlineLvl w:val="1"/></w:pPr></w:style>
+</w:styles>`
+
+const testDocxCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">
+  <dc:title>The Test Document</dc:title>
+  <dc:creator>Jane Doe</dc:creator>
+  <dc:description>A short document.</dc:description>
+  <dc:language>en-US</dc:language>
+  <cp:keywords>magic, dragons</cp:keywords>
+  <dcterms:created>2020-05-06T07:08:09Z</dcterms:created>
+</cp:coreProperties>`
+
+func TestDocxParser_Parse(t *testing.T) {
+	filePath := writeTestZip(t, "test.docx", map[string]string{
+		"word/document.xml": testDocxDocument,
+		"word/styles.xml":   testDocxStyles,
+	})
+
+	chapters, err := NewDocxParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 4)
+
+	assert.Equal(t, frontMatterTitle, chapters[0].Title)
+	assert.Equal(t, "Foreword text.", chapters[0].Content)
+
+	assert.Equal(t, "Part One", chapters[1].Title)
+	assert.Equal(t, 0, chapters[1].VolumeChapterNumber)
+
+	ch1 := chapters[2]
+	assert.Equal(t, "Chapter 1", ch1.Title)
+	assert.Equal(t, 1, ch1.VolumeChapterNumber)
+	assert.Contains(t, ch1.Content, "It was a cold and dark morning.\n")
+	assert.Contains(t, ch1.Content, "Second paragraph.")
+	assert.Contains(t, ch1.ContentHTML, "<em>cold</em>")
+	assert.Contains(t, ch1.ContentHTML, "<strong>dark</strong>")
+
+	assert.Equal(t, "Chapter 2", chapters[3].Title)
+	assert.Equal(t, 2, chapters[3].VolumeChapterNumber)
+}
+
+func TestDocxParser_NoHeadings(t *testing.T) {
+	filePath := writeTestZip(t, "My Notes.docx", map[string]string{
+		"word/document.xml": `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Line one</w:t><w:br/><w:t>line two</w:t></w:r></w:p></w:body></w:document>`,
+	})
+
+	chapters, err := NewDocxParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Equal(t, "My Notes", chapters[0].Title)
+	assert.Contains(t, chapters[0].ContentHTML, "Line one<br")
+}
+
+func TestDocxParser_ExtractMetadata(t *testing.T) {
+	filePath := writeTestZip(t, "test.docx", map[string]string{
+		"word/document.xml": testDocxDocument,
+		"docProps/core.xml": testDocxCore,
+	})
+
+	meta, err := NewDocxParser().ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "The Test Document", meta.Title)
+	assert.Equal(t, "Jane Doe", meta.Author)
+	assert.Equal(t, "A short document.", meta.Description)
+	assert.Equal(t, "en-US", meta.Language)
+	assert.Equal(t, "2020-05-06", meta.PublishedDate)
+	assert.Equal(t, []string{"magic", "dragons"}, meta.Subjects)
+}
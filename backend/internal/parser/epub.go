@@ -2,6 +2,7 @@ package parser
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -13,16 +14,24 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/textstat"
 )
 
 // EpubParser parses .epub files
-type EpubParser struct{}
+type EpubParser struct {
+	opts Options
+}
 
 // NewEpubParser creates a new EpubParser
 func NewEpubParser() *EpubParser {
 	return &EpubParser{}
 }
 
+// NewEpubParserWithOptions creates an EpubParser that renders chapters with opts
+func NewEpubParserWithOptions(opts Options) *EpubParser {
+	return &EpubParser{opts: opts}
+}
+
 // EPUB structures for XML parsing
 const (
 	opfNS = "http://www.idpf.org/2007/opf"
@@ -38,23 +47,63 @@ type Rootfile struct {
 }
 
 type Package struct {
-	Metadata Metadata  `xml:"{http://www.idpf.org/2007/opf}metadata"`
-	Manifest []Item    `xml:"{http://www.idpf.org/2007/opf}manifest>{http://www.idpf.org/2007/opf}item"`
-	Spine    SpineData `xml:"{http://www.idpf.org/2007/opf}spine"`
+	UniqueIdentifier string `xml:"unique-identifier,attr"`
+
+	Metadata Metadata    `xml:"metadata"`
+	Manifest []Item      `xml:"manifest>item"`
+	Spine    SpineData   `xml:"spine"`
+	Guide    []Reference `xml:"guide>reference"`
 }
 
 type Metadata struct {
-	Title []string `xml:"{http://purl.org/dc/elements/1.1/}title"`
+	Title       []string     `xml:"title"`
+	Creators    []Creator    `xml:"creator"`
+	Description []string     `xml:"description"`
+	Language    []string     `xml:"language"`
+	Publisher   []string     `xml:"publisher"`
+	Date        []string     `xml:"date"`
+	Identifiers []Identifier `xml:"identifier"`
+	Subjects    []string     `xml:"subject"`
+	Metas       []Meta       `xml:"meta"`
+}
+
+type Creator struct {
+	ID   string `xml:"id,attr"`
+	Role string `xml:"role,attr"`
+	Name string `xml:",chardata"`
+}
+
+type Identifier struct {
+	ID     string `xml:"id,attr"`
+	Scheme string `xml:"scheme,attr"`
+	Value  string `xml:",chardata"`
+}
+
+// Meta covers both EPUB2 (<meta name content>) and EPUB3 (<meta property refines>value</meta>) forms
+type Meta struct {
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Value    string `xml:",chardata"`
 }
 
 type Item struct {
-	ID        string `xml:"id,attr"`
-	Href      string `xml:"href,attr"`
-	MediaType string `xml:"media-type,attr"`
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+type Reference struct {
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
 }
 
 type SpineData struct {
-	ItemRefs []ItemRef `xml:"{http://www.idpf.org/2007/opf}itemref"`
+	Toc      string    `xml:"toc,attr"`
+	ItemRefs []ItemRef `xml:"itemref"`
 }
 
 type ItemRef struct {
@@ -63,124 +112,47 @@ type ItemRef struct {
 
 // Parse parses an epub file and returns chapters
 func (p *EpubParser) Parse(filePath string) ([]models.Chapter, error) {
-	// Open ZIP file (EPUB is a ZIP file)
-	reader, err := zip.OpenReader(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open epub: %w", err)
-	}
-	defer reader.Close()
-
-	// Find container.xml
-	containerPath := "META-INF/container.xml"
-	containerFile := findFileInZip(&reader.Reader, containerPath)
-	if containerFile == nil {
-		return nil, fmt.Errorf("container.xml not found")
-	}
+	return collectChapters(p, filePath)
+}
 
-	// Parse container.xml to find OPF file
-	rc, err := containerFile.Open()
+// ParseStream reads an EPUB and hands its chapters to emit one section at a time.
+// Progress counts sections.
+func (p *EpubParser) ParseStream(ctx context.Context, r io.ReaderAt, size int64, emit ChapterFunc) error {
+	// Open ZIP file (EPUB is a ZIP file)
+	reader, err := zip.NewReader(r, size)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open container.xml: %w", err)
-	}
-	defer rc.Close()
-
-	var container Container
-	if err := xml.NewDecoder(rc).Decode(&container); err != nil {
-		return nil, fmt.Errorf("failed to parse container.xml: %w", err)
+		return fmt.Errorf("failed to open epub: %w", err)
 	}
 
-	if len(container.Rootfiles) == 0 {
-		return nil, fmt.Errorf("no rootfile found in container")
-	}
-
-	// Parse OPF file
-	opfPath := container.Rootfiles[0].FullPath
-	opfFile := findFileInZip(&reader.Reader, opfPath)
-	if opfFile == nil {
-		return nil, fmt.Errorf("OPF file not found: %s", opfPath)
-	}
-
-	rc, err = opfFile.Open()
+	pkg, opfPath, err := readPackage(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open OPF file: %w", err)
-	}
-	defer rc.Close()
-
-	var pkg Package
-	if err := xml.NewDecoder(rc).Decode(&pkg); err != nil {
-		return nil, fmt.Errorf("failed to parse OPF file: %w", err)
+		return err
 	}
 
 	// Get base directory from OPF path (normalize slashes first)
 	baseDir := path.Dir(filepath.ToSlash(opfPath))
+	spine := readSpine(reader, pkg, baseDir)
 
-	// Create chapters from spine
-	var chapters []models.Chapter
-	manifest := make(map[string]Item)
-	for _, it := range pkg.Manifest {
-		manifest[it.ID] = it
+	// Prefer the navigation document for titles and structure; fall back to one chapter per spine item
+	var sections []epubSection
+	if toc := readTOC(reader, pkg, baseDir); len(toc) > 0 {
+		sections = sectionsFromTOC(spine, flattenTOC(toc))
+	}
+	if len(sections) == 0 {
+		sections = sectionsFromSpine(spine, firstNonEmpty(pkg.Metadata.Title))
 	}
 
-	for i, itemRef := range pkg.Spine.ItemRefs {
-		// Find manifest item
-		item, ok := manifest[itemRef.IDRef]
-		if !ok {
-			continue
-		}
-		if !strings.Contains(item.MediaType, "html") {
-			// Skip non-html items such as nav/cover/images
-			continue
-		}
-		href := item.Href
-
-		// Resolve path
-		fullPath := path.Clean(path.Join(baseDir, href))
-		contentFile := findFileInZip(&reader.Reader, fullPath)
-		if contentFile == nil {
-			continue
-		}
-
-		// Read content
-		rc, err := contentFile.Open()
-		if err != nil {
-			continue
-		}
-
-		content, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			continue
-		}
-
-		// Extract text
-		raw := string(content)
-		contentStr := stripHTMLTags(raw)
-		if strings.TrimSpace(contentStr) == "" {
-			continue
-		}
-
-		// Create chapter
-		title := fmt.Sprintf("Chapter %d", i+1)
-		if i == 0 && len(pkg.Metadata.Title) > 0 {
-			title = pkg.Metadata.Title[0]
-		} else if t := extractHTMLTitle(raw); t != "" {
-			title = t
-		}
-
-		chapter := models.Chapter{
-			ID:                  uuid.New().String(),
-			ChapterNumber:       i + 1,
-			VolumeNumber:        1,
-			VolumeChapterNumber: i + 1,
-			Title:               title,
-			Content:             contentStr,
-			WordCount:           len([]rune(contentStr)),
-		}
-
-		chapters = append(chapters, chapter)
+	sink := newChapterSink(ctx, p.opts.splitWords(), emit)
+	emitted := 0
+	err = eachSectionChapter(sections, p.opts.ResourceBaseURL, collectNotes(spine), func(ch models.Chapter, done int) error {
+		emitted++
+		return sink.add(ch, Progress{Done: int64(done), Total: int64(len(sections))})
+	})
+	if err != nil {
+		return err
 	}
 
-	if len(chapters) == 0 {
+	if emitted == 0 {
 		// Fallback: grab all HTML/XHTML files in the zip (excluding nav/cover) sorted by name
 		var htmlFiles []string
 		for _, f := range reader.File {
@@ -198,7 +170,7 @@ func (p *EpubParser) Parse(filePath string) ([]models.Chapter, error) {
 		})
 
 		for i, name := range htmlFiles {
-			contentFile := findFileInZip(&reader.Reader, name)
+			contentFile := findFileInZip(reader, name)
 			if contentFile == nil {
 				continue
 			}
@@ -212,7 +184,7 @@ func (p *EpubParser) Parse(filePath string) ([]models.Chapter, error) {
 				continue
 			}
 			raw := string(rawBytes)
-			contentStr := stripHTMLTags(raw)
+			contentStr := htmlToText(htmlBody(raw))
 			if strings.TrimSpace(contentStr) == "" {
 				continue
 			}
@@ -220,23 +192,72 @@ func (p *EpubParser) Parse(filePath string) ([]models.Chapter, error) {
 			if title == "" {
 				title = fmt.Sprintf("Chapter %d", i+1)
 			}
-			chapters = append(chapters, models.Chapter{
+			emitted++
+			err = sink.add(models.Chapter{
 				ID:                  uuid.New().String(),
-				ChapterNumber:       len(chapters) + 1,
 				VolumeNumber:        1,
-				VolumeChapterNumber: len(chapters) + 1,
+				VolumeChapterNumber: 1,
 				Title:               title,
 				Content:             contentStr,
-				WordCount:           len([]rune(contentStr)),
-			})
+				ContentHTML:         sanitizeHTML(htmlBody(raw), resourceResolver(p.opts.ResourceBaseURL, name)),
+				WordCount:           textstat.WordCount(contentStr),
+			}, Progress{Done: int64(i + 1), Total: int64(len(htmlFiles))})
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	if len(chapters) == 0 {
-		return nil, fmt.Errorf("no chapters found in epub (manifest=%d, spine=%d)", len(pkg.Manifest), len(pkg.Spine.ItemRefs))
+	if emitted == 0 {
+		return fmt.Errorf("no chapters found in epub (manifest=%d, spine=%d)", len(pkg.Manifest), len(pkg.Spine.ItemRefs))
 	}
+	return nil
+}
 
-	return chapters, nil
+// readPackage locates the OPF package document through META-INF/container.xml and parses it
+func readPackage(r *zip.Reader) (*Package, string, error) {
+	// Find container.xml
+	containerPath := "META-INF/container.xml"
+	containerFile := findFileInZip(r, containerPath)
+	if containerFile == nil {
+		return nil, "", fmt.Errorf("container.xml not found")
+	}
+
+	// Parse container.xml to find OPF file
+	rc, err := containerFile.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open container.xml: %w", err)
+	}
+	defer rc.Close()
+
+	var container Container
+	if err := xml.NewDecoder(rc).Decode(&container); err != nil {
+		return nil, "", fmt.Errorf("failed to parse container.xml: %w", err)
+	}
+
+	if len(container.Rootfiles) == 0 {
+		return nil, "", fmt.Errorf("no rootfile found in container")
+	}
+
+	// Parse OPF file
+	opfPath := container.Rootfiles[0].FullPath
+	opfFile := findFileInZip(r, opfPath)
+	if opfFile == nil {
+		return nil, "", fmt.Errorf("OPF file not found: %s", opfPath)
+	}
+
+	rc, err = opfFile.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open OPF file: %w", err)
+	}
+	defer rc.Close()
+
+	var pkg Package
+	if err := xml.NewDecoder(rc).Decode(&pkg); err != nil {
+		return nil, "", fmt.Errorf("failed to parse OPF file: %w", err)
+	}
+
+	return &pkg, opfPath, nil
 }
 
 // naturalLess compares strings by numeric parts to avoid 1,10,100 ordering issues.
@@ -326,7 +347,7 @@ func extractHTMLTitle(content string) string {
 		if tagEnd == -1 {
 			return ""
 		}
-		
+
 		realStart := start + tagEnd + 1
 
 		end := strings.Index(lower[realStart:], close)
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// imgSrcPattern matches the image reference of <img src> or SVG <image href|xlink:href> in a cover page
+var imgSrcPattern = regexp.MustCompile(`(?i)<(?:img|image)\b[^>]*?\s(?:src|xlink:href|href)\s*=\s*["']([^"']+)["']`)
+
+// ExtractCover returns the cover image declared by the EPUB package.
+// It checks, in order: the EPUB3 "cover-image" manifest property, the EPUB2
+// <meta name="cover"> element, the guide's cover page and finally any image named like a cover.
+func (p *EpubParser) ExtractCover(filePath string) ([]byte, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open epub: %w", err)
+	}
+	defer reader.Close()
+
+	pkg, opfPath, err := readPackage(&reader.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	coverPath := findCoverPath(&reader.Reader, pkg, path.Dir(filepath.ToSlash(opfPath)))
+	if coverPath == "" {
+		return nil, fmt.Errorf("no cover declared in epub")
+	}
+
+	f := findFileInZip(&reader.Reader, coverPath)
+	if f == nil {
+		return nil, fmt.Errorf("cover image not found: %s", coverPath)
+	}
+	return readZipFile(f)
+}
+
+// findCoverPath resolves the zip path of the cover image, or "" if none is declared
+func findCoverPath(r *zip.Reader, pkg *Package, baseDir string) string {
+	resolve := func(href string) string {
+		return resolveHref(baseDir, href)
+	}
+
+	// EPUB3: <item properties="cover-image">
+	for _, item := range pkg.Manifest {
+		if hasProperty(item.Properties, "cover-image") {
+			return resolve(item.Href)
+		}
+	}
+
+	// EPUB2: <meta name="cover" content="manifest-id">
+	for _, meta := range pkg.Metadata.Metas {
+		if !strings.EqualFold(meta.Name, "cover") || meta.Content == "" {
+			continue
+		}
+		for _, item := range pkg.Manifest {
+			if item.ID == meta.Content && strings.HasPrefix(item.MediaType, "image/") {
+				return resolve(item.Href)
+			}
+		}
+		// Some generators put the href instead of the id into content
+		if f := findFileInZip(r, resolve(meta.Content)); f != nil {
+			return resolve(meta.Content)
+		}
+	}
+
+	// EPUB2 guide: <reference type="cover" href="cover.xhtml"/> pointing at a page with an <img>
+	for _, ref := range pkg.Guide {
+		if !strings.EqualFold(ref.Type, "cover") {
+			continue
+		}
+		pagePath := resolve(ref.Href)
+		if img := firstImageInPage(r, pagePath); img != "" {
+			return img
+		}
+	}
+
+	// Last resort: an image whose id or file name mentions "cover"
+	for _, item := range pkg.Manifest {
+		if !strings.HasPrefix(item.MediaType, "image/") {
+			continue
+		}
+		if strings.Contains(strings.ToLower(item.ID), "cover") || strings.Contains(strings.ToLower(path.Base(item.Href)), "cover") {
+			return resolve(item.Href)
+		}
+	}
+
+	return ""
+}
+
+// firstImageInPage returns the zip path of the first image referenced by an (X)HTML page
+func firstImageInPage(r *zip.Reader, pagePath string) string {
+	f := findFileInZip(r, pagePath)
+	if f == nil {
+		return ""
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return ""
+	}
+	m := imgSrcPattern.FindStringSubmatch(string(data))
+	if m == nil {
+		return ""
+	}
+	return resolveHref(path.Dir(pagePath), m[1])
+}
+
+// hasProperty reports whether a space-separated OPF properties attribute contains prop
+func hasProperty(properties, prop string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
+
+// stripFragment removes a trailing "#fragment" from an href
+func stripFragment(href string) string {
+	if i := strings.Index(href, "#"); i >= 0 {
+		return href[:i]
+	}
+	return href
+}
+
+// readZipFile reads the full content of a file inside a ZIP archive
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	isbnPattern      = regexp.MustCompile(`^(?:\d{9}[\dX]|\d{13})$`)
+	subjectSeparator = regexp.MustCompile(`\s*[;,，；、]\s*`)
+)
+
+// ExtractMetadata reads Dublin Core, EPUB3 refinements and calibre metadata from the OPF package
+func (p *EpubParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open epub: %w", err)
+	}
+	defer reader.Close()
+
+	pkg, _, err := readPackage(&reader.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return packageMetadata(pkg), nil
+}
+
+// packageMetadata converts the OPF <metadata> element into BookMetadata
+func packageMetadata(pkg *Package) *BookMetadata {
+	md := pkg.Metadata
+	refines := metaRefinements(md.Metas)
+
+	meta := &BookMetadata{
+		Title:         firstNonEmpty(md.Title),
+		Author:        epubAuthors(md.Creators, refines),
+		Description:   cleanDescription(firstNonEmpty(md.Description)),
+		Language:      firstNonEmpty(md.Language),
+		Publisher:     firstNonEmpty(md.Publisher),
+		PublishedDate: normalizeDate(firstNonEmpty(md.Date)),
+		Subjects:      splitSubjects(md.Subjects),
+	}
+	meta.ISBN, meta.Identifier = epubIdentifiers(md.Identifiers, pkg.UniqueIdentifier)
+	meta.Series, meta.SeriesIndex = epubSeries(md.Metas, refines)
+
+	return meta
+}
+
+// metaRefinements indexes EPUB3 <meta refines="#id" property="..."> by target id and property
+func metaRefinements(metas []Meta) map[string]map[string]string {
+	refines := make(map[string]map[string]string)
+	for _, m := range metas {
+		if m.Refines == "" || m.Property == "" {
+			continue
+		}
+		id := strings.TrimPrefix(m.Refines, "#")
+		if refines[id] == nil {
+			refines[id] = make(map[string]string)
+		}
+		refines[id][m.Property] = strings.TrimSpace(m.Value)
+	}
+	return refines
+}
+
+// epubAuthors joins creators with the "aut" role. Creators without any role count as authors;
+// if every creator has a non-author role (e.g. only an illustrator), all of them are used.
+func epubAuthors(creators []Creator, refines map[string]map[string]string) string {
+	var authors, all []string
+	for _, c := range creators {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			continue
+		}
+		all = append(all, name)
+
+		role := c.Role
+		if r, ok := refines[c.ID]["role"]; ok && role == "" {
+			role = r
+		}
+		if role == "" || strings.EqualFold(role, "aut") {
+			authors = append(authors, name)
+		}
+	}
+	if len(authors) == 0 {
+		authors = all
+	}
+	return strings.Join(authors, ", ")
+}
+
+// epubIdentifiers picks the ISBN (digits only) and the package's unique identifier (e.g. "urn:uuid:...")
+func epubIdentifiers(ids []Identifier, uniqueID string) (string, string) {
+	var isbn, identifier, fallback string
+	for _, id := range ids {
+		value := strings.TrimSpace(id.Value)
+		if value == "" {
+			continue
+		}
+		if isbn == "" {
+			if n, ok := normalizeISBN(value, id.Scheme); ok {
+				isbn = n
+			}
+		}
+		if uniqueID != "" && id.ID == uniqueID {
+			identifier = value
+		}
+		if fallback == "" {
+			fallback = value
+		}
+	}
+	if identifier == "" {
+		identifier = fallback
+	}
+	return isbn, identifier
+}
+
+// normalizeISBN strips "urn:isbn:" prefixes and separators, returning the bare ISBN-10/13
+func normalizeISBN(value, scheme string) (string, bool) {
+	lower := strings.ToLower(value)
+	explicit := strings.EqualFold(scheme, "isbn")
+	for _, prefix := range []string{"urn:isbn:", "isbn:", "isbn "} {
+		if strings.HasPrefix(lower, prefix) {
+			value = value[len(prefix):]
+			explicit = true
+			break
+		}
+	}
+
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(value)))
+	if !isbnPattern.MatchString(digits) {
+		return "", false
+	}
+	// Bare 10/13 digit strings without an ISBN marker are only trusted if they look like one
+	if !explicit && len(digits) == 13 && !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+		return "", false
+	}
+	return digits, true
+}
+
+// epubSeries reads calibre's series metadata or the EPUB3 belongs-to-collection equivalent
+func epubSeries(metas []Meta, refines map[string]map[string]string) (string, float64) {
+	var series string
+	var index float64
+
+	for _, m := range metas {
+		switch strings.ToLower(m.Name) {
+		case "calibre:series":
+			series = strings.TrimSpace(m.Content)
+		case "calibre:series_index":
+			index, _ = strconv.ParseFloat(strings.TrimSpace(m.Content), 64)
+		}
+	}
+	if series != "" {
+		return series, index
+	}
+
+	for _, m := range metas {
+		if m.Property != "belongs-to-collection" || m.Refines != "" {
+			continue
+		}
+		props := refines[m.ID]
+		if t, ok := props["collection-type"]; ok && t != "series" {
+			continue
+		}
+		if pos, ok := props["group-position"]; ok {
+			index, _ = strconv.ParseFloat(pos, 64)
+		}
+		return strings.TrimSpace(m.Value), index
+	}
+
+	return "", 0
+}
+
+// cleanDescription turns an HTML description (common in calibre output) into plain text
+func cleanDescription(desc string) string {
+	if desc == "" {
+		return ""
+	}
+	return html.UnescapeString(stripHTMLTags(desc))
+}
+
+// normalizeDate reduces full timestamps ("2010-05-01T00:00:00+00:00") to a date
+func normalizeDate(date string) string {
+	if len(date) >= 10 {
+		if _, err := time.Parse("2006-01-02", date[:10]); err == nil {
+			return date[:10]
+		}
+	}
+	return date
+}
+
+// splitSubjects splits and deduplicates subjects; some tools pack several into one element
+func splitSubjects(subjects []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, s := range subjects {
+		for _, part := range subjectSeparator.Split(s, -1) {
+			part = strings.TrimSpace(part)
+			if part == "" || seen[strings.ToLower(part)] {
+				continue
+			}
+			seen[strings.ToLower(part)] = true
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func firstNonEmpty(values []string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// tocEntry is one entry of the book's table of contents, resolved to a zip path
+type tocEntry struct {
+	Title    string
+	Path     string
+	Fragment string
+	Children []tocEntry
+}
+
+// NCX (EPUB2) structures
+type ncxDocument struct {
+	NavPoints []ncxNavPoint `xml:"navMap>navPoint"`
+}
+
+type ncxNavPoint struct {
+	Label    string        `xml:"navLabel>text"`
+	Content  ncxContent    `xml:"content"`
+	Children []ncxNavPoint `xml:"navPoint"`
+}
+
+type ncxContent struct {
+	Src string `xml:"src,attr"`
+}
+
+// readTOC returns the table of contents from the EPUB3 navigation document,
+// falling back to the EPUB2 toc.ncx. Returns nil if the book has neither.
+func readTOC(r *zip.Reader, pkg *Package, baseDir string) []tocEntry {
+	for _, item := range pkg.Manifest {
+		if hasProperty(item.Properties, "nav") {
+			if toc := readNavDocument(r, resolveHref(baseDir, item.Href)); len(toc) > 0 {
+				return toc
+			}
+		}
+	}
+
+	ncxPath := ""
+	for _, item := range pkg.Manifest {
+		if (pkg.Spine.Toc != "" && item.ID == pkg.Spine.Toc) || item.MediaType == "application/x-dtbncx+xml" {
+			ncxPath = resolveHref(baseDir, item.Href)
+			break
+		}
+	}
+	if ncxPath == "" {
+		return nil
+	}
+	return readNCX(r, ncxPath)
+}
+
+// readNCX parses an EPUB2 toc.ncx navMap
+func readNCX(r *zip.Reader, ncxPath string) []tocEntry {
+	f := findFileInZip(r, ncxPath)
+	if f == nil {
+		return nil
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil
+	}
+
+	var doc ncxDocument
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil
+	}
+
+	ncxDir := path.Dir(ncxPath)
+	var convert func(points []ncxNavPoint) []tocEntry
+	convert = func(points []ncxNavPoint) []tocEntry {
+		var entries []tocEntry
+		for _, np := range points {
+			entry := newTOCEntry(ncxDir, np.Label, np.Content.Src)
+			entry.Children = convert(np.Children)
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	return convert(doc.NavPoints)
+}
+
+// readNavDocument parses the <nav epub:type="toc"> list of an EPUB3 navigation document
+func readNavDocument(r *zip.Reader, navPath string) []tocEntry {
+	f := findFileInZip(r, navPath)
+	if f == nil {
+		return nil
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	nav := doc.Find("nav").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return hasProperty(s.AttrOr("epub:type", ""), "toc") || s.AttrOr("role", "") == "doc-toc"
+	}).First()
+	if nav.Length() == 0 {
+		nav = doc.Find("nav").First()
+	}
+	if nav.Length() == 0 {
+		return nil
+	}
+
+	navDir := path.Dir(navPath)
+	var convert func(list *goquery.Selection) []tocEntry
+	convert = func(list *goquery.Selection) []tocEntry {
+		var entries []tocEntry
+		list.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+			link := li.ChildrenFiltered("a").First()
+			label := li.ChildrenFiltered("a, span").First()
+			entry := newTOCEntry(navDir, label.Text(), link.AttrOr("href", ""))
+			entry.Children = convert(li.ChildrenFiltered("ol, ul").First())
+			entries = append(entries, entry)
+		})
+		return entries
+	}
+	return convert(nav.ChildrenFiltered("ol, ul").First())
+}
+
+// newTOCEntry builds an entry from a label and an href relative to the TOC document
+func newTOCEntry(dir, label, href string) tocEntry {
+	entry := tocEntry{Title: strings.Join(strings.Fields(label), " ")}
+	if href == "" {
+		return entry
+	}
+	if i := strings.Index(href, "#"); i >= 0 {
+		entry.Fragment = href[i+1:]
+		href = href[:i]
+	}
+	if href != "" {
+		entry.Path = resolveHref(dir, href)
+	}
+	return entry
+}
+
+// resolveHref resolves a (possibly percent-encoded) href against a directory inside the zip
+func resolveHref(dir, href string) string {
+	href = stripFragment(href)
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Clean(path.Join(dir, href))
+}
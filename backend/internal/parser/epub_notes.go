@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+	xhtml "golang.org/x/net/html"
+)
+
+// noteBodyTypes are epub:type / role values that mark a footnote or endnote body
+var noteBodyTypes = []string{"footnote", "endnote", "rearnote", "note", "doc-footnote", "doc-endnote"}
+
+// epubNotes holds the footnote and endnote bodies of a book, keyed by "path#id"
+type epubNotes struct {
+	bodies map[string]models.Footnote
+}
+
+// collectNotes finds note bodies in all spine documents: elements typed as footnotes/endnotes,
+// plus any element that an epub:type="noteref" link points to (older books often use plain <p id>).
+func collectNotes(spine []spineDoc) *epubNotes {
+	notes := &epubNotes{bodies: make(map[string]models.Footnote)}
+
+	ids := make(map[string]*xhtml.Node)
+	referenced := make(map[string]bool)
+	var typed []string
+
+	for _, doc := range spine {
+		root, err := xhtml.Parse(strings.NewReader(doc.Raw))
+		if err != nil {
+			continue
+		}
+
+		var walk func(n *xhtml.Node)
+		walk = func(n *xhtml.Node) {
+			if n.Type == xhtml.ElementNode {
+				if id := attrValue(n, "id"); id != "" {
+					key := noteKey(doc.Path, "#"+id)
+					if _, ok := ids[key]; !ok {
+						ids[key] = n
+					}
+					if isNoteBody(n) {
+						typed = append(typed, key)
+					}
+				}
+				if strings.EqualFold(n.Data, "a") && isNoteRef(n) {
+					if key := noteKey(doc.Path, attrValue(n, "href")); key != "" {
+						referenced[key] = true
+					}
+				}
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk(root)
+	}
+
+	for _, key := range typed {
+		notes.add(key, ids[key])
+	}
+	for key := range referenced {
+		n, ok := ids[key]
+		if !ok || strings.EqualFold(n.Data, "body") || strings.EqualFold(n.Data, "html") {
+			continue
+		}
+		if _, exists := notes.bodies[key]; !exists {
+			notes.add(key, n)
+		}
+	}
+	return notes
+}
+
+func (notes *epubNotes) add(key string, n *xhtml.Node) {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		xhtml.Render(&buf, c)
+	}
+	inner := buf.String()
+
+	notes.bodies[key] = models.Footnote{
+		ID:          attrValue(n, "id"),
+		Content:     htmlToText(inner),
+		ContentHTML: sanitizeHTML(inner, nil),
+	}
+}
+
+// isBody reports whether n (inside docPath) is a note body that must not appear inline
+func (notes *epubNotes) isBody(docPath string, n *xhtml.Node) bool {
+	if isNoteBody(n) {
+		return true
+	}
+	if notes == nil {
+		return false
+	}
+	id := attrValue(n, "id")
+	if id == "" {
+		return false
+	}
+	_, ok := notes.bodies[noteKey(docPath, "#"+id)]
+	return ok
+}
+
+// lookup returns the note a link in docPath points to
+func (notes *epubNotes) lookup(docPath, href string) (models.Footnote, bool) {
+	if notes == nil {
+		return models.Footnote{}, false
+	}
+	key := noteKey(docPath, href)
+	if key == "" {
+		return models.Footnote{}, false
+	}
+	fn, ok := notes.bodies[key]
+	return fn, ok
+}
+
+// noteKey resolves a link (relative to docPath) to a "path#id" key, or "" if it has no fragment
+func noteKey(docPath, href string) string {
+	i := strings.Index(href, "#")
+	if i < 0 || i == len(href)-1 {
+		return ""
+	}
+	target := docPath
+	if href[:i] != "" {
+		target = resolveHref(path.Dir(docPath), href[:i])
+	}
+	return strings.ToLower(target) + "#" + href[i+1:]
+}
+
+func isNoteBody(n *xhtml.Node) bool {
+	if n.Type != xhtml.ElementNode {
+		return false
+	}
+	types := attrValue(n, "epub:type") + " " + attrValue(n, "role")
+	for _, t := range noteBodyTypes {
+		if hasProperty(types, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNoteRef(n *xhtml.Node) bool {
+	types := attrValue(n, "epub:type") + " " + attrValue(n, "role")
+	return hasProperty(types, "noteref") || hasProperty(types, "doc-noteref")
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// localImageExts are the files next to a book file that openLocalImage serves
+var localImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".svg": true, ".bmp": true,
+}
+
+// OpenResource opens a file inside the EPUB (e.g. "OEBPS/images/p1.jpg") for streaming.
+// The caller must close the returned reader.
+func (p *EpubParser) OpenResource(filePath, name string) (io.ReadCloser, error) {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))[1:]
+	if name == "" || strings.HasPrefix(strings.ToUpper(name), "META-INF/") {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open epub: %w", err)
+	}
+
+	f := findFileInZip(&reader.Reader, name)
+	if f == nil {
+		reader.Close()
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to open resource: %w", err)
+	}
+	return &zipResource{ReadCloser: rc, archive: reader}, nil
+}
+
+// zipResource closes the archive together with the entry being read
+type zipResource struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (r *zipResource) Close() error {
+	err := r.ReadCloser.Close()
+	if cerr := r.archive.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// openLocalImage opens an image a book file refers to by a path relative to its directory,
+// such as a Markdown illustration or a saved page's "page_files/" folder. Paths leaving the
+// directory are refused.
+func openLocalImage(filePath, name string) (io.ReadCloser, error) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(name) || strings.HasPrefix(name, "../") || !localImageExts[strings.ToLower(path.Ext(name))] {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+	f, err := os.Open(filepath.Join(filepath.Dir(filePath), filepath.FromSlash(name)))
+	if err != nil {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+	return f, nil
+}
+
+// resourceResolver returns a function that rewrites image sources found in docPath
+// to URLs under baseURL. Remote and data: URLs are kept as they are.
+func resourceResolver(baseURL, docPath string) func(string) string {
+	if baseURL == "" {
+		return nil
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	dir := path.Dir(docPath)
+
+	return func(src string) string {
+		src = strings.TrimSpace(src)
+		if u, err := url.Parse(src); err == nil && u.Scheme != "" {
+			switch strings.ToLower(u.Scheme) {
+			case "http", "https", "data":
+				return src
+			default:
+				return ""
+			}
+		}
+		if strings.HasPrefix(src, "/") {
+			return ""
+		}
+
+		resolved := resolveHref(dir, src)
+		if resolved == "." || strings.HasPrefix(resolved, "../") {
+			return ""
+		}
+		segments := strings.Split(resolved, "/")
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+		return baseURL + strings.Join(segments, "/")
+	}
+}
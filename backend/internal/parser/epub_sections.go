@@ -0,0 +1,409 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/textstat"
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	bodyOpenPattern  = regexp.MustCompile(`(?is)<body\b[^>]*>`)
+	bodyClosePattern = regexp.MustCompile(`(?is)</body\s*>`)
+	imageTagPattern  = regexp.MustCompile(`(?i)<(?:img|image)\b`)
+)
+
+// spineDoc is an (X)HTML document of the spine in reading order
+type spineDoc struct {
+	Path string
+	Raw  string
+}
+
+// epubSection is a run of HTML that becomes one chapter (or a volume page).
+// It can span several spine documents, so each part remembers where it came from
+// to resolve relative image paths.
+type epubSection struct {
+	Title  string
+	Volume bool
+	Parts  []htmlPart
+}
+
+type htmlPart struct {
+	Path string
+	HTML string
+}
+
+func (sec *epubSection) add(docPath, html string) {
+	sec.Parts = append(sec.Parts, htmlPart{Path: docPath, HTML: html})
+}
+
+// tocMark is a flattened TOC entry: the position where a chapter or volume starts
+type tocMark struct {
+	Title    string
+	Path     string
+	Fragment string
+	Volume   bool
+}
+
+// readSpine loads all (X)HTML documents of the spine in reading order
+func readSpine(r *zip.Reader, pkg *Package, baseDir string) []spineDoc {
+	manifest := make(map[string]Item)
+	for _, it := range pkg.Manifest {
+		manifest[it.ID] = it
+	}
+
+	var docs []spineDoc
+	for _, itemRef := range pkg.Spine.ItemRefs {
+		item, ok := manifest[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		if !strings.Contains(item.MediaType, "html") || hasProperty(item.Properties, "nav") {
+			// Skip non-html items such as images, and the navigation document itself
+			continue
+		}
+
+		fullPath := resolveHref(baseDir, item.Href)
+		contentFile := findFileInZip(r, fullPath)
+		if contentFile == nil {
+			continue
+		}
+		data, err := readZipFile(contentFile)
+		if err != nil {
+			continue
+		}
+		docs = append(docs, spineDoc{Path: fullPath, Raw: string(data)})
+	}
+	return docs
+}
+
+// flattenTOC turns the TOC tree into chapter/volume start marks in reading order.
+// A top-level entry whose children lead into other files is a part and becomes a volume;
+// children that only point at fragments of the parent's own file are sections of one chapter
+// and, like every deeper level, become chapters of their own.
+func flattenTOC(entries []tocEntry) []tocMark {
+	var marks []tocMark
+	var flattenChapters func(entries []tocEntry)
+	flattenChapters = func(entries []tocEntry) {
+		for _, e := range entries {
+			if e.Path != "" {
+				marks = append(marks, tocMark{Title: e.Title, Path: e.Path, Fragment: e.Fragment})
+			}
+			flattenChapters(e.Children)
+		}
+	}
+
+	for _, e := range entries {
+		if !isPartEntry(e) {
+			flattenChapters([]tocEntry{e})
+			continue
+		}
+
+		volume := tocMark{Title: e.Title, Path: e.Path, Fragment: e.Fragment, Volume: true}
+		if volume.Path == "" {
+			// Part headings without a link start where their first chapter starts
+			if first, ok := firstLinkedEntry(e.Children); ok {
+				volume.Path, volume.Fragment = first.Path, first.Fragment
+			}
+		}
+		if volume.Path != "" {
+			marks = append(marks, volume)
+		}
+		flattenChapters(e.Children)
+	}
+	return marks
+}
+
+// isPartEntry reports whether a top-level TOC entry groups chapters living in other files
+func isPartEntry(e tocEntry) bool {
+	if len(e.Children) == 0 {
+		return false
+	}
+	for _, c := range e.Children {
+		if c.Path != "" && c.Path != e.Path {
+			return true
+		}
+	}
+	return e.Path == ""
+}
+
+func firstLinkedEntry(entries []tocEntry) (tocEntry, bool) {
+	for _, e := range entries {
+		if e.Path != "" {
+			return e, true
+		}
+		if c, ok := firstLinkedEntry(e.Children); ok {
+			return c, true
+		}
+	}
+	return tocEntry{}, false
+}
+
+// sectionsFromTOC cuts the spine into sections at every TOC mark. A file with several marks
+// is split at the anchors they point to; files without marks continue the previous section.
+// Returns nil if no mark points into the spine, so the caller can fall back.
+func sectionsFromTOC(spine []spineDoc, marks []tocMark) []epubSection {
+	byPath := make(map[string][]tocMark)
+	for _, m := range marks {
+		key := strings.ToLower(m.Path)
+		byPath[key] = append(byPath[key], m)
+	}
+
+	matched := false
+	for _, doc := range spine {
+		if len(byPath[strings.ToLower(doc.Path)]) > 0 {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil
+	}
+
+	type cut struct {
+		offset int
+		mark   tocMark
+	}
+
+	var sections []*epubSection
+	var current *epubSection
+	untitled := func(doc spineDoc) *epubSection {
+		sec := &epubSection{Title: extractHTMLTitle(doc.Raw)}
+		if sec.Title == "" {
+			sec.Title = fmt.Sprintf("Chapter %d", len(sections)+1)
+		}
+		sections = append(sections, sec)
+		return sec
+	}
+
+	for _, doc := range spine {
+		body := htmlBody(doc.Raw)
+
+		var cuts []cut
+		var anchors map[string]int
+		for _, m := range byPath[strings.ToLower(doc.Path)] {
+			offset := 0
+			if m.Fragment != "" {
+				if anchors == nil {
+					anchors = anchorOffsets(body)
+				}
+				if o, ok := anchors[m.Fragment]; ok {
+					offset = o
+				}
+			}
+			cuts = append(cuts, cut{offset: offset, mark: m})
+		}
+		sort.SliceStable(cuts, func(i, j int) bool { return cuts[i].offset < cuts[j].offset })
+
+		if len(cuts) == 0 {
+			if current == nil {
+				if isBlankHTML(body) {
+					continue
+				}
+				current = untitled(doc)
+			}
+			current.add(doc.Path, body)
+			continue
+		}
+
+		// Text before the first anchor belongs to the previous chapter
+		if lead := body[:cuts[0].offset]; cuts[0].offset > 0 {
+			if current == nil && !isBlankHTML(lead) {
+				current = untitled(doc)
+			}
+			if current != nil {
+				current.add(doc.Path, lead)
+			}
+		}
+
+		for i, c := range cuts {
+			end := len(body)
+			if i+1 < len(cuts) {
+				end = cuts[i+1].offset
+			}
+			current = &epubSection{Title: c.mark.Title, Volume: c.mark.Volume}
+			current.add(doc.Path, body[c.offset:end])
+			sections = append(sections, current)
+		}
+	}
+
+	result := make([]epubSection, 0, len(sections))
+	for _, sec := range sections {
+		result = append(result, *sec)
+	}
+	return result
+}
+
+// sectionsFromSpine makes one section per spine document, titled from the document itself
+func sectionsFromSpine(spine []spineDoc, bookTitle string) []epubSection {
+	var sections []epubSection
+	for i, doc := range spine {
+		title := fmt.Sprintf("Chapter %d", i+1)
+		if t := extractHTMLTitle(doc.Raw); t != "" {
+			title = t
+		} else if i == 0 && bookTitle != "" {
+			title = bookTitle
+		}
+
+		sec := epubSection{Title: title}
+		sec.add(doc.Path, htmlBody(doc.Raw))
+		sections = append(sections, sec)
+	}
+	return sections
+}
+
+// sectionsToChapters turns sections into chapters numbered like TxtParser does: volume pages
+// get VolumeChapterNumber 0, and chapters without any text or images are dropped. When notes
+// is not nil, note references are linked to footnotes stored on their chapter and the note
+// bodies are left out of the chapter text.
+func sectionsToChapters(sections []epubSection, resourceBaseURL string, notes *epubNotes) []models.Chapter {
+	var chapters []models.Chapter
+	eachSectionChapter(sections, resourceBaseURL, notes, func(ch models.Chapter, _ int) error {
+		chapters = append(chapters, ch)
+		return nil
+	})
+	return chapters
+}
+
+// eachSectionChapter converts sections to chapters one at a time, passing fn each chapter
+// and the number of sections done so far; an error from fn stops the conversion
+func eachSectionChapter(sections []epubSection, resourceBaseURL string, notes *epubNotes, fn func(ch models.Chapter, done int) error) error {
+	chapterNumber := 0
+	volumeNumber := 1
+	volumeChapterNumber := 0
+
+	for i, sec := range sections {
+		var texts, htmls []string
+		var footnotes models.Footnotes
+		seen := make(map[string]bool)
+		for _, part := range sec.Parts {
+			docPath := part.Path
+			skip := func(n *xhtml.Node) bool { return notes.isBody(docPath, n) }
+			s := &sanitizer{
+				resolveURL: resourceResolver(resourceBaseURL, docPath),
+				skip:       skip,
+				noteRef: func(href, label string) string {
+					fn, ok := notes.lookup(docPath, href)
+					if !ok {
+						return ""
+					}
+					if !seen[fn.ID] {
+						seen[fn.ID] = true
+						fn.Label = label
+						footnotes = append(footnotes, fn)
+					}
+					return fn.ID
+				},
+			}
+
+			if text := renderText(part.HTML, skip); text != "" {
+				texts = append(texts, text)
+			}
+			if h := s.sanitize(part.HTML); h != "" {
+				htmls = append(htmls, h)
+			}
+		}
+		contentStr := strings.Join(texts, "\n")
+		contentHTML := strings.Join(htmls, "\n")
+
+		if sec.Volume {
+			if chapterNumber > 0 {
+				volumeNumber++
+			}
+			volumeChapterNumber = 0
+			chapterNumber++
+			err := fn(models.Chapter{
+				ID:                  uuid.New().String(),
+				ChapterNumber:       chapterNumber,
+				VolumeNumber:        volumeNumber,
+				VolumeChapterNumber: 0,
+				Title:               sec.Title,
+				Content:             contentStr,
+				ContentHTML:         contentHTML,
+				Footnotes:           footnotes,
+				WordCount:           textstat.WordCount(contentStr),
+			}, i+1)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if contentStr == "" && !strings.Contains(contentHTML, "<img") {
+			continue
+		}
+
+		chapterNumber++
+		volumeChapterNumber++
+		err := fn(models.Chapter{
+			ID:                  uuid.New().String(),
+			ChapterNumber:       chapterNumber,
+			VolumeNumber:        volumeNumber,
+			VolumeChapterNumber: volumeChapterNumber,
+			Title:               sec.Title,
+			Content:             contentStr,
+			ContentHTML:         contentHTML,
+			Footnotes:           footnotes,
+			WordCount:           textstat.WordCount(contentStr),
+		}, i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// htmlBody returns the inner HTML of <body>, or the whole document if it has none
+func htmlBody(raw string) string {
+	open := bodyOpenPattern.FindStringIndex(raw)
+	if open == nil {
+		return raw
+	}
+	body := raw[open[1]:]
+	if close := bodyClosePattern.FindStringIndex(body); close != nil {
+		body = body[:close[0]]
+	}
+	return body
+}
+
+// isBlankHTML reports whether a fragment has neither text nor images
+func isBlankHTML(fragment string) bool {
+	if strings.TrimSpace(stripHTMLTags(fragment)) != "" {
+		return false
+	}
+	return !imageTagPattern.MatchString(fragment)
+}
+
+// anchorOffsets maps the id and name attributes in body to the offset of the tag carrying
+// them. The first tag wins when a value repeats.
+func anchorOffsets(body string) map[string]int {
+	anchors := make(map[string]int)
+	z := xhtml.NewTokenizer(strings.NewReader(body))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			return anchors
+		}
+		start := offset
+		offset += len(z.Raw())
+		if tt != xhtml.StartTagToken && tt != xhtml.SelfClosingTagToken {
+			continue
+		}
+		for _, hasAttr := z.TagName(); hasAttr; {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			if k := string(key); k == "id" || k == "name" {
+				if _, seen := anchors[string(val)]; !seen {
+					anchors[string(val)] = start
+				}
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"archive/zip"
 	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -51,6 +52,17 @@ func TestExtractHTMLTitle(t *testing.T) {
 	}
 }
 
+func TestAnchorOffsets(t *testing.T) {
+	body := `<h1 id="top">Title</h1><p>Text</p><a name="s1"/><p ID='s2'>More</p><p id="s1">Again</p>`
+	anchors := anchorOffsets(body)
+
+	assert.Equal(t, 0, anchors["top"])
+	assert.Equal(t, len(`<h1 id="top">Title</h1><p>Text</p>`), anchors["s1"])
+	assert.Equal(t, len(`<h1 id="top">Title</h1><p>Text</p><a name="s1"/>`), anchors["s2"])
+	_, ok := anchors["missing"]
+	assert.False(t, ok)
+}
+
 func TestNaturalLess(t *testing.T) {
 	tests := []struct {
 		s1, s2   string
@@ -147,4 +159,378 @@ func TestEpubParser_Parse(t *testing.T) {
 	assert.Contains(t, chapters[0].Content, "Hello world")
 	assert.Equal(t, "Chapter 2: The End", chapters[1].Title)
 	assert.Contains(t, chapters[1].Content, "Goodbye world")
-}
\ No newline at end of file
+}
+
+// writeTestZip writes the given files into a zip archive and returns its path
+func writeTestZip(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buf)
+	for fileName, content := range files {
+		f, err := zipWriter.Create(fileName)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zipWriter.Close())
+
+	filePath := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0644))
+	return filePath
+}
+
+const testContainerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+func TestEpubParser_ExtractCover(t *testing.T) {
+	pngData := "\x89PNG\r\n\x1a\nfake-cover"
+	chapter := `<html><body><p>Text</p></body></html>`
+
+	tests := []struct {
+		name  string
+		opf   string
+		extra map[string]string
+	}{
+		{
+			name: "EPUB3 cover-image property",
+			opf: `<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Cover</dc:title></metadata>
+  <manifest>
+    <item id="img" href="images/front.png" media-type="image/png" properties="cover-image"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c1"/></spine>
+</package>`,
+		},
+		{
+			name: "EPUB2 meta cover",
+			opf: `<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Cover</dc:title>
+    <meta name="cover" content="cover-img"/>
+  </metadata>
+  <manifest>
+    <item id="cover-img" href="images/front.png" media-type="image/png"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c1"/></spine>
+</package>`,
+		},
+		{
+			name: "Guide cover page",
+			opf: `<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Cover</dc:title></metadata>
+  <manifest>
+    <item id="front" href="images/front.png" media-type="image/png"/>
+    <item id="titlepage" href="text/titlepage.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c1"/></spine>
+  <guide><reference type="cover" href="text/titlepage.xhtml"/></guide>
+</package>`,
+			extra: map[string]string{
+				"OEBPS/text/titlepage.xhtml": `<html><body><img src="../images/front.png" alt="cover"/></body></html>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{
+				"META-INF/container.xml": testContainerXML,
+				"OEBPS/content.opf":      tt.opf,
+				"OEBPS/c1.xhtml":         chapter,
+				"OEBPS/images/front.png": pngData,
+			}
+			for k, v := range tt.extra {
+				files[k] = v
+			}
+			filePath := writeTestZip(t, "cover.epub", files)
+
+			data, err := NewEpubParser().ExtractCover(filePath)
+			assert.NoError(t, err)
+			assert.Equal(t, pngData, string(data))
+		})
+	}
+
+	t.Run("No cover", func(t *testing.T) {
+		_, err := NewEpubParser().ExtractCover(createTestEpub(t))
+		assert.Error(t, err)
+	})
+}
+
+func TestEpubParser_ExtractMetadata(t *testing.T) {
+	t.Run("EPUB2 with calibre metadata", func(t *testing.T) {
+		opf := `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="uuid_id" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>The Test Book</dc:title>
+    <dc:creator opf:role="aut">Jane Doe</dc:creator>
+    <dc:creator opf:role="ill">Ann Artist</dc:creator>
+    <dc:description>&lt;p&gt;A &lt;b&gt;thrilling&lt;/b&gt; tale &amp;amp; more.&lt;/p&gt;</dc:description>
+    <dc:language>en</dc:language>
+    <dc:publisher>Test House</dc:publisher>
+    <dc:date>2015-03-04T00:00:00+00:00</dc:date>
+    <dc:identifier opf:scheme="uuid" id="uuid_id">urn:uuid:0f1e2d3c-aaaa-bbbb-cccc-000000000001</dc:identifier>
+    <dc:identifier opf:scheme="ISBN">978-0-306-40615-7</dc:identifier>
+    <dc:subject>Fantasy</dc:subject>
+    <dc:subject>Adventure; fantasy</dc:subject>
+    <meta name="calibre:series" content="Test Saga"/>
+    <meta name="calibre:series_index" content="2.0"/>
+  </metadata>
+  <manifest><item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/></manifest>
+  <spine><itemref idref="c1"/></spine>
+</package>`
+		filePath := writeTestZip(t, "meta.epub", map[string]string{
+			"META-INF/container.xml": testContainerXML,
+			"OEBPS/content.opf":      opf,
+			"OEBPS/c1.xhtml":         `<html><body><p>Text</p></body></html>`,
+		})
+
+		meta, err := NewEpubParser().ExtractMetadata(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, "The Test Book", meta.Title)
+		assert.Equal(t, "Jane Doe", meta.Author)
+		assert.Equal(t, "A thrilling tale & more.", meta.Description)
+		assert.Equal(t, "en", meta.Language)
+		assert.Equal(t, "Test House", meta.Publisher)
+		assert.Equal(t, "2015-03-04", meta.PublishedDate)
+		assert.Equal(t, "9780306406157", meta.ISBN)
+		assert.Equal(t, "urn:uuid:0f1e2d3c-aaaa-bbbb-cccc-000000000001", meta.Identifier)
+		assert.Equal(t, "Test Saga", meta.Series)
+		assert.Equal(t, 2.0, meta.SeriesIndex)
+		assert.Equal(t, []string{"Fantasy", "Adventure"}, meta.Subjects)
+	})
+
+	t.Run("EPUB3 refinements", func(t *testing.T) {
+		opf := `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="pub-id" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="pub-id">urn:isbn:4040000000</dc:identifier>
+    <dc:title>轻小说</dc:title>
+    <dc:creator id="ill">插画师</dc:creator>
+    <meta refines="#ill" property="role" scheme="marc:relators">ill</meta>
+    <dc:creator id="author">作者甲</dc:creator>
+    <meta refines="#author" property="role" scheme="marc:relators">aut</meta>
+    <meta property="belongs-to-collection" id="c01">某系列</meta>
+    <meta refines="#c01" property="collection-type">series</meta>
+    <meta refines="#c01" property="group-position">3</meta>
+  </metadata>
+  <manifest><item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/></manifest>
+  <spine><itemref idref="c1"/></spine>
+</package>`
+		filePath := writeTestZip(t, "meta3.epub", map[string]string{
+			"META-INF/container.xml": testContainerXML,
+			"OEBPS/content.opf":      opf,
+			"OEBPS/c1.xhtml":         `<html><body><p>Text</p></body></html>`,
+		})
+
+		meta, err := NewEpubParser().ExtractMetadata(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, "轻小说", meta.Title)
+		assert.Equal(t, "作者甲", meta.Author)
+		assert.Equal(t, "4040000000", meta.ISBN)
+		assert.Equal(t, "urn:isbn:4040000000", meta.Identifier)
+		assert.Equal(t, "某系列", meta.Series)
+		assert.Equal(t, 3.0, meta.SeriesIndex)
+		assert.Empty(t, meta.Subjects)
+	})
+}
+
+func TestEpubParser_ParseNavigation(t *testing.T) {
+	t.Run("NCX with parts and fragments", func(t *testing.T) {
+		opf := `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Book</dc:title></metadata>
+  <manifest>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="p1" href="part1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/c1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="text/c2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p2" href="part2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c3" href="text/c3.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="p1"/><itemref idref="c1"/><itemref idref="c2"/>
+    <itemref idref="p2"/><itemref idref="c3"/>
+  </spine>
+</package>`
+		ncx := `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <navMap>
+    <navPoint id="n1"><navLabel><text>Part One</text></navLabel><content src="part1.xhtml"/>
+      <navPoint id="n2"><navLabel><text>Arrival</text></navLabel><content src="text/c1.xhtml"/></navPoint>
+      <navPoint id="n3"><navLabel><text>Departure</text></navLabel><content src="text/c2.xhtml"/></navPoint>
+    </navPoint>
+    <navPoint id="n4"><navLabel><text>Part Two</text></navLabel><content src="part2.xhtml"/>
+      <navPoint id="n5"><navLabel><text>Morning</text></navLabel><content src="text/c3.xhtml#s1"/></navPoint>
+      <navPoint id="n6"><navLabel><text>Evening</text></navLabel><content src="text/c3.xhtml#s2"/></navPoint>
+    </navPoint>
+  </navMap>
+</ncx>`
+		filePath := writeTestZip(t, "ncx.epub", map[string]string{
+			"META-INF/container.xml": testContainerXML,
+			"OEBPS/content.opf":      opf,
+			"OEBPS/toc.ncx":          ncx,
+			"OEBPS/part1.xhtml":      `<html><head><title>p1</title></head><body><h1>Part One</h1></body></html>`,
+			"OEBPS/text/c1.xhtml":    `<html><head><title>c1</title></head><body><p>They arrived.</p></body></html>`,
+			"OEBPS/text/c2.xhtml":    `<html><head><title>c2</title></head><body><p>They left.</p></body></html>`,
+			"OEBPS/part2.xhtml":      `<html><body></body></html>`,
+			"OEBPS/text/c3.xhtml": `<html><body><h2 id="s1">Morning</h2><p>Sunrise.</p>
+<h2 id="s2">Evening</h2><p>Sunset.</p></body></html>`,
+		})
+
+		chapters, err := NewEpubParser().Parse(filePath)
+		assert.NoError(t, err)
+		if assert.Len(t, chapters, 6) {
+			titles := make([]string, len(chapters))
+			for i, c := range chapters {
+				titles[i] = c.Title
+				assert.Equal(t, i+1, c.ChapterNumber)
+			}
+			assert.Equal(t, []string{"Part One", "Arrival", "Departure", "Part Two", "Morning", "Evening"}, titles)
+
+			assert.Equal(t, 1, chapters[0].VolumeNumber)
+			assert.Equal(t, 0, chapters[0].VolumeChapterNumber)
+			assert.Equal(t, 1, chapters[2].VolumeNumber)
+			assert.Equal(t, 2, chapters[2].VolumeChapterNumber)
+			assert.Equal(t, 2, chapters[3].VolumeNumber)
+			assert.Equal(t, 0, chapters[3].VolumeChapterNumber)
+
+			assert.Contains(t, chapters[4].Content, "Sunrise.")
+			assert.NotContains(t, chapters[4].Content, "Sunset.")
+			assert.Contains(t, chapters[5].Content, "Sunset.")
+		}
+	})
+	t.Run("EPUB3 nav document", func(t *testing.T) {
+		opf := `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Book</dc:title></metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="nav"/><itemref idref="c1"/></spine>
+</package>`
+		nav := `<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="landmarks"><ol><li><a href="c1.xhtml">Start</a></li></ol></nav>
+<nav epub:type="toc"><ol>
+  <li><a href="c1.xhtml#one">第一章 出发</a></li>
+  <li><a href="c1.xhtml#two">第二章 归来</a></li>
+</ol></nav></body></html>`
+		filePath := writeTestZip(t, "nav.epub", map[string]string{
+			"META-INF/container.xml": testContainerXML,
+			"OEBPS/content.opf":      opf,
+			"OEBPS/nav.xhtml":        nav,
+			"OEBPS/c1.xhtml": `<html><head><title>c1</title></head><body>
+<div id="one"><p>出发了。</p></div><div id="two"><p>回来了。</p></div></body></html>`,
+		})
+
+		chapters, err := NewEpubParser().Parse(filePath)
+		assert.NoError(t, err)
+		if assert.Len(t, chapters, 2) {
+			assert.Equal(t, "第一章 出发", chapters[0].Title)
+			assert.Equal(t, "出发了。", chapters[0].Content)
+			assert.Equal(t, "第二章 归来", chapters[1].Title)
+			assert.Equal(t, "回来了。", chapters[1].Content)
+		}
+	})
+}
+
+func TestEpubParser_Images(t *testing.T) {
+	opf := `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Comic</dc:title></metadata>
+  <manifest>
+    <item id="p1" href="text/p1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p2" href="text/p2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="img1" href="images/page 1.jpg" media-type="image/jpeg"/>
+  </manifest>
+  <spine><itemref idref="p1"/><itemref idref="p2"/></spine>
+</package>`
+	filePath := writeTestZip(t, "images.epub", map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf":      opf,
+		"OEBPS/text/p1.xhtml":    `<html><head><title>Page 1</title></head><body><p><img src="../images/page%201.jpg" alt="p1" onerror="x()"/></p></body></html>`,
+		"OEBPS/text/p2.xhtml": `<html><head><title>Page 2</title></head><body>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href="../images/page 1.jpg"/></svg></body></html>`,
+		"OEBPS/images/page 1.jpg": "jpeg-data",
+	})
+
+	t.Run("Rewrites image URLs", func(t *testing.T) {
+		p := NewEpubParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"})
+		chapters, err := p.Parse(filePath)
+		assert.NoError(t, err)
+		if assert.Len(t, chapters, 2) {
+			assert.Equal(t, `<p><img alt="p1" src="/api/books/b1/resources/OEBPS/images/page%201.jpg"></p>`, chapters[0].ContentHTML)
+			assert.Equal(t, `<img src="/api/books/b1/resources/OEBPS/images/page%201.jpg">`, chapters[1].ContentHTML)
+			assert.Empty(t, chapters[0].Content)
+		}
+	})
+
+	t.Run("Opens resources", func(t *testing.T) {
+		rc, err := NewEpubParser().OpenResource(filePath, "OEBPS/images/page 1.jpg")
+		if assert.NoError(t, err) {
+			data, err := io.ReadAll(rc)
+			assert.NoError(t, err)
+			assert.Equal(t, "jpeg-data", string(data))
+			assert.NoError(t, rc.Close())
+		}
+
+		_, err = NewEpubParser().OpenResource(filePath, "../../etc/passwd")
+		assert.Error(t, err)
+		_, err = NewEpubParser().OpenResource(filePath, "META-INF/container.xml")
+		assert.Error(t, err)
+	})
+}
+
+func TestEpubParser_Footnotes(t *testing.T) {
+	opf := `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Notes</dc:title></metadata>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="notes.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c1"/><itemref idref="notes"/></spine>
+</package>`
+	chapter := `<html xmlns:epub="http://www.idpf.org/2007/ops"><head><title>One</title></head><body>
+<p>Magic<sup><a epub:type="noteref" href="#fn1">※1</a></sup> and mana<a epub:type="noteref" href="notes.xhtml#en1">[2]</a>.</p>
+<aside epub:type="footnote" id="fn1"><p>A kind of spell.</p></aside>
+</body></html>`
+	notes := `<html><head><title>Notes</title></head><body>
+<p id="en1">Energy used by <em>mages</em>.</p>
+</body></html>`
+
+	filePath := writeTestZip(t, "notes.epub", map[string]string{
+		"META-INF/container.xml": testContainerXML,
+		"OEBPS/content.opf":      opf,
+		"OEBPS/c1.xhtml":         chapter,
+		"OEBPS/notes.xhtml":      notes,
+	})
+
+	chapters, err := NewEpubParser().Parse(filePath)
+	assert.NoError(t, err)
+	// The notes page only held note bodies, so it does not become a chapter of its own
+	if assert.Len(t, chapters, 1) {
+		c := chapters[0]
+		assert.Equal(t, "Magic※1 and mana[2].", c.Content)
+		assert.NotContains(t, c.ContentHTML, "A kind of spell")
+		assert.Contains(t, c.ContentHTML, `<a href="#fn1" data-footnote="fn1">※1</a>`)
+		assert.Contains(t, c.ContentHTML, `<a href="#en1" data-footnote="en1">[2]</a>`)
+
+		if assert.Len(t, c.Footnotes, 2) {
+			assert.Equal(t, "fn1", c.Footnotes[0].ID)
+			assert.Equal(t, "※1", c.Footnotes[0].Label)
+			assert.Equal(t, "A kind of spell.", c.Footnotes[0].Content)
+			assert.Equal(t, "en1", c.Footnotes[1].ID)
+			assert.Equal(t, "[2]", c.Footnotes[1].Label)
+			assert.Equal(t, "Energy used by mages.", c.Footnotes[1].Content)
+			assert.Equal(t, "Energy used by <em>mages</em>.", c.Footnotes[1].ContentHTML)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// fb2DocPath is the pseudo path FB2 content is rendered under; images resolve to "binary/<id>"
+const fb2DocPath = "book.fb2"
+
+// fb2Tags maps FB2 elements to the HTML elements they are rendered as
+var fb2Tags = map[string]string{
+	"p": "p", "v": "p", "text-author": "p", "subtitle": "h4",
+	"emphasis": "em", "strong": "strong", "strikethrough": "s", "sub": "sub", "sup": "sup", "code": "code",
+	"cite": "blockquote", "epigraph": "blockquote", "poem": "div", "stanza": "div", "annotation": "div",
+	"table": "table", "tr": "tr", "td": "td", "th": "th",
+}
+
+// Fb2Parser parses FictionBook (.fb2) files, plain or zipped (.fb2.zip)
+type Fb2Parser struct {
+	opts Options
+}
+
+// NewFb2Parser creates a new Fb2Parser
+func NewFb2Parser() *Fb2Parser {
+	return &Fb2Parser{}
+}
+
+// NewFb2ParserWithOptions creates an Fb2Parser that renders content according to opts
+func NewFb2ParserWithOptions(opts Options) *Fb2Parser {
+	return &Fb2Parser{opts: opts}
+}
+
+// Parse maps top-level sections with nested sections to volumes and every other
+// section to a chapter. The notes body becomes the chapters' footnotes.
+func (p *Fb2Parser) Parse(filePath string) ([]models.Chapter, error) {
+	doc, err := readFB2(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []epubSection
+	for _, body := range doc.children("body") {
+		if isFB2NotesBody(body) {
+			continue
+		}
+		sections = append(sections, fb2BodySections(body)...)
+	}
+	chapters := sectionsToChapters(sections, p.opts.ResourceBaseURL, fb2Notes(doc))
+
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no chapters found in fb2")
+	}
+	return chapters, nil
+}
+
+// ExtractMetadata reads <title-info>, <publish-info> and <document-info>
+func (p *Fb2Parser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	doc, err := readFB2(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := doc.child("description")
+	info := desc.child("title-info")
+	publish := desc.child("publish-info")
+
+	var authors []string
+	for _, a := range info.children("author") {
+		name := strings.Join(strings.Fields(a.child("first-name").text()+" "+a.child("middle-name").text()+" "+a.child("last-name").text()), " ")
+		if name == "" {
+			name = strings.TrimSpace(a.child("nickname").text())
+		}
+		if name != "" {
+			authors = append(authors, name)
+		}
+	}
+
+	var annotation []string
+	for _, c := range info.child("annotation").Children {
+		if t := strings.TrimSpace(c.text()); t != "" {
+			annotation = append(annotation, t)
+		}
+	}
+
+	meta := &BookMetadata{
+		Title:       strings.TrimSpace(info.child("book-title").text()),
+		Author:      strings.Join(authors, ", "),
+		Description: strings.Join(annotation, "\n"),
+		Language:    strings.TrimSpace(info.child("lang").text()),
+		Publisher:   strings.TrimSpace(publish.child("publisher").text()),
+		Identifier:  strings.TrimSpace(desc.child("document-info").child("id").text()),
+		Subjects:    splitSubjects([]string{info.child("keywords").text()}),
+	}
+
+	date := info.child("date")
+	meta.PublishedDate = normalizeDate(firstNonEmpty([]string{date.attr("value"), date.text(), publish.child("year").text()}))
+	if isbn, ok := normalizeISBN(publish.child("isbn").text(), "isbn"); ok {
+		meta.ISBN = isbn
+	}
+	if seq := info.child("sequence"); seq != nil {
+		meta.Series = strings.TrimSpace(seq.attr("name"))
+		meta.SeriesIndex, _ = strconv.ParseFloat(strings.TrimSpace(seq.attr("number")), 64)
+	}
+	return meta, nil
+}
+
+// ExtractCover decodes the <binary> referenced by the <coverpage> image
+func (p *Fb2Parser) ExtractCover(filePath string) ([]byte, error) {
+	doc, err := readFB2(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	image := doc.child("description").child("title-info").child("coverpage").child("image")
+	id := strings.TrimPrefix(image.attr("href"), "#")
+	if id == "" {
+		return nil, fmt.Errorf("no cover declared in fb2")
+	}
+	return doc.binary(id)
+}
+
+// OpenResource opens an embedded <binary> by the name chapters refer to it with ("binary/<id>")
+func (p *Fb2Parser) OpenResource(filePath, name string) (io.ReadCloser, error) {
+	dir, id := path.Split(path.Clean("/" + name))
+	if dir != "/binary/" || id == "" {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+
+	doc, err := readFB2(filePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := doc.binary(id)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// readFB2 reads and parses an .fb2 file, unpacking it first if it is zipped
+func readFB2(filePath string) (*xmlNode, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fb2: %w", err)
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open fb2.zip: %w", err)
+		}
+		var entry *zip.File
+		for _, f := range zr.File {
+			if strings.HasSuffix(strings.ToLower(f.Name), ".fb2") {
+				entry = f
+				break
+			}
+		}
+		if entry == nil {
+			return nil, fmt.Errorf("no .fb2 file found in archive")
+		}
+		if data, err = readZipFile(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseFB2(data)
+}
+
+// parseFB2 parses the document; the declared encoding (often windows-1251) is honoured
+func parseFB2(data []byte) (*xmlNode, error) {
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fb2: %w", err)
+	}
+	doc := root.child("FictionBook")
+	if doc == nil {
+		return nil, fmt.Errorf("not a FictionBook document")
+	}
+	return doc, nil
+}
+
+// binary decodes the base64 <binary> with the given id
+func (n *xmlNode) binary(id string) ([]byte, error) {
+	for _, b := range n.children("binary") {
+		if b.attr("id") != id {
+			continue
+		}
+		encoded := strings.Join(strings.Fields(b.text()), "")
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode fb2 binary %s: %w", id, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("resource not found: binary/%s", id)
+}
+
+func isFB2NotesBody(body *xmlNode) bool {
+	name := strings.ToLower(body.attr("name"))
+	return name == "notes" || name == "footnotes" || name == "comments"
+}
+
+// fb2BodySections turns a <body> into sections. Content before the first <section>
+// (other than the body title, which repeats the book title) is kept as front matter.
+func fb2BodySections(body *xmlNode) []epubSection {
+	var sections []epubSection
+
+	lead := &xmlNode{Name: "body"}
+	for _, c := range body.Children {
+		if c.Name == "section" {
+			break
+		}
+		if c.Name != "title" {
+			lead.Children = append(lead.Children, c)
+		}
+	}
+	if h := fb2SectionHTML(lead, 1); !isBlankHTML(h) {
+		sec := epubSection{Title: frontMatterTitle}
+		sec.add(fb2DocPath, h)
+		sections = append(sections, sec)
+	}
+
+	for _, s := range body.children("section") {
+		if len(s.children("section")) == 0 {
+			sections = append(sections, fb2Section(s, 1, len(sections)))
+			continue
+		}
+		// A top-level section holding sections is a part
+		volume := fb2Section(s, 1, len(sections))
+		volume.Volume = true
+		sections = append(sections, volume)
+		for _, sub := range s.children("section") {
+			sections = fb2FlattenSections(sub, 2, sections)
+		}
+	}
+	return sections
+}
+
+// fb2FlattenSections adds a section and, recursively, its subsections as chapters.
+// Sections that only group subsections under a heading are skipped.
+func fb2FlattenSections(s *xmlNode, depth int, sections []epubSection) []epubSection {
+	subsections := s.children("section")
+	sec := fb2Section(s, depth, len(sections))
+	if len(subsections) == 0 || !isBlankHTML(fb2SectionHTML(withoutTitle(s), depth)) {
+		sections = append(sections, sec)
+	}
+	for _, sub := range subsections {
+		sections = fb2FlattenSections(sub, depth+1, sections)
+	}
+	return sections
+}
+
+func fb2Section(s *xmlNode, depth, index int) epubSection {
+	title := fb2Title(s)
+	if title == "" {
+		title = fmt.Sprintf("Chapter %d", index+1)
+	}
+	sec := epubSection{Title: title}
+	sec.add(fb2DocPath, fb2SectionHTML(s, depth))
+	return sec
+}
+
+// fb2Title joins the paragraphs of a section's <title>
+func fb2Title(s *xmlNode) string {
+	var lines []string
+	for _, c := range s.child("title").Children {
+		if t := strings.Join(strings.Fields(c.text()), " "); t != "" {
+			lines = append(lines, t)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func withoutTitle(s *xmlNode) *xmlNode {
+	stripped := &xmlNode{Name: s.Name, Attrs: s.Attrs}
+	for _, c := range s.Children {
+		if c.Name != "title" {
+			stripped.Children = append(stripped.Children, c)
+		}
+	}
+	return stripped
+}
+
+// fb2SectionHTML renders the direct content of a section (not its subsections) as HTML
+func fb2SectionHTML(s *xmlNode, depth int) string {
+	var sb strings.Builder
+	for _, c := range s.Children {
+		if c.Name == "section" {
+			continue
+		}
+		writeFB2HTML(&sb, c, depth)
+	}
+	return sb.String()
+}
+
+func writeFB2HTML(sb *strings.Builder, n *xmlNode, depth int) {
+	switch n.Name {
+	case "":
+		sb.WriteString(html.EscapeString(n.Text))
+		return
+	case "title":
+		level := strconv.Itoa(min(depth+1, 6))
+		sb.WriteString("<h" + level + ">")
+		// Title paragraphs become lines of one heading
+		for i, c := range n.children("p") {
+			if i > 0 {
+				sb.WriteString("<br>")
+			}
+			writeFB2Children(sb, c, depth)
+		}
+		sb.WriteString("</h" + level + ">")
+		return
+	case "empty-line":
+		sb.WriteString("<br>")
+		return
+	case "image":
+		if id := strings.TrimPrefix(n.attr("href"), "#"); id != "" {
+			sb.WriteString(`<img src="binary/` + html.EscapeString(id) + `" alt="` + html.EscapeString(n.attr("alt")) + `">`)
+		}
+		return
+	case "a":
+		// Internal links may point at notes; sectionsToChapters turns those into footnote references
+		if href := n.attr("href"); strings.HasPrefix(href, "#") {
+			sb.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+			writeFB2Children(sb, n, depth)
+			sb.WriteString("</a>")
+			return
+		}
+		writeFB2Children(sb, n, depth)
+		return
+	case "section":
+		writeFB2Children(sb, n, depth+1)
+		return
+	}
+
+	tag, ok := fb2Tags[n.Name]
+	if !ok {
+		writeFB2Children(sb, n, depth)
+		return
+	}
+	sb.WriteString("<" + tag + ">")
+	writeFB2Children(sb, n, depth)
+	sb.WriteString("</" + tag + ">")
+}
+
+func writeFB2Children(sb *strings.Builder, n *xmlNode, depth int) {
+	for _, c := range n.Children {
+		writeFB2HTML(sb, c, depth)
+	}
+}
+
+// fb2Notes collects the sections of the notes body, keyed like EPUB notes so the
+// chapter renderer can link references to them
+func fb2Notes(doc *xmlNode) *epubNotes {
+	notes := &epubNotes{bodies: make(map[string]models.Footnote)}
+	for _, body := range doc.children("body") {
+		if !isFB2NotesBody(body) {
+			continue
+		}
+		var walk func(n *xmlNode)
+		walk = func(n *xmlNode) {
+			for _, s := range n.children("section") {
+				if id := s.attr("id"); id != "" {
+					var sb strings.Builder
+					for _, c := range withoutTitle(s).Children {
+						writeFB2HTML(&sb, c, 1)
+					}
+					notes.bodies[noteKey(fb2DocPath, "#"+id)] = models.Footnote{
+						ID:          id,
+						Label:       fb2Title(s),
+						Content:     htmlToText(sb.String()),
+						ContentHTML: sanitizeHTML(sb.String(), nil),
+					}
+				}
+				walk(s)
+			}
+		}
+		walk(body)
+	}
+	return notes
+}
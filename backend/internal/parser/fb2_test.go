@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const testFB2 = `<?xml version="1.0" encoding="utf-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+  <description>
+    <title-info>
+      <genre>sf_fantasy</genre>
+      <author><first-name>Ivan</first-name><last-name>Petrov</last-name></author>
+      <book-title>The Test Book</book-title>
+      <annotation><p>A short book.</p><p>Second line.</p></annotation>
+      <keywords>magic, dragons</keywords>
+      <date value="2011-02-03">2011</date>
+      <coverpage><image l:href="#cover.png"/></coverpage>
+      <lang>ru</lang>
+      <sequence name="Test Saga" number="2"/>
+    </title-info>
+    <document-info><id>doc-42</id></document-info>
+    <publish-info><publisher>Test House</publisher><isbn>978-0-306-40615-7</isbn></publish-info>
+  </description>
+  <body>
+    <title><p>The Test Book</p></title>
+    <epigraph><p>An opening quote.</p></epigraph>
+    <section>
+      <title><p>Part One</p></title>
+      <section>
+        <title><p>Chapter 1</p></title>
+        <p>It was a <emphasis>cold</emphasis> morning.<a l:href="#n1" type="note">[1]</a></p>
+        <image l:href="#cover.png"/>
+      </section>
+      <section>
+        <title><p>Chapter 2</p></title>
+        <p>The end.</p>
+      </section>
+    </section>
+  </body>
+  <body name="notes">
+    <section id="n1"><title><p>1</p></title><p>A note body.</p></section>
+  </body>
+  <binary id="cover.png" content-type="image/png">iVBORw0KGgpjb3Zlcg==</binary>
+</FictionBook>`
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filePath, data, 0644))
+	return filePath
+}
+
+func TestFb2Parser_Parse(t *testing.T) {
+	filePath := writeTestFile(t, "test.fb2", []byte(testFB2))
+
+	p := NewFb2ParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"})
+	chapters, err := p.Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 4)
+
+	assert.Equal(t, frontMatterTitle, chapters[0].Title)
+	assert.Equal(t, "An opening quote.", chapters[0].Content)
+
+	assert.Equal(t, "Part One", chapters[1].Title)
+	assert.Equal(t, 2, chapters[1].VolumeNumber)
+	assert.Equal(t, 0, chapters[1].VolumeChapterNumber)
+
+	ch1 := chapters[2]
+	assert.Equal(t, "Chapter 1", ch1.Title)
+	assert.Equal(t, 1, ch1.VolumeChapterNumber)
+	assert.Contains(t, ch1.Content, "It was a cold morning.")
+	assert.Contains(t, ch1.ContentHTML, "<em>cold</em>")
+	assert.Contains(t, ch1.ContentHTML, `<a href="#n1" data-footnote="n1">[1]</a>`)
+	assert.Contains(t, ch1.ContentHTML, `<img alt="" src="/api/books/b1/resources/binary/cover.png">`)
+	require.Len(t, ch1.Footnotes, 1)
+	assert.Equal(t, "n1", ch1.Footnotes[0].ID)
+	assert.Equal(t, "[1]", ch1.Footnotes[0].Label)
+	assert.Equal(t, "A note body.", ch1.Footnotes[0].Content)
+
+	assert.Equal(t, "Chapter 2", chapters[3].Title)
+	assert.Equal(t, 2, chapters[3].VolumeChapterNumber)
+
+	rc, err := p.OpenResource(filePath, "binary/cover.png")
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG\r\n\x1a\ncover", string(data))
+}
+
+func TestFb2Parser_ExtractMetadataAndCover(t *testing.T) {
+	filePath := writeTestFile(t, "test.fb2", []byte(testFB2))
+
+	p := NewFb2Parser()
+	meta, err := p.ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "The Test Book", meta.Title)
+	assert.Equal(t, "Ivan Petrov", meta.Author)
+	assert.Equal(t, "A short book.\nSecond line.", meta.Description)
+	assert.Equal(t, "ru", meta.Language)
+	assert.Equal(t, "Test House", meta.Publisher)
+	assert.Equal(t, "9780306406157", meta.ISBN)
+	assert.Equal(t, "doc-42", meta.Identifier)
+	assert.Equal(t, "2011-02-03", meta.PublishedDate)
+	assert.Equal(t, "Test Saga", meta.Series)
+	assert.Equal(t, 2.0, meta.SeriesIndex)
+	assert.Equal(t, []string{"magic", "dragons"}, meta.Subjects)
+
+	cover, err := p.ExtractCover(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG\r\n\x1a\ncover", string(cover))
+}
+
+func TestFb2Parser_ZippedWindows1251(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="windows-1251"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0">
+  <body><section><title><p>Глава 1</p></title><p>Привет, мир.</p></section></body>
+</FictionBook>`
+	encoded, err := charmap.Windows1251.NewEncoder().String(doc)
+	require.NoError(t, err)
+
+	filePath := filepath.Join(t.TempDir(), "test.fb2.zip")
+	f, err := os.Create(filePath)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("book.fb2")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(encoded))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	chapters, err := NewFb2Parser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Equal(t, "Глава 1", chapters[0].Title)
+	assert.Contains(t, chapters[0].Content, "Привет, мир.")
+}
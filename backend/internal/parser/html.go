@@ -0,0 +1,306 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// htmlDocPath is the pseudo path page content is rendered under; MHTML parts resolve to "parts/<n>"
+const htmlDocPath = "index.html"
+
+// HtmlParser parses saved web pages (.html, .htm) and web archives (.mhtml, .mht)
+type HtmlParser struct {
+	opts Options
+}
+
+// NewHtmlParser creates a new HtmlParser
+func NewHtmlParser() *HtmlParser {
+	return &HtmlParser{}
+}
+
+// NewHtmlParserWithOptions creates an HtmlParser that renders content according to opts
+func NewHtmlParserWithOptions(opts Options) *HtmlParser {
+	return &HtmlParser{opts: opts}
+}
+
+// htmlPage is a decoded page together with the parts of its web archive, if any
+type htmlPage struct {
+	doc      *xhtml.Node
+	location string
+	parts    []mhtmlPart
+}
+
+// Parse extracts the main content of the page and splits it into chapters at <h1>-<h3>
+func (p *HtmlParser) Parse(filePath string) ([]models.Chapter, error) {
+	page, err := readHTMLPage(filePath)
+	if err != nil {
+		return nil, err
+	}
+	body := findElement(page.doc, "body")
+	if body == nil {
+		return nil, fmt.Errorf("no body found in html")
+	}
+	if len(page.parts) > 0 {
+		page.linkParts(body)
+	}
+
+	var blocks []docBlock
+	for _, n := range readableContent(body) {
+		blocks = append(blocks, htmlBlocks(n)...)
+	}
+
+	title := pageTitle(page.doc)
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+	chapters := sectionsToChapters(headingSections(blocks, htmlDocPath, title), p.opts.ResourceBaseURL, nil)
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no text found in html")
+	}
+	return chapters, nil
+}
+
+// ExtractMetadata reads the <title>, <html lang> and the common <meta> tags (standard, Open Graph, article)
+func (p *HtmlParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	page, err := readHTMLPage(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := make(map[string]string)
+	walkElements(findElement(page.doc, "head"), func(n *xhtml.Node) bool {
+		if n.Data == "meta" {
+			key := strings.ToLower(firstNonEmpty([]string{attrValue(n, "name"), attrValue(n, "property")}))
+			if _, seen := meta[key]; !seen && key != "" {
+				meta[key] = strings.TrimSpace(attrValue(n, "content"))
+			}
+		}
+		return true
+	})
+
+	var lang string
+	if root := findElement(page.doc, "html"); root != nil {
+		lang = strings.TrimSpace(attrValue(root, "lang"))
+	}
+	var subjects []string
+	if keywords := meta["keywords"]; keywords != "" {
+		subjects = splitSubjects([]string{keywords})
+	}
+	return &BookMetadata{
+		Title:         firstNonEmpty([]string{meta["og:title"], pageTitle(page.doc)}),
+		Author:        firstNonEmpty([]string{meta["author"], meta["article:author"], meta["dc.creator"]}),
+		Description:   cleanDescription(firstNonEmpty([]string{meta["description"], meta["og:description"]})),
+		Language:      lang,
+		Publisher:     meta["og:site_name"],
+		PublishedDate: normalizeDate(firstNonEmpty([]string{meta["article:published_time"], meta["date"], meta["dc.date"]})),
+		Subjects:      subjects,
+	}, nil
+}
+
+// OpenResource opens a part of a web archive by the name chapters refer to it with ("parts/<n>"),
+// or an image saved next to the page (e.g. "page_files/1.png")
+func (p *HtmlParser) OpenResource(filePath, name string) (io.ReadCloser, error) {
+	dir, file := path.Split(path.Clean("/" + name))
+	index, err := strconv.Atoi(file)
+	if dir != "/parts/" || err != nil || !isMHTMLFile(filePath) {
+		return openLocalImage(filePath, name)
+	}
+
+	parts, err := mhtmlArchives.parts(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(parts) {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+	return io.NopCloser(bytes.NewReader(parts[index].Data)), nil
+}
+
+// readHTMLPage reads an HTML file or web archive and parses the page in its declared charset
+func readHTMLPage(filePath string) (*htmlPage, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read html: %w", err)
+	}
+
+	page := &htmlPage{}
+	contentType := "text/html"
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".mhtml" || ext == ".mht" || isMHTML(data) {
+		if page.parts, err = readMHTML(data); err != nil {
+			return nil, err
+		}
+		root := page.mainPart()
+		if root == nil {
+			return nil, fmt.Errorf("no html part found in mhtml")
+		}
+		data, contentType, page.location = root.Data, root.ContentType, root.Location
+	}
+
+	// charset.NewReader honours the BOM, the Content-Type charset and <meta charset>, in that order
+	r, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode html: %w", err)
+	}
+	if page.doc, err = xhtml.Parse(r); err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	return page, nil
+}
+
+// mainPart returns the first HTML part of the archive, which is the saved page
+func (page *htmlPage) mainPart() *mhtmlPart {
+	for i := range page.parts {
+		if strings.HasPrefix(page.parts[i].ContentType, "text/html") {
+			return &page.parts[i]
+		}
+	}
+	return nil
+}
+
+// linkParts points images at the archive parts they were saved as
+func (page *htmlPage) linkParts(body *xhtml.Node) {
+	base, _ := url.Parse(page.location)
+	lookup := func(src string) (int, bool) {
+		src = strings.TrimSpace(src)
+		if id, ok := strings.CutPrefix(src, "cid:"); ok {
+			for i, part := range page.parts {
+				if part.ContentID == id {
+					return i, true
+				}
+			}
+			return 0, false
+		}
+		if base != nil {
+			if ref, err := base.Parse(src); err == nil {
+				src = ref.String()
+			}
+		}
+		for i, part := range page.parts {
+			if part.Location != "" && part.Location == src {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	walkElements(body, func(n *xhtml.Node) bool {
+		if n.Data != "img" {
+			return true
+		}
+		for i, a := range n.Attr {
+			if a.Key == "src" {
+				if index, ok := lookup(a.Val); ok {
+					n.Attr[i].Val = "parts/" + strconv.Itoa(index)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// htmlBlocks turns a content node into document blocks; containers holding chapter
+// headings are descended into so every <h1>-<h3> becomes its own block
+func htmlBlocks(n *xhtml.Node) []docBlock {
+	if n.Type == xhtml.ElementNode {
+		if level := chapterHeadingLevel(n); level > 0 {
+			block := headingBlock(level, renderChildren(n))
+			// All of <h1>-<h3> start chapters; headingSections treats a lone level as chapters
+			if block.Level > 0 {
+				block.Level = 1
+			}
+			return []docBlock{block}
+		}
+		if containsChapterHeading(n) {
+			var blocks []docBlock
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				blocks = append(blocks, htmlBlocks(c)...)
+			}
+			return blocks
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := xhtml.Render(&buf, n); err != nil {
+		return nil
+	}
+	return []docBlock{{HTML: buf.String()}}
+}
+
+func chapterHeadingLevel(n *xhtml.Node) int {
+	switch n.Data {
+	case "h1":
+		return 1
+	case "h2":
+		return 2
+	case "h3":
+		return 3
+	}
+	return 0
+}
+
+func containsChapterHeading(n *xhtml.Node) bool {
+	found := false
+	walkElements(n, func(c *xhtml.Node) bool {
+		if c != n && chapterHeadingLevel(c) > 0 {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func renderChildren(n *xhtml.Node) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		xhtml.Render(&buf, c)
+	}
+	return buf.String()
+}
+
+// pageTitle returns the text of <title>
+func pageTitle(doc *xhtml.Node) string {
+	title := findElement(findElement(doc, "head"), "title")
+	if title == nil {
+		return ""
+	}
+	return textContent(title)
+}
+
+// findElement returns the first element named tag in document order
+func findElement(n *xhtml.Node, tag string) *xhtml.Node {
+	var found *xhtml.Node
+	walkElements(n, func(c *xhtml.Node) bool {
+		if found == nil && c.Data == tag {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+// walkElements calls fn for n and its descendant elements in document order;
+// fn returning false skips the element's children
+func walkElements(n *xhtml.Node, fn func(*xhtml.Node) bool) {
+	if n == nil {
+		return
+	}
+	if n.Type == xhtml.ElementNode && !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkElements(c, fn)
+	}
+}
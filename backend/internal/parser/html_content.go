@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// Class and id hints used to score content blocks, after Mozilla Readability
+var (
+	unlikelyCandidatePattern = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|menu|pager|pagination|popup|related|remark|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|^ad-|-ad$|\bnav`)
+	likelyCandidatePattern   = regexp.MustCompile(`(?i)article|body|chapter|column|content|main|shadow|story|text`)
+	positiveWeightPattern    = regexp.MustCompile(`(?i)article|blog|body|chapter|content|entry|hentry|main|page|post|story|text`)
+	negativeWeightPattern    = regexp.MustCompile(`(?i)combx|comment|com-|contact|foot|masthead|media|meta|outbrain|promo|related|scroll|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|nav|menu`)
+)
+
+// boilerplateTags never hold the main content of a page
+var boilerplateTags = map[string]bool{
+	"nav": true, "aside": true, "footer": true, "form": true, "menu": true, "dialog": true,
+	"script": true, "style": true, "noscript": true, "template": true, "iframe": true,
+}
+
+// minParagraphLength is the text length below which a block does not add to its container's score
+const minParagraphLength = 25
+
+// readableContent removes boilerplate from body and returns the nodes holding the main content:
+// the best scoring container, plus its siblings that look like part of the same text
+func readableContent(body *xhtml.Node) []*xhtml.Node {
+	removeBoilerplate(body)
+
+	scores := make(map[*xhtml.Node]float64)
+	walkElements(body, func(n *xhtml.Node) bool {
+		if !isScoredBlock(n) {
+			return true
+		}
+		text := textContent(n)
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return true
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "。"))
+		score += min(float64(length)/100, 3)
+
+		for i, ancestor := 0, n.Parent; i < 2 && ancestor != nil && ancestor.Type == xhtml.ElementNode; i, ancestor = i+1, ancestor.Parent {
+			if _, ok := scores[ancestor]; !ok {
+				scores[ancestor] = initialScore(ancestor)
+			}
+			scores[ancestor] += score / float64(i+1)
+		}
+		return true
+	})
+
+	var top *xhtml.Node
+	topScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if top == nil || score > topScore {
+			top, topScore = n, score
+		}
+	}
+	if top == nil {
+		return childNodes(body)
+	}
+
+	// A wrapper that holds little besides the candidate (a heading, a byline) is part of the content
+	for top != body && top.Parent != nil && textLength(top) >= textLength(top.Parent)*7/10 {
+		top = top.Parent
+	}
+	if top == body {
+		return childNodes(body)
+	}
+
+	threshold := max(10, topScore*0.2)
+	var nodes []*xhtml.Node
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		switch {
+		case s == top:
+			nodes = append(nodes, s)
+		case s.Type != xhtml.ElementNode:
+		case chapterHeadingLevel(s) > 0:
+			nodes = append(nodes, s)
+		case scores[s]*(1-linkDensity(s)) >= threshold:
+			nodes = append(nodes, s)
+		case s.Data == "p" && textLength(s) > 80 && linkDensity(s) < 0.25:
+			nodes = append(nodes, s)
+		}
+	}
+	return nodes
+}
+
+// removeBoilerplate drops navigation, forms, scripts and elements whose class or id marks them as page chrome
+func removeBoilerplate(n *xhtml.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == xhtml.CommentNode || c.Type == xhtml.ElementNode && isBoilerplate(c) {
+			n.RemoveChild(c)
+		} else {
+			removeBoilerplate(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *xhtml.Node) bool {
+	if boilerplateTags[n.Data] || strings.EqualFold(attrValue(n, "aria-hidden"), "true") || hasAttr(n, "hidden") {
+		return true
+	}
+	switch n.Data {
+	case "body", "article", "main", "a", "h1", "h2", "h3":
+		return false
+	}
+	hints := attrValue(n, "class") + " " + attrValue(n, "id")
+	return unlikelyCandidatePattern.MatchString(hints) && !likelyCandidatePattern.MatchString(hints)
+}
+
+// isScoredBlock reports whether n is a paragraph-like block: a <p>, <pre>, <td> or <blockquote>,
+// or a <div> used as a paragraph (without block children)
+func isScoredBlock(n *xhtml.Node) bool {
+	switch n.Data {
+	case "p", "pre", "td", "blockquote":
+		return true
+	case "div":
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == xhtml.ElementNode && blockTags[c.Data] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func initialScore(n *xhtml.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "article", "main":
+		score = 10
+	case "div", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "ol", "ul", "dl", "dd", "dt", "li", "address":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+	hints := attrValue(n, "class") + " " + attrValue(n, "id")
+	if positiveWeightPattern.MatchString(hints) {
+		score += 25
+	}
+	if negativeWeightPattern.MatchString(hints) {
+		score -= 25
+	}
+	return score
+}
+
+// linkDensity is the share of n's text that sits inside links
+func linkDensity(n *xhtml.Node) float64 {
+	total := textLength(n)
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	walkElements(n, func(c *xhtml.Node) bool {
+		if c.Data == "a" {
+			linked += textLength(c)
+			return false
+		}
+		return true
+	})
+	return float64(linked) / float64(total)
+}
+
+func textLength(n *xhtml.Node) int {
+	return utf8.RuneCountInString(textContent(n))
+}
+
+func hasAttr(n *xhtml.Node, key string) bool {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func childNodes(n *xhtml.Node) []*xhtml.Node {
+	var nodes []*xhtml.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, c)
+	}
+	return nodes
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+const testHTMLPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>The Test Story</title>
+  <meta name="author" content="Jane Doe">
+  <meta name="description" content="A short story.">
+  <meta property="og:site_name" content="Test Stories">
+  <meta name="keywords" content="magic, dragons">
+  <meta property="article:published_time" content="2020-05-06T07:08:09Z">
+</head>
+<body>
+  <header class="site-header"><h1>Test Stories</h1><a href="/">Home</a></header>
+  <nav><a href="/a">Previous</a> <a href="/b">Next</a></nav>
+  <div id="main-content" class="post">
+    <p>Some introductory words, before any chapter begins, set the scene.</p>
+    <h2>Chapter 1</h2>
+    <p>It was a <em>cold</em> morning, and the dragons were still asleep in the hills.</p>
+    <p>Nobody in the village, not even the baker, had noticed the smoke yet.</p>
+    <section>
+      <h3>Chapter 2</h3>
+      <p>By noon, however, the whole valley was awake and talking about the dragons.</p>
+    </section>
+  </div>
+  <div class="sidebar-comments"><p>Great story, thanks for sharing it with all of us here!</p></div>
+  <footer>Copyright, all rights reserved, and some more footer text here.</footer>
+</body>
+</html>`
+
+func TestHtmlParser_Parse(t *testing.T) {
+	filePath := writeTestFile(t, "story.html", []byte(testHTMLPage))
+
+	chapters, err := NewHtmlParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 3)
+
+	assert.Equal(t, frontMatterTitle, chapters[0].Title)
+	assert.Contains(t, chapters[0].Content, "introductory words")
+
+	assert.Equal(t, "Chapter 1", chapters[1].Title)
+	assert.Contains(t, chapters[1].ContentHTML, "<em>cold</em>")
+	assert.Contains(t, chapters[1].Content, "the baker")
+
+	assert.Equal(t, "Chapter 2", chapters[2].Title)
+	assert.Contains(t, chapters[2].Content, "whole valley")
+
+	for _, ch := range chapters {
+		assert.NotContains(t, ch.Content, "Great story")
+		assert.NotContains(t, ch.Content, "Copyright")
+		assert.NotContains(t, ch.Content, "Previous")
+	}
+}
+
+func TestHtmlParser_DeclaredCharset(t *testing.T) {
+	page := `<html><head><meta http-equiv="Content-Type" content="text/html; charset=gbk"><title>测试</title></head>
+<body><h1>第一章</h1><p>天色微明，村庄还在沉睡之中，炊烟尚未升起，远处的山岭一片寂静。</p></body></html>`
+	encoded, err := simplifiedchinese.GBK.NewEncoder().String(page)
+	require.NoError(t, err)
+	filePath := writeTestFile(t, "gbk.htm", []byte(encoded))
+
+	chapters, err := NewHtmlParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Equal(t, "第一章", chapters[0].Title)
+	assert.Contains(t, chapters[0].Content, "村庄还在沉睡之中")
+}
+
+func TestHtmlParser_ExtractMetadata(t *testing.T) {
+	filePath := writeTestFile(t, "story.html", []byte(testHTMLPage))
+
+	meta, err := NewHtmlParser().ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "The Test Story", meta.Title)
+	assert.Equal(t, "Jane Doe", meta.Author)
+	assert.Equal(t, "A short story.", meta.Description)
+	assert.Equal(t, "en", meta.Language)
+	assert.Equal(t, "Test Stories", meta.Publisher)
+	assert.Equal(t, "2020-05-06", meta.PublishedDate)
+	assert.Equal(t, []string{"magic", "dragons"}, meta.Subjects)
+}
+
+func TestHtmlParser_MHTML(t *testing.T) {
+	archive := strings.ReplaceAll(`From: <Saved by Blink>
+Subject: The Test Story
+MIME-Version: 1.0
+Content-Type: multipart/related;
+	type="text/html";
+	boundary="----MultipartBoundary--abc"
+
+------MultipartBoundary--abc
+Content-Type: text/html
+Content-ID: <frame-1@mhtml.blink>
+Content-Transfer-Encoding: quoted-printable
+Content-Location: https://example.com/story/index.html
+
+<html><head><meta http-equiv=3D"Content-Type" content=3D"text/html; charset=3DUTF-8"><title>The Test Story</title></head>=
+<body><article><h2>Chapter 1</h2><p>It was a cold morning, and the dragons were st=
+ill asleep in the hills.</p><img src=3D"images/dragon.png"></article></body></html>
+------MultipartBoundary--abc
+Content-Type: image/png
+Content-Transfer-Encoding: base64
+Content-Location: https://example.com/story/images/dragon.png
+
+iVBORw0KGgpk
+cmFnb24=
+------MultipartBoundary--abc--
+`, "\n", "\r\n")
+	filePath := writeTestFile(t, "story.mhtml", []byte(archive))
+
+	p := NewHtmlParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"})
+	chapters, err := p.Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Equal(t, "Chapter 1", chapters[0].Title)
+	assert.Contains(t, chapters[0].Content, "the dragons were still asleep")
+	assert.Contains(t, chapters[0].ContentHTML, `<img src="/api/books/b1/resources/parts/1">`)
+
+	for i := 0; i < 2; i++ {
+		// The second request is served from the unpacked archive
+		rc, err := p.OpenResource(filePath, "parts/1")
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "\x89PNG\r\n\x1a\ndragon", string(data))
+	}
+
+	// An archive changed on disk is unpacked again
+	require.NoError(t, os.WriteFile(filePath, []byte(strings.ReplaceAll(archive, "cmFnb24=", "aW1hZ2U=")), 0644))
+	require.NoError(t, os.Chtimes(filePath, time.Now(), time.Now().Add(time.Minute)))
+	rc, err := p.OpenResource(filePath, "parts/1")
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG\r\n\x1a\ndimage", string(data))
+}
+
+func TestHtmlParser_LocalImages(t *testing.T) {
+	dir := t.TempDir()
+	page := `<html><head><title>Saved</title></head><body><article><h2>Chapter 1</h2>` +
+		`<p>The dragons were still asleep in the hills, and nobody had noticed the smoke.</p>` +
+		`<img src="story_files/dragon.png"><img src="../outside.png"></article></body></html>`
+	filePath := filepath.Join(dir, "story.html")
+	require.NoError(t, os.WriteFile(filePath, []byte(page), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "story_files"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "story_files", "dragon.png"), []byte("png"), 0644))
+
+	p := NewHtmlParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"})
+	chapters, err := p.Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Contains(t, chapters[0].ContentHTML, `<img src="/api/books/b1/resources/story_files/dragon.png">`)
+	assert.NotContains(t, chapters[0].ContentHTML, "outside.png")
+
+	rc, err := p.OpenResource(filePath, "story_files/dragon.png")
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	for _, name := range []string{"../outside.png", "story.html", "parts/1"} {
+		_, err := p.OpenResource(filePath, name)
+		assert.Error(t, err, name)
+	}
+}
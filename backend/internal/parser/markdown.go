@@ -2,100 +2,329 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/textstat"
+	"golang.org/x/text/transform"
+)
+
+// Heading modes for Options.MarkdownHeadings
+const (
+	// MarkdownHeadingsAuto makes "#" headings volumes and "##" headings chapters when a file
+	// uses both, and makes its headings chapters when it uses only one of the two levels
+	MarkdownHeadingsAuto = ""
+	// MarkdownHeadingsVolumes makes "#" headings volumes and "##" headings chapters
+	MarkdownHeadingsVolumes = "volumes"
+	// MarkdownHeadingsChapters makes both "#" and "##" headings chapters
+	MarkdownHeadingsChapters = "chapters"
+)
+
+var (
+	// atxHeadingPattern matches "# Title" headings; "#hashtag" lines are not headings
+	atxHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*))?$`)
+	// closingHashesPattern matches the optional closing sequence of "## Title ##"
+	closingHashesPattern = regexp.MustCompile(`(?:^|[ \t]+)#+[ \t]*$`)
+	// codeFencePattern matches the opening or closing line of a fenced code block
+	codeFencePattern = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
 )
 
 // MarkdownParser parses .md files
-type MarkdownParser struct{}
+type MarkdownParser struct {
+	opts Options
+}
 
 // NewMarkdownParser creates a new MarkdownParser
 func NewMarkdownParser() *MarkdownParser {
 	return &MarkdownParser{}
 }
 
+// NewMarkdownParserWithOptions creates a MarkdownParser that maps headings with opts.MarkdownHeadings
+func NewMarkdownParserWithOptions(opts Options) *MarkdownParser {
+	return &MarkdownParser{opts: opts}
+}
+
 // Parse parses a markdown file and returns chapters
 // Chapters are determined by ## or # headers
 func (p *MarkdownParser) Parse(filePath string) ([]models.Chapter, error) {
-	file, err := os.Open(filePath)
+	return collectChapters(p, filePath)
+}
+
+// ParseStream reads a markdown file line by line, decoded from its detected charset, and hands
+// its chapters to emit. Front matter is skipped, headings inside fenced code are ignored and text before the
+// first heading is kept as a preface. Progress is in bytes.
+func (p *MarkdownParser) ParseStream(ctx context.Context, r io.ReaderAt, size int64, emit ChapterFunc) error {
+	// Markdown is decoded like TXT; bodyStart counts decoded bytes
+	enc := detectEncoding(r)
+	decode := func(raw io.Reader) io.Reader {
+		return transform.NewReader(raw, enc.NewDecoder())
+	}
+	_, bodyStart := markdownFrontMatter(decode(io.NewSectionReader(r, 0, size)))
+	body, err := skipBytes(decode(io.NewSectionReader(r, 0, size)), bodyStart)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return err
+	}
+	used, refs, err := scanMarkdown(body)
+	if err != nil {
+		return err
+	}
+	levels, err := p.headingLevels(used)
+	if err != nil {
+		return err
 	}
-	defer file.Close()
 
-	var chapters []models.Chapter
-	var currentChapter *models.Chapter
-	chapterNumber := 0
+	counter := &countingReader{r: io.NewSectionReader(r, 0, size)}
+	body, err = skipBytes(decode(counter), bodyStart)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(body)
+	sink := newChapterSink(ctx, p.opts.splitWords(), emit)
+	progress := func() Progress {
+		return Progress{Done: counter.n, Total: size}
+	}
 
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
+	currentChapter := &models.Chapter{VolumeNumber: 1, VolumeChapterNumber: 1, Title: frontMatterTitle}
+	isPreface := true
+	volumeNumber := 1
+	headings := 0
+	emitted := 0
 
 	var contentBuilder strings.Builder
 
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Check if line is a markdown header
-		if strings.HasPrefix(strings.TrimSpace(line), "#") {
-			// Save previous chapter if exists
-			if currentChapter != nil {
-				currentChapter.Content = contentBuilder.String()
-				currentChapter.WordCount = len(strings.Fields(currentChapter.Content))
-				chapters = append(chapters, *currentChapter)
+	saveCurrentChapter := func() error {
+		if currentChapter == nil {
+			return nil
+		}
+		chapter := *currentChapter
+		content := contentBuilder.String()
+		currentChapter = nil
+		contentBuilder.Reset()
+
+		// Headings always make a chapter; only an empty preface is dropped
+		if isPreface {
+			isPreface = false
+			if strings.TrimSpace(content) == "" {
+				return nil
 			}
+		}
+		chapter.ID = uuid.New().String()
+		chapter.ContentHTML, chapter.Content, chapter.Footnotes = RenderMarkdown(refs.appendTo(content), p.opts.ResourceBaseURL)
+		chapter.WordCount = textstat.WordCount(chapter.Content)
+		emitted++
+		return sink.add(chapter, progress())
+	}
 
-			// Start new chapter
-			chapterNumber++
-			currentChapter = &models.Chapter{
-				ID:                  uuid.New().String(),
-				ChapterNumber:       chapterNumber,
-				VolumeNumber:        1,
-				VolumeChapterNumber: chapterNumber,
-				Title:               strings.TrimSpace(strings.TrimLeft(line, "#")),
+	var fence codeFence
+	var definition footnoteBlock
+	for lines := 0; ; lines++ {
+		raw, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("error reading file: %w", readErr)
+		}
+		if raw == "" && readErr == io.EOF {
+			break
+		}
+		if lines%1000 == 999 {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
-			contentBuilder.Reset()
-		} else if currentChapter != nil {
-			// Add content to current chapter
+		}
+
+		line := strings.TrimRight(raw, "\r\n")
+		if lines == 0 && bodyStart == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		if fence.inCode(line) {
+			definition = footnoteBlock{}
+		} else if _, _, ok := definition.feed(line); ok {
+			// Footnote definitions are added to the chapters that refer to them
+			continue
+		} else {
+			if level, title, ok := markdownHeading(line); ok {
+				switch levels.kind(level) {
+				case volumeLine:
+					if err := saveCurrentChapter(); err != nil {
+						return err
+					}
+					// Chapters before the first volume heading make up volume 1
+					if headings > 0 {
+						volumeNumber++
+					}
+					headings++
+					currentChapter = &models.Chapter{VolumeNumber: volumeNumber, VolumeChapterNumber: 0, Title: title}
+					continue
+				case chapterLine:
+					if err := saveCurrentChapter(); err != nil {
+						return err
+					}
+					headings++
+					currentChapter = &models.Chapter{VolumeNumber: volumeNumber, VolumeChapterNumber: 1, Title: title}
+					continue
+				}
+			}
+		}
+
+		if currentChapter != nil {
 			contentBuilder.WriteString(line)
-			contentBuilder.WriteString("\n")
+			if strings.HasSuffix(raw, "\n") {
+				contentBuilder.WriteString("\n")
+			}
+		}
+		if readErr == io.EOF {
+			break
 		}
 	}
 
-	// Save last chapter
-	if currentChapter != nil {
-		currentChapter.Content = contentBuilder.String()
-		currentChapter.WordCount = len(strings.Fields(currentChapter.Content))
-		chapters = append(chapters, *currentChapter)
+	// Save last chapter; a file without headings is not a preface but one untitled chapter,
+	// which the sink names "Chapter 1"
+	if headings == 0 {
+		currentChapter.Title = ""
+	}
+	if err := saveCurrentChapter(); err != nil {
+		return err
 	}
 
-	if err := scanner.Err(); err != nil {
+	// An empty file still gets a chapter
+	if emitted == 0 {
+		return sink.add(models.Chapter{
+			ID:                  uuid.New().String(),
+			VolumeNumber:        1,
+			VolumeChapterNumber: 1,
+		}, progress())
+	}
+	return nil
+}
+
+// ExtractMetadata reads title, author, description and tags from YAML or TOML front matter
+func (p *MarkdownParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	meta, _ := markdownFrontMatter(transform.NewReader(file, detectEncoding(file).NewDecoder()))
+	if meta == nil {
+		return nil, fmt.Errorf("no front matter found")
+	}
+	return meta, nil
+}
+
+// markdownLevels tells which heading levels start volumes and chapters; volume is 0
+// when headings up to the chapter level all start chapters
+type markdownLevels struct {
+	volume  int
+	chapter int
+}
+
+func (l markdownLevels) kind(level int) lineKind {
+	switch {
+	case l.volume > 0 && level == l.volume:
+		return volumeLine
+	case l.volume == 0 && level <= l.chapter, level == l.chapter:
+		return chapterLine
+	}
+	return bodyLine
+}
+
+// headingLevels resolves p's heading mode; MarkdownHeadingsAuto looks at the heading levels the file uses
+func (p *MarkdownParser) headingLevels(used [7]bool) (markdownLevels, error) {
+	switch p.opts.MarkdownHeadings {
+	case MarkdownHeadingsVolumes:
+		return markdownLevels{volume: 1, chapter: 2}, nil
+	case MarkdownHeadingsChapters:
+		return markdownLevels{chapter: 2}, nil
+	case MarkdownHeadingsAuto:
+	default:
+		return markdownLevels{}, fmt.Errorf("unknown markdown heading mode %q", p.opts.MarkdownHeadings)
+	}
+
+	switch {
+	case used[1] && used[2]:
+		return markdownLevels{volume: 1, chapter: 2}, nil
+	case used[1]:
+		return markdownLevels{chapter: 1}, nil
+	case used[2]:
+		return markdownLevels{chapter: 2}, nil
+	}
+	// Without "#" or "##" headings, the top level the file uses starts chapters
+	for level := 3; level < len(used); level++ {
+		if used[level] {
+			return markdownLevels{chapter: level}, nil
+		}
+	}
+	return markdownLevels{}, nil
+}
+
+// skipBytes discards the first n bytes of r, such as a front matter block already read
+func skipBytes(r io.Reader, n int64) (io.Reader, error) {
+	if _, err := io.CopyN(io.Discard, r, n); err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
+	return r, nil
+}
 
-	// If no chapters found, create a single chapter with all content
-	if len(chapters) == 0 {
-		file.Seek(0, 0)
-		content, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
+// scanMarkdown reads the body of a markdown file ahead of parsing it, for the heading levels
+// it uses and the footnote and link definitions chapters may refer to
+func scanMarkdown(r io.Reader) ([7]bool, *markdownReferences, error) {
+	var used [7]bool
+	refs := newMarkdownReferences()
+	var fence codeFence
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		if fence.inCode(line) {
+			refs.block = footnoteBlock{}
+			continue
 		}
+		refs.collect(line)
+		if level, _, ok := markdownHeading(line); ok {
+			used[level] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return used, nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return used, refs, nil
+}
 
-		return []models.Chapter{
-			{
-				ID:                  uuid.New().String(),
-				ChapterNumber:       1,
-				VolumeNumber:        1,
-				VolumeChapterNumber: 1,
-				Title:               "Chapter 1",
-				Content:             string(content),
-				WordCount:           len(strings.Fields(string(content))),
-			},
-		}, nil
+// markdownHeading returns the level and text of an ATX heading line
+func markdownHeading(line string) (int, string, bool) {
+	m := atxHeadingPattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0, "", false
 	}
+	title := closingHashesPattern.ReplaceAllString(strings.TrimSpace(m[2]), "")
+	return len(m[1]), strings.TrimSpace(title), true
+}
 
-	return chapters, nil
+// codeFence tracks whether lines are inside a fenced code block
+type codeFence struct {
+	marker string
+}
+
+// inCode reports whether line belongs to a fenced code block, fence lines included
+func (f *codeFence) inCode(line string) bool {
+	m := codeFencePattern.FindStringSubmatch(line)
+	if f.marker == "" {
+		if m == nil {
+			return false
+		}
+		f.marker = m[1]
+		return true
+	}
+	// A closing fence uses the same character, is at least as long and has no info string
+	if m != nil && m[1][0] == f.marker[0] && len(m[1]) >= len(f.marker) && strings.TrimSpace(line[len(m[0]):]) == "" {
+		f.marker = ""
+	}
+	return true
 }
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxMarkdownFrontMatterLines bounds the search for the end of a front matter block
+const maxMarkdownFrontMatterLines = 1000
+
+// markdownFrontMatter reads a YAML ("---") or TOML ("+++") block at the start of a markdown
+// file and returns its metadata and its length in bytes. Only title, author(s), description
+// and tags are read. It returns nil and 0 when the file has no complete block.
+func markdownFrontMatter(r io.Reader) (*BookMetadata, int64) {
+	reader := bufio.NewReader(r)
+	var lines []string
+	var size int64
+	closing := ""
+	for len(lines) <= maxMarkdownFrontMatterLines {
+		raw, err := reader.ReadString('\n')
+		size += int64(len(raw))
+		line := strings.TrimRight(raw, "\r\n")
+		if closing == "" {
+			switch strings.TrimSpace(strings.TrimPrefix(line, "\ufeff")) {
+			case "---":
+				closing = "---"
+			case "+++":
+				closing = "+++"
+			default:
+				return nil, 0
+			}
+		} else if line == closing || (closing == "---" && line == "...") {
+			if closing == "+++" {
+				return tomlFrontMatter(lines), size
+			}
+			return yamlFrontMatter(lines), size
+		} else {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return nil, 0
+}
+
+// frontMatterValues collects the values of the front matter keys the parser knows about
+type frontMatterValues map[string][]string
+
+func (v frontMatterValues) add(key string, values ...string) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			v[key] = append(v[key], value)
+		}
+	}
+}
+
+func (v frontMatterValues) metadata() *BookMetadata {
+	first := func(key string) string {
+		if values := v[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	return &BookMetadata{
+		Title:       first("title"),
+		Author:      strings.Join(append(v["author"], v["authors"]...), ", "),
+		Description: firstNonEmpty([]string{first("description"), first("summary")}),
+		Subjects:    append(v["tags"], v["keywords"]...),
+	}
+}
+
+// yamlFrontMatter reads top-level "key: value" pairs; values may be quoted, inline lists,
+// "- item" lists or "|" and ">" block scalars
+func yamlFrontMatter(lines []string) *BookMetadata {
+	values := frontMatterValues{}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		// Indented lines that follow belong to the key
+		var block []string
+		for i+1 < len(lines) && (strings.TrimSpace(lines[i+1]) == "" || lines[i+1][0] == ' ' || lines[i+1][0] == '\t') {
+			i++
+			block = append(block, strings.TrimSpace(lines[i]))
+		}
+
+		switch {
+		case strings.HasPrefix(value, "["):
+			values.add(key, splitInlineList(value)...)
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			sep := "\n"
+			if value[0] == '>' {
+				sep = " "
+			}
+			values.add(key, strings.Join(block, sep))
+		case value == "":
+			for _, item := range block {
+				if rest, ok := strings.CutPrefix(item, "-"); ok {
+					values.add(key, unquoteFrontMatter(rest))
+				}
+			}
+		default:
+			values.add(key, unquoteFrontMatter(value))
+		}
+	}
+	return values.metadata()
+}
+
+// tomlFrontMatter reads top-level "key = value" pairs; values may be strings or arrays,
+// which may span several lines. Keys below the first table header are ignored.
+func tomlFrontMatter(lines []string) *BookMetadata {
+	values := frontMatterValues{}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "[") {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "[") {
+			for !strings.HasSuffix(value, "]") && i+1 < len(lines) {
+				i++
+				value += " " + strings.TrimSpace(lines[i])
+			}
+			values.add(key, splitInlineList(value)...)
+			continue
+		}
+		values.add(key, unquoteFrontMatter(value))
+	}
+	return values.metadata()
+}
+
+// splitInlineList splits `["a", 'b', c]` into its items
+func splitInlineList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "["), "]")
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range value {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, unquoteFrontMatter(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, unquoteFrontMatter(value[start:]))
+}
+
+// unquoteFrontMatter strips the quotes around a scalar value
+func unquoteFrontMatter(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return value
+	}
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+		return value[1 : len(value)-1]
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
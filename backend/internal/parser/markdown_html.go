@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	xhtml "golang.org/x/net/html"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// markdownRenderer renders CommonMark with the GFM extensions (tables, strikethrough, autolinks,
+// task lists) and footnotes. Raw HTML in the source is left out.
+var markdownRenderer = goldmark.New(goldmark.WithExtensions(extension.GFM, extension.Footnote))
+
+var (
+	// footnoteDefinitionPattern matches the first line of a "[^label]: text" footnote definition
+	footnoteDefinitionPattern = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:`)
+	// footnoteReferencePattern matches "[^label]" footnote references
+	footnoteReferencePattern = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+	// linkDefinitionPattern matches "[label]: url" link reference definitions
+	linkDefinitionPattern = regexp.MustCompile(`^ {0,3}\[[^\]^][^\]]*\]:\s*\S`)
+)
+
+// RenderMarkdown renders markdown as sanitized chapter HTML and returns it with its plain text
+// and footnotes. Relative image paths are served from resourceBaseURL (see Options); images
+// are dropped when it is empty.
+func RenderMarkdown(source, resourceBaseURL string) (string, string, models.Footnotes) {
+	resolveURL := resourceResolver(resourceBaseURL, "")
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		// Rendering only fails on write errors, which a buffer never returns
+		return "<pre>" + html.EscapeString(source) + "</pre>", source, nil
+	}
+	rendered := buf.String()
+
+	s := &sanitizer{resolveURL: resolveURL, skip: isMarkdownFootnotes, noteRef: markdownNoteRef}
+	contentHTML := s.sanitize(rendered)
+	text := renderText(rendered, isMarkdownFootnotes)
+
+	var footnotes models.Footnotes
+	for _, n := range parseHTMLFragment(rendered) {
+		walkElements(n, func(el *xhtml.Node) bool {
+			if !isMarkdownFootnotes(el) {
+				return true
+			}
+			footnotes = markdownFootnotes(el, resolveURL)
+			return false
+		})
+	}
+	return contentHTML, text, footnotes
+}
+
+// TextToHTML renders plain chapter text as HTML, one paragraph per non-empty line
+func TextToHTML(text string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sb.WriteString("<p>" + html.EscapeString(line) + "</p>")
+		}
+	}
+	return sb.String()
+}
+
+// isMarkdownFootnotes matches the footnote list goldmark appends to a document
+func isMarkdownFootnotes(n *xhtml.Node) bool {
+	return n.Type == xhtml.ElementNode && n.Data == "div" && hasProperty(attrValue(n, "class"), "footnotes")
+}
+
+// removeBackrefs removes the links from a footnote back to its reference, with the
+// non-breaking space goldmark puts before them
+func removeBackrefs(n *xhtml.Node) {
+	var backrefs []*xhtml.Node
+	walkElements(n, func(el *xhtml.Node) bool {
+		if el.Data == "a" && hasProperty(attrValue(el, "class"), "footnote-backref") {
+			backrefs = append(backrefs, el)
+		}
+		return true
+	})
+	for _, a := range backrefs {
+		if prev := a.PrevSibling; prev != nil && prev.Type == xhtml.TextNode {
+			prev.Data = strings.TrimRight(prev.Data, "\u00a0")
+		}
+		a.Parent.RemoveChild(a)
+	}
+}
+
+// markdownNoteRef returns the footnote ID of a goldmark footnote reference link ("#fn:1")
+func markdownNoteRef(href, _ string) string {
+	if id, ok := strings.CutPrefix(href, "#fn:"); ok && id != "" {
+		return "fn:" + id
+	}
+	return ""
+}
+
+// markdownFootnotes turns the items of goldmark's footnote list into chapter footnotes
+func markdownFootnotes(list *xhtml.Node, resolveURL func(string) string) models.Footnotes {
+	var footnotes models.Footnotes
+	walkElements(list, func(el *xhtml.Node) bool {
+		if el.Data != "li" || !strings.HasPrefix(attrValue(el, "id"), "fn:") {
+			return true
+		}
+		removeBackrefs(el)
+		var buf bytes.Buffer
+		for _, c := range childNodes(el) {
+			xhtml.Render(&buf, c)
+		}
+		footnotes = append(footnotes, models.Footnote{
+			ID:          attrValue(el, "id"),
+			Label:       strconv.Itoa(len(footnotes) + 1),
+			Content:     htmlToText(buf.String()),
+			ContentHTML: sanitizeHTML(buf.String(), resolveURL),
+		})
+		return false
+	})
+	return footnotes
+}
+
+// markdownReferences collects the definitions a chapter may use from anywhere in the file:
+// footnotes by label and link reference definitions
+type markdownReferences struct {
+	footnotes map[string]string
+	links     []string
+	block     footnoteBlock
+	duplicate bool
+}
+
+func newMarkdownReferences() *markdownReferences {
+	return &markdownReferences{footnotes: map[string]string{}}
+}
+
+// collect records line if it belongs to a definition; lines in fenced code must not be passed.
+// The first definition of a footnote label wins, as in CommonMark.
+func (refs *markdownReferences) collect(line string) {
+	label, start, ok := refs.block.feed(line)
+	switch {
+	case ok && start:
+		_, refs.duplicate = refs.footnotes[label]
+		if !refs.duplicate {
+			refs.footnotes[label] = line + "\n"
+		}
+	case ok:
+		if !refs.duplicate {
+			refs.footnotes[label] += line + "\n"
+		}
+	case linkDefinitionPattern.MatchString(line):
+		refs.links = append(refs.links, line)
+	}
+}
+
+// appendTo adds the definitions source refers to, so a chapter renders the footnotes and
+// reference links defined elsewhere in the file
+func (refs *markdownReferences) appendTo(source string) string {
+	var sb strings.Builder
+	sb.WriteString(source)
+	seen := map[string]bool{}
+	for _, m := range footnoteReferencePattern.FindAllStringSubmatch(source, -1) {
+		if def, ok := refs.footnotes[m[1]]; ok && !seen[m[1]] {
+			seen[m[1]] = true
+			sb.WriteString("\n\n" + strings.TrimSpace(def))
+		}
+	}
+	if len(refs.links) > 0 {
+		sb.WriteString("\n\n" + strings.Join(refs.links, "\n"))
+	}
+	return sb.String()
+}
+
+// footnoteBlock tracks whether lines belong to a footnote definition: its "[^label]:" line
+// and the indented or blank lines that follow
+type footnoteBlock struct {
+	label string
+}
+
+// feed reports the label of the footnote definition line belongs to and whether line starts it
+func (b *footnoteBlock) feed(line string) (string, bool, bool) {
+	if m := footnoteDefinitionPattern.FindStringSubmatch(line); m != nil {
+		b.label = m[1]
+		return b.label, true, true
+	}
+	if b.label != "" && (strings.TrimSpace(line) == "" || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) {
+		return b.label, false, true
+	}
+	b.label = ""
+	return "", false, false
+}
+
+// OpenResource opens an image referenced by the markdown file, relative to its directory
+func (p *MarkdownParser) OpenResource(filePath, name string) (io.ReadCloser, error) {
+	return openLocalImage(filePath, name)
+}
@@ -3,9 +3,12 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 func createTestMarkdownFile(t *testing.T, content string) string {
@@ -73,4 +76,214 @@ With two lines.
 		assert.Equal(t, "Chapter 3", chapters[2].Title)
 		assert.Equal(t, "", chapters[0].Content)
 	})
-}
\ No newline at end of file
+}
+func TestMarkdownParser_Headings(t *testing.T) {
+	content := "Intro text.\n\n" +
+		"# Part One\n" +
+		"About part one.\n" +
+		"## First\n" +
+		"#hashtag is not a heading\n" +
+		"```bash\n" +
+		"# a shell comment\n" +
+		"```\n" +
+		"## Second ##\n" +
+		"### Section\n" +
+		"Text.\n" +
+		"# Part Two\n" +
+		"## Third\n"
+	filePath := createTestMarkdownFile(t, content)
+
+	t.Run("Auto", func(t *testing.T) {
+		chapters, err := NewMarkdownParser().Parse(filePath)
+		require.NoError(t, err)
+		require.Len(t, chapters, 6)
+
+		assert.Equal(t, frontMatterTitle, chapters[0].Title)
+		assert.Equal(t, "Part One", chapters[1].Title)
+		assert.Equal(t, 0, chapters[1].VolumeChapterNumber)
+		assert.Equal(t, "About part one.", chapters[1].Content)
+		assert.Equal(t, "First", chapters[2].Title)
+		assert.Contains(t, chapters[2].Content, "#hashtag")
+		assert.Contains(t, chapters[2].Content, "# a shell comment")
+		assert.Equal(t, "Second", chapters[3].Title)
+		assert.Contains(t, chapters[3].ContentHTML, "<h3>Section</h3>")
+		assert.Equal(t, 2, chapters[3].VolumeChapterNumber)
+		assert.Equal(t, "Part Two", chapters[4].Title)
+		assert.Equal(t, 2, chapters[4].VolumeNumber)
+		assert.Equal(t, "Third", chapters[5].Title)
+		assert.Equal(t, 2, chapters[5].VolumeNumber)
+		assert.Equal(t, 1, chapters[5].VolumeChapterNumber)
+	})
+
+	t.Run("Chapters", func(t *testing.T) {
+		chapters, err := NewMarkdownParserWithOptions(Options{MarkdownHeadings: MarkdownHeadingsChapters}).Parse(filePath)
+		require.NoError(t, err)
+		require.Len(t, chapters, 6)
+		for _, ch := range chapters {
+			assert.Equal(t, 1, ch.VolumeNumber)
+			assert.NotZero(t, ch.VolumeChapterNumber)
+		}
+		assert.Equal(t, "Part One", chapters[1].Title)
+	})
+
+	t.Run("Unknown mode", func(t *testing.T) {
+		_, err := NewMarkdownParserWithOptions(Options{MarkdownHeadings: "sections"}).Parse(filePath)
+		assert.Error(t, err)
+	})
+}
+
+func TestMarkdownParser_OnlyChapterHeadings(t *testing.T) {
+	filePath := createTestMarkdownFile(t, "## One\nFirst.\n## Two\nSecond.\n")
+
+	chapters, err := NewMarkdownParserWithOptions(Options{MarkdownHeadings: MarkdownHeadingsAuto}).Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, 1, chapters[0].VolumeChapterNumber)
+	assert.Equal(t, 2, chapters[1].VolumeChapterNumber)
+}
+
+func TestMarkdownParser_FrontMatter(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		content := "---\n" +
+			"title: \"The Book\"\n" +
+			"author: Jane Doe\n" +
+			"tags: [fantasy, 'short story']\n" +
+			"keywords:\n" +
+			"  - magic\n" +
+			"description: >\n" +
+			"  A long\n" +
+			"  description.\n" +
+			"---\n" +
+			"# One\n" +
+			"Text.\n"
+		filePath := createTestMarkdownFile(t, content)
+		p := NewMarkdownParser()
+
+		meta, err := p.ExtractMetadata(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "The Book", meta.Title)
+		assert.Equal(t, "Jane Doe", meta.Author)
+		assert.Equal(t, "A long description.", meta.Description)
+		assert.Equal(t, []string{"fantasy", "short story", "magic"}, meta.Subjects)
+
+		chapters, err := p.Parse(filePath)
+		require.NoError(t, err)
+		require.Len(t, chapters, 1)
+		assert.Equal(t, "One", chapters[0].Title)
+		assert.Equal(t, "Text.", chapters[0].Content)
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		content := "+++\n" +
+			"title = \"The Book\"\n" +
+			"authors = [\"Jane Doe\", \"John Roe\"]\n" +
+			"tags = [\n  \"fantasy\",\n  \"magic\"\n]\n" +
+			"\n[params]\n" +
+			"description = \"ignored\"\n" +
+			"+++\n" +
+			"Body text.\n"
+		filePath := createTestMarkdownFile(t, content)
+		p := NewMarkdownParser()
+
+		meta, err := p.ExtractMetadata(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "The Book", meta.Title)
+		assert.Equal(t, "Jane Doe, John Roe", meta.Author)
+		assert.Empty(t, meta.Description)
+		assert.Equal(t, []string{"fantasy", "magic"}, meta.Subjects)
+
+		chapters, err := p.Parse(filePath)
+		require.NoError(t, err)
+		require.Len(t, chapters, 1)
+		assert.Equal(t, "Body text.", chapters[0].Content)
+	})
+
+	t.Run("Thematic break is not front matter", func(t *testing.T) {
+		filePath := createTestMarkdownFile(t, "---\nNo closing line.\n")
+
+		_, err := NewMarkdownParser().ExtractMetadata(filePath)
+		assert.Error(t, err)
+
+		chapters, err := NewMarkdownParser().Parse(filePath)
+		require.NoError(t, err)
+		require.Len(t, chapters, 1)
+		assert.Contains(t, chapters[0].ContentHTML, "<hr>")
+		assert.Equal(t, "No closing line.", chapters[0].Content)
+	})
+}
+
+func TestMarkdownParser_DecodesCharset(t *testing.T) {
+	body := strings.Repeat("他们在山中走了很久，终于看见了那座古老的寺庙。", 20)
+	content := "---\ntitle: 山中古寺\n---\n# 第一章 入山\n" + body + "\n# 第二章 古寺\n" + body + "\n"
+	encoded, err := simplifiedchinese.GBK.NewEncoder().String(content)
+	require.NoError(t, err)
+	filePath := createTestMarkdownFile(t, encoded)
+	p := NewMarkdownParser()
+
+	meta, err := p.ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "山中古寺", meta.Title)
+
+	chapters, err := p.Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "第一章 入山", chapters[0].Title)
+	assert.Equal(t, "第二章 古寺", chapters[1].Title)
+	assert.Equal(t, body, chapters[1].Content)
+}
+
+func TestMarkdownParser_RendersHTML(t *testing.T) {
+	content := "# One\n" +
+		"Some **bold** and ~~struck~~ text with a [link](https://example.com) and a note.[^n]\n\n" +
+		"| Name | Value |\n" +
+		"| ---- | ----- |\n" +
+		"| a    | 1     |\n\n" +
+		"<script>alert(1)</script>\n\n" +
+		"![Map](images/map.png)\n" +
+		"# Two\n" +
+		"Second note.[^n]\n\n" +
+		"[^n]: The *note*.\n"
+	filePath := createTestMarkdownFile(t, content)
+
+	chapters, err := NewMarkdownParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"}).Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+
+	one := chapters[0]
+	assert.Contains(t, one.ContentHTML, "<strong>bold</strong>")
+	assert.Contains(t, one.ContentHTML, "<del>struck</del>")
+	assert.Contains(t, one.ContentHTML, "<table>")
+	assert.Contains(t, one.ContentHTML, "<td>a</td>")
+	assert.Contains(t, one.ContentHTML, `<img alt="Map" src="/api/books/b1/resources/images/map.png">`)
+	assert.Contains(t, one.ContentHTML, `data-footnote="fn:1"`)
+	assert.NotContains(t, one.ContentHTML, "script")
+	assert.NotContains(t, one.ContentHTML, "example.com")
+	assert.Contains(t, one.Content, "Some bold and struck text with a link and a note.1")
+	assert.NotContains(t, one.Content, "**")
+	require.Len(t, one.Footnotes, 1)
+	assert.Equal(t, "fn:1", one.Footnotes[0].ID)
+	assert.Equal(t, "1", one.Footnotes[0].Label)
+	assert.Equal(t, "The note.", one.Footnotes[0].Content)
+	assert.Equal(t, "<p>The <em>note</em>.</p>", one.Footnotes[0].ContentHTML)
+
+	// The definition at the end of the file serves both chapters and is not shown as text
+	two := chapters[1]
+	require.Len(t, two.Footnotes, 1)
+	assert.Equal(t, "The note.", two.Footnotes[0].Content)
+	assert.Equal(t, "Second note.1", two.Content)
+}
+
+func TestMarkdownParser_OpenResource(t *testing.T) {
+	filePath := createTestMarkdownFile(t, "![Map](map.png)\n")
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(filePath), "map.png"), []byte("png"), 0644))
+	p := NewMarkdownParser()
+
+	rc, err := p.OpenResource(filePath, "map.png")
+	require.NoError(t, err)
+	rc.Close()
+
+	_, err = p.OpenResource(filePath, "test.md")
+	assert.Error(t, err)
+	_, err = p.OpenResource(filePath, "../map.png")
+	assert.Error(t, err)
+}
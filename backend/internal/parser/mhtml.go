@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mhtmlCacheSize is how many unpacked web archives mhtmlArchives keeps
+const mhtmlCacheSize = 4
+
+// mhtmlArchives keeps recently unpacked web archives, so the images of a page are served
+// without unpacking its archive again for each of them
+var mhtmlArchives = &mhtmlCache{}
+
+// mhtmlPart is one decoded MIME part of a web archive
+type mhtmlPart struct {
+	ContentType string
+	Location    string
+	ContentID   string
+	Data        []byte
+}
+
+// isMHTML reports whether data starts with MIME headers rather than markup
+func isMHTML(data []byte) bool {
+	head := bytes.ToLower(data[:min(len(data), 2048)])
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	return !bytes.HasPrefix(bytes.TrimSpace(head), []byte("<")) &&
+		bytes.Contains(head, []byte("mime-version:")) && bytes.Contains(head, []byte("content-type:"))
+}
+
+// isMHTMLFile reports whether a file is a web archive, by its extension or its content
+func isMHTMLFile(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".mhtml", ".mht":
+		return true
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 2048)
+	n, _ := io.ReadFull(f, head)
+	return isMHTML(head[:n])
+}
+
+// mhtmlCache is a small most-recently-used cache of unpacked web archives by file path;
+// an archive changed on disk is unpacked again
+type mhtmlCache struct {
+	mu      sync.Mutex
+	entries []mhtmlCacheEntry // least recently used first
+}
+
+type mhtmlCacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+	parts   []mhtmlPart
+}
+
+// parts returns the parts of the archive at filePath, unpacking it if it is not cached
+func (c *mhtmlCache) parts(filePath string) ([]mhtmlPart, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read html: %w", err)
+	}
+
+	c.mu.Lock()
+	for i, e := range c.entries {
+		if e.path == filePath && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+			c.entries = append(append(c.entries[:i:i], c.entries[i+1:]...), e)
+			c.mu.Unlock()
+			return e.parts, nil
+		}
+	}
+	c.mu.Unlock()
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read html: %w", err)
+	}
+	parts, err := readMHTML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := c.entries[:0:0]
+	for _, e := range c.entries {
+		if e.path != filePath {
+			entries = append(entries, e)
+		}
+	}
+	entries = append(entries, mhtmlCacheEntry{path: filePath, size: info.Size(), modTime: info.ModTime(), parts: parts})
+	c.entries = entries[max(0, len(entries)-mhtmlCacheSize):]
+	return parts, nil
+}
+
+// readMHTML unpacks a web archive (RFC 2557) into its parts in archive order.
+// A single-part archive yields one part.
+func readMHTML(data []byte) ([]mhtmlPart, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mhtml: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		part, err := readMHTMLPart(textproto.MIMEHeader(msg.Header), msg.Body)
+		if err != nil {
+			return nil, err
+		}
+		return []mhtmlPart{part}, nil
+	}
+
+	var parts []mhtmlPart
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		// NextRawPart leaves quoted-printable bodies alone so all encodings are handled in one place
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mhtml part: %w", err)
+		}
+		part, err := readMHTMLPart(p.Header, p)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func readMHTMLPart(header textproto.MIMEHeader, body io.Reader) (mhtmlPart, error) {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return mhtmlPart{}, fmt.Errorf("failed to decode mhtml part: %w", err)
+	}
+
+	return mhtmlPart{
+		ContentType: strings.ToLower(strings.TrimSpace(header.Get("Content-Type"))),
+		Location:    strings.TrimSpace(header.Get("Content-Location")),
+		ContentID:   strings.Trim(strings.TrimSpace(header.Get("Content-ID")), "<>"),
+		Data:        data,
+	}, nil
+}
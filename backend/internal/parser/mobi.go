@@ -0,0 +1,509 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ErrDRMProtected is returned for Kindle books whose text is encrypted
+var ErrDRMProtected = errors.New("mobi is DRM protected: only DRM-free MOBI/AZW3 files can be imported")
+
+// MOBI compression types
+const (
+	mobiNoCompression      = 1
+	mobiPalmDocCompression = 2
+	mobiHuffCompression    = 17480
+)
+
+// EXTH record types
+const (
+	exthAuthor      = 100
+	exthPublisher   = 101
+	exthDescription = 103
+	exthISBN        = 104
+	exthSubject     = 105
+	exthPublishDate = 106
+	exthASIN        = 113
+	exthCoverOffset = 201
+	exthThumbOffset = 202
+	exthTitle       = 503
+	exthLanguage    = 524
+)
+
+var (
+	pageBreakPattern   = regexp.MustCompile(`(?i)<mbp:pagebreak\b[^>]*>`)
+	kf8DocumentPattern = regexp.MustCompile(`(?is)(?:<\?xml[^>]*>\s*)?<html\b`)
+	recIndexPattern    = regexp.MustCompile(`(?i)\brecindex\s*=\s*["']?0*(\d+)["']?`)
+	kindleEmbedPattern = regexp.MustCompile(`(?i)kindle:embed:([0-9a-v]+)(?:\?[^"'\s>]*)?`)
+)
+
+// MobiParser parses DRM-free Kindle .mobi / .azw / .azw3 files
+type MobiParser struct {
+	opts Options
+}
+
+// NewMobiParser creates a new MobiParser
+func NewMobiParser() *MobiParser {
+	return &MobiParser{}
+}
+
+// NewMobiParserWithOptions creates a MobiParser that renders content according to opts
+func NewMobiParserWithOptions(opts Options) *MobiParser {
+	return &MobiParser{opts: opts}
+}
+
+// mobiBook is a PalmDB container together with its decoded record 0 headers.
+// Records are read from the file as they are needed.
+type mobiBook struct {
+	file        *os.File
+	offsets     []int64 // record start offsets, followed by the file size
+	header      []byte  // record 0
+	mobiLength  int     // length of the MOBI header, 0 for plain PalmDOC
+	compression uint16
+	textLength  int
+	textRecords int
+	encoding    uint32
+	version     uint32
+	exth        map[uint32][][]byte
+}
+
+// Parse decodes the book text and splits it into chapters along the NCX, falling back
+// to <mbp:pagebreak> markers (MOBI) or the individual HTML files (KF8/AZW3)
+func (p *MobiParser) Parse(filePath string) ([]models.Chapter, error) {
+	book, err := openMobi(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer book.Close()
+
+	text, err := book.text()
+	if err != nil {
+		return nil, err
+	}
+
+	var spine []spineDoc
+	var toc []tocEntry
+	if book.isKF8() {
+		spine, toc = book.kf8Documents(text)
+	} else {
+		spine, toc = book.mobiDocuments(text)
+	}
+
+	var sections []epubSection
+	if len(toc) > 0 {
+		sections = sectionsFromTOC(spine, flattenTOC(toc))
+	}
+	if len(sections) == 0 {
+		sections = sectionsFromSpine(spine, book.title())
+	}
+	chapters := sectionsToChapters(sections, p.opts.ResourceBaseURL, nil)
+
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no chapters found in mobi (records=%d)", book.recordCount())
+	}
+	return chapters, nil
+}
+
+// ExtractMetadata reads the book title and EXTH metadata records
+func (p *MobiParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	book, err := openMobi(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer book.Close()
+
+	meta := &BookMetadata{
+		Title:         book.title(),
+		Author:        strings.Join(book.exthStrings(exthAuthor), ", "),
+		Description:   cleanDescription(book.exthString(exthDescription)),
+		Language:      book.exthString(exthLanguage),
+		Publisher:     book.exthString(exthPublisher),
+		PublishedDate: normalizeDate(book.exthString(exthPublishDate)),
+		Identifier:    book.exthString(exthASIN),
+		Subjects:      splitSubjects(book.exthStrings(exthSubject)),
+	}
+	if isbn, ok := normalizeISBN(book.exthString(exthISBN), "isbn"); ok {
+		meta.ISBN = isbn
+	}
+	return meta, nil
+}
+
+// ExtractCover returns the image record referenced by the EXTH cover (or thumbnail) offset
+func (p *MobiParser) ExtractCover(filePath string) ([]byte, error) {
+	book, err := openMobi(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer book.Close()
+
+	for _, typ := range []uint32{exthCoverOffset, exthThumbOffset} {
+		values := book.exth[typ]
+		if len(values) == 0 || len(values[0]) != 4 {
+			continue
+		}
+		offset := binary.BigEndian.Uint32(values[0])
+		if offset == 0xFFFFFFFF {
+			continue
+		}
+		if data := book.image(int(offset) + 1); data != nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("no cover declared in mobi")
+}
+
+// OpenResource opens an embedded image by the name chapters refer to it with ("images/3")
+func (p *MobiParser) OpenResource(filePath, name string) (io.ReadCloser, error) {
+	dir, file := path.Split(path.Clean("/" + name))
+	index, err := strconv.Atoi(file)
+	if dir != "/images/" || err != nil {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+
+	book, err := openMobi(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer book.Close()
+	data := book.image(index)
+	if data == nil {
+		return nil, fmt.Errorf("resource not found: %s", name)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// openMobi reads the PalmDB record list and the PalmDOC/MOBI/EXTH headers of record 0.
+// The book keeps the file open for reading other records until it is closed.
+func openMobi(filePath string) (*mobiBook, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mobi: %w", err)
+	}
+	book, err := readMobi(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return book, nil
+}
+
+func readMobi(file *os.File) (*mobiBook, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mobi: %w", err)
+	}
+	size := info.Size()
+
+	head := make([]byte, 78)
+	if _, err := file.ReadAt(head, 0); err != nil {
+		return nil, fmt.Errorf("not a mobi file: too short")
+	}
+	switch string(head[60:68]) {
+	case "BOOKMOBI", "TEXtREAd":
+	default:
+		return nil, fmt.Errorf("not a mobi file: unknown type %q", head[60:68])
+	}
+
+	count := int(binary.BigEndian.Uint16(head[76:]))
+	if count == 0 || int64(78+count*8) > size {
+		return nil, fmt.Errorf("invalid mobi record list")
+	}
+	list := make([]byte, count*8)
+	if _, err := file.ReadAt(list, 78); err != nil {
+		return nil, fmt.Errorf("failed to read mobi record list: %w", err)
+	}
+	book := &mobiBook{file: file, offsets: make([]int64, count+1)}
+	for i := 0; i < count; i++ {
+		book.offsets[i] = int64(binary.BigEndian.Uint32(list[i*8:]))
+	}
+	book.offsets[count] = size
+	for i := 0; i < count; i++ {
+		if book.offsets[i] > book.offsets[i+1] {
+			return nil, fmt.Errorf("invalid mobi record offset %d", i)
+		}
+	}
+
+	h := book.record(0)
+	if len(h) < 16 {
+		return nil, fmt.Errorf("invalid mobi header")
+	}
+	book.header = h
+	book.compression = binary.BigEndian.Uint16(h[0:])
+	book.textLength = int(binary.BigEndian.Uint32(h[4:]))
+	book.textRecords = int(binary.BigEndian.Uint16(h[8:]))
+	book.encoding = 1252
+	if binary.BigEndian.Uint16(h[12:]) != 0 {
+		return nil, ErrDRMProtected
+	}
+
+	if len(h) >= 24 && string(h[16:20]) == "MOBI" {
+		book.mobiLength = int(binary.BigEndian.Uint32(h[20:]))
+		book.encoding = book.u32(0x1C)
+		book.version = book.u32(0x24)
+		if book.u32(0x80)&0x40 != 0 {
+			book.exth = parseEXTH(h[min(16+book.mobiLength, len(h)):])
+		}
+	}
+	return book, nil
+}
+
+// Close closes the book's file
+func (b *mobiBook) Close() error {
+	return b.file.Close()
+}
+
+func (b *mobiBook) recordCount() int {
+	return len(b.offsets) - 1
+}
+
+// record reads the i-th PalmDB record; it is nil if i is out of range or cannot be read
+func (b *mobiBook) record(i int) []byte {
+	if i < 0 || i >= b.recordCount() {
+		return nil
+	}
+	data := make([]byte, b.offsets[i+1]-b.offsets[i])
+	if _, err := b.file.ReadAt(data, b.offsets[i]); err != nil {
+		return nil
+	}
+	return data
+}
+
+// parseEXTH reads the EXTH block that follows the MOBI header
+func parseEXTH(data []byte) map[uint32][][]byte {
+	exth := make(map[uint32][][]byte)
+	if len(data) < 12 || string(data[:4]) != "EXTH" {
+		return exth
+	}
+	count := int(binary.BigEndian.Uint32(data[8:]))
+	pos := 12
+	for i := 0; i < count && pos+8 <= len(data); i++ {
+		typ := binary.BigEndian.Uint32(data[pos:])
+		size := int(binary.BigEndian.Uint32(data[pos+4:]))
+		if size < 8 || pos+size > len(data) {
+			break
+		}
+		exth[typ] = append(exth[typ], data[pos+8:pos+size])
+		pos += size
+	}
+	return exth
+}
+
+// u32 reads a MOBI header field by its offset in record 0; fields beyond the header read as unset
+func (b *mobiBook) u32(offset int) uint32 {
+	if offset+4 > 16+b.mobiLength || offset+4 > len(b.header) {
+		return 0xFFFFFFFF
+	}
+	return binary.BigEndian.Uint32(b.header[offset:])
+}
+
+// isKF8 reports whether the book holds only KF8 (AZW3) content. Combined MOBI/KF8 files
+// are read through their MOBI half, which is complete on its own.
+func (b *mobiBook) isKF8() bool {
+	return b.mobiLength > 0 && b.version >= 8 && b.version != 0xFFFFFFFF
+}
+
+// decode converts text in the book's encoding to UTF-8
+func (b *mobiBook) decode(raw []byte) string {
+	if b.encoding == 65001 {
+		return strings.ToValidUTF8(string(raw), "�")
+	}
+	s, err := charmap.Windows1252.NewDecoder().Bytes(raw)
+	if err != nil {
+		return string(raw)
+	}
+	return string(s)
+}
+
+func (b *mobiBook) exthStrings(typ uint32) []string {
+	var values []string
+	for _, v := range b.exth[typ] {
+		if s := strings.TrimSpace(b.decode(v)); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+func (b *mobiBook) exthString(typ uint32) string {
+	return firstNonEmpty(b.exthStrings(typ))
+}
+
+// title prefers the EXTH updated title over the full name stored in record 0
+func (b *mobiBook) title() string {
+	if t := b.exthString(exthTitle); t != "" {
+		return t
+	}
+	offset, length := b.u32(0x54), b.u32(0x58)
+	if offset == 0xFFFFFFFF || length == 0xFFFFFFFF || int(offset)+int(length) > len(b.header) {
+		return ""
+	}
+	return strings.TrimSpace(b.decode(b.header[offset : offset+length]))
+}
+
+// image returns the index-th (1-based) resource record if it holds an image, or nil.
+// Resource records also include indexes, fonts and end markers, which are never served.
+func (b *mobiBook) image(index int) []byte {
+	first := b.u32(0x6C)
+	if first == 0xFFFFFFFF || index < 1 {
+		return nil
+	}
+	rec := int(first) + index - 1
+	if rec <= 0 {
+		return nil
+	}
+	data := b.record(rec)
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil
+	}
+	return data
+}
+
+// mobiDocuments splits MOBI text at every NCX entry, or at page breaks if there is no NCX
+func (b *mobiBook) mobiDocuments(text []byte) ([]spineDoc, []tocEntry) {
+	entries := b.readNCX()
+	var cuts []int
+	for _, e := range entries {
+		if e.Pos >= 0 && e.Pos < len(text) {
+			cuts = append(cuts, e.Pos)
+		}
+	}
+	if len(cuts) == 0 {
+		var docs []spineDoc
+		for i, part := range pageBreakPattern.Split(b.decode(text), -1) {
+			docs = append(docs, spineDoc{Path: fmt.Sprintf("part%04d.html", i), Raw: rewriteMobiImages(part)})
+		}
+		return docs, nil
+	}
+
+	cuts = append(cuts, 0, len(text))
+	cuts = sortedUnique(cuts)
+	var docs []spineDoc
+	pathAt := make(map[int]string)
+	for i := 0; i+1 < len(cuts); i++ {
+		docPath := fmt.Sprintf("part%04d.html", i)
+		pathAt[cuts[i]] = docPath
+		docs = append(docs, spineDoc{Path: docPath, Raw: rewriteMobiImages(b.decode(text[cuts[i]:cuts[i+1]]))})
+	}
+
+	links := make([]tocEntry, len(entries))
+	for i, e := range entries {
+		links[i] = tocEntry{Title: e.Title, Path: pathAt[e.Pos]}
+	}
+	return docs, mobiTOCTree(entries, links)
+}
+
+// kf8Documents rebuilds the HTML files of a KF8 book from its skeleton and fragment
+// indexes, and anchors the NCX entries inside them
+func (b *mobiBook) kf8Documents(text []byte) ([]spineDoc, []tocEntry) {
+	// The first flow is the HTML; later flows hold CSS and SVG
+	if fdst := b.u32(0xC0); fdst != 0xFFFFFFFF {
+		if rec := b.record(int(fdst)); len(rec) >= 20 && string(rec[:4]) == "FDST" {
+			if end := int(binary.BigEndian.Uint32(rec[16:])); end > 0 && end < len(text) {
+				text = text[:end]
+			}
+		}
+	}
+
+	files, frags := b.kf8Files(text)
+	if len(files) == 0 {
+		var docs []spineDoc
+		for i, part := range splitBefore(string(text), kf8DocumentPattern) {
+			docs = append(docs, spineDoc{Path: fmt.Sprintf("part%04d.html", i), Raw: rewriteMobiImages(part)})
+		}
+		return docs, nil
+	}
+
+	entries := b.readNCX()
+	links := make([]tocEntry, len(entries))
+	anchors := make(map[int][]kf8Anchor)
+	for i, e := range entries {
+		links[i].Title = e.Title
+		if e.Fid < 0 || e.Fid >= len(frags) {
+			continue
+		}
+		pos := frags[e.Fid].insertPos + e.Offset
+		for fi, f := range files {
+			if pos >= f.start && pos < f.start+len(f.html) {
+				links[i].Path = fmt.Sprintf("part%04d.html", fi)
+				if offset := pos - f.start; offset > 0 {
+					id := fmt.Sprintf("toc-%d", i)
+					links[i].Fragment = id
+					anchors[fi] = append(anchors[fi], kf8Anchor{offset: offset, id: id})
+				}
+				break
+			}
+		}
+	}
+
+	docs := make([]spineDoc, len(files))
+	for i, f := range files {
+		docs[i] = spineDoc{Path: fmt.Sprintf("part%04d.html", i), Raw: rewriteMobiImages(insertAnchors(f.html, anchors[i]))}
+	}
+	return docs, mobiTOCTree(entries, links)
+}
+
+// rewriteMobiImages points MOBI recindex and KF8 kindle:embed image references at "images/<n>"
+func rewriteMobiImages(html string) string {
+	html = recIndexPattern.ReplaceAllString(html, `src="images/$1"`)
+	return kindleEmbedPattern.ReplaceAllStringFunc(html, func(m string) string {
+		index, err := strconv.ParseInt(kindleEmbedPattern.FindStringSubmatch(m)[1], 32, 64)
+		if err != nil {
+			return m
+		}
+		return fmt.Sprintf("images/%d", index)
+	})
+}
+
+// mobiTOCTree nests the flat NCX entries (with their resolved links) by depth:
+// an entry's parent is the closest preceding entry at a lower depth
+func mobiTOCTree(entries []mobiTOCEntry, links []tocEntry) []tocEntry {
+	parents := make([]int, len(entries))
+	for i, e := range entries {
+		parents[i] = -1
+		for j := i - 1; j >= 0; j-- {
+			if entries[j].Depth < e.Depth {
+				parents[i] = j
+				break
+			}
+		}
+	}
+
+	var children func(parent int) []tocEntry
+	children = func(parent int) []tocEntry {
+		var result []tocEntry
+		for i := range entries {
+			if parents[i] == parent {
+				entry := links[i]
+				entry.Children = children(i)
+				result = append(result, entry)
+			}
+		}
+		return result
+	}
+	return children(-1)
+}
+
+// splitBefore splits s before every match of re
+func splitBefore(s string, re *regexp.Regexp) []string {
+	var parts []string
+	start := 0
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		if loc[0] > start {
+			parts = append(parts, s[start:loc[0]])
+		}
+		start = loc[0]
+	}
+	return append(parts, s[start:])
+}
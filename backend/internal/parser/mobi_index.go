@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errNoIndex = errors.New("mobi index not present")
+
+// NCX index tags
+const (
+	ncxTagOffset = 1
+	ncxTagLabel  = 3
+	ncxTagDepth  = 4
+	ncxTagPosFid = 6
+)
+
+// mobiIndexEntry is one entry of an INDX table: its identifier and tag values
+type mobiIndexEntry struct {
+	Ident string
+	Tags  map[uint8][]int
+}
+
+// mobiCNCX holds the strings that index entries refer to by offset
+type mobiCNCX [][]byte
+
+// mobiTOCEntry is a flattened NCX entry
+type mobiTOCEntry struct {
+	Title  string
+	Depth  int
+	Pos    int // text position (MOBI), -1 if unset
+	Fid    int // fragment (KF8), -1 if unset
+	Offset int // offset from the start of the fragment (KF8)
+}
+
+// kf8File is an HTML file rebuilt from a skeleton and its fragments
+type kf8File struct {
+	start int // position of the skeleton in the text
+	html  string
+}
+
+type kf8Fragment struct {
+	insertPos int
+	length    int
+}
+
+type kf8Anchor struct {
+	offset int
+	id     string
+}
+
+type tagxEntry struct {
+	tag       uint8
+	numValues int
+	mask      uint8
+	eof       bool
+}
+
+// readNCX reads the NCX index; titles are decoded in the book's encoding
+func (b *mobiBook) readNCX() []mobiTOCEntry {
+	entries, cncx, err := b.readIndex(b.u32(0xF4))
+	if err != nil {
+		return nil
+	}
+
+	var toc []mobiTOCEntry
+	for _, e := range entries {
+		t := mobiTOCEntry{Pos: -1, Fid: -1}
+		if v := e.Tags[ncxTagLabel]; len(v) > 0 {
+			t.Title = strings.Join(strings.Fields(b.decode(cncx.string(v[0]))), " ")
+		}
+		if v := e.Tags[ncxTagDepth]; len(v) > 0 {
+			t.Depth = v[0]
+		}
+		if v := e.Tags[ncxTagOffset]; len(v) > 0 {
+			t.Pos = v[0]
+		}
+		if v := e.Tags[ncxTagPosFid]; len(v) >= 2 {
+			t.Fid, t.Offset = v[0], v[1]
+		}
+		toc = append(toc, t)
+	}
+	return toc
+}
+
+// kf8Files reassembles the HTML files: each skeleton is followed in the text by its
+// fragments, which are inserted into it at their recorded positions
+func (b *mobiBook) kf8Files(text []byte) ([]kf8File, []kf8Fragment) {
+	skeletons, _, err := b.readIndex(b.u32(0xFC))
+	if err != nil {
+		return nil, nil
+	}
+	fragEntries, _, err := b.readIndex(b.u32(0xF8))
+	if err != nil {
+		return nil, nil
+	}
+
+	frags := make([]kf8Fragment, 0, len(fragEntries))
+	for _, e := range fragEntries {
+		insertPos, err := strconv.Atoi(e.Ident)
+		if v := e.Tags[6]; err == nil && len(v) >= 2 {
+			frags = append(frags, kf8Fragment{insertPos: insertPos, length: v[1]})
+		} else {
+			return nil, nil
+		}
+	}
+
+	var files []kf8File
+	next := 0
+	for _, s := range skeletons {
+		pos, count := s.Tags[6], s.Tags[1]
+		if len(pos) < 2 || len(count) < 1 || pos[0]+pos[1] > len(text) {
+			return nil, nil
+		}
+		start := pos[0]
+		html := string(text[start : start+pos[1]])
+		base := start + pos[1]
+		for i := 0; i < count[0] && next < len(frags); i, next = i+1, next+1 {
+			f := frags[next]
+			if base+f.length > len(text) {
+				return nil, nil
+			}
+			at := min(max(f.insertPos-start, 0), len(html))
+			html = html[:at] + string(text[base:base+f.length]) + html[at:]
+			base += f.length
+		}
+		files = append(files, kf8File{start: start, html: html})
+	}
+	return files, frags
+}
+
+// insertAnchors adds an empty <a id> before the tag at each offset, so the TOC can link to it
+func insertAnchors(html string, anchors []kf8Anchor) string {
+	sort.SliceStable(anchors, func(i, j int) bool { return anchors[i].offset > anchors[j].offset })
+	for _, a := range anchors {
+		at := min(a.offset, len(html))
+		if at == len(html) || html[at] != '<' {
+			if i := strings.LastIndex(html[:at], "<"); i >= 0 {
+				at = i
+			}
+		}
+		html = html[:at] + `<a id="` + a.id + `"></a>` + html[at:]
+	}
+	return html
+}
+
+// readIndex reads an INDX table: a header record describing the tags (TAGX),
+// followed by the records holding the entries and the CNCX string records
+func (b *mobiBook) readIndex(first uint32) ([]mobiIndexEntry, mobiCNCX, error) {
+	if first == 0xFFFFFFFF || int(first) >= b.recordCount() {
+		return nil, nil, errNoIndex
+	}
+	start := int(first)
+	rec := b.record(start)
+	header, ok := parseINDXHeader(rec)
+	if !ok {
+		return nil, nil, errNoIndex
+	}
+	controlBytes, tagx, ok := parseTAGX(rec[min(header.length, len(rec)):])
+	if !ok {
+		return nil, nil, errNoIndex
+	}
+
+	var cncx mobiCNCX
+	for i := start + header.count + 1; i < start+header.count+1+header.cncxCount && i < b.recordCount(); i++ {
+		cncx = append(cncx, b.record(i))
+	}
+
+	var entries []mobiIndexEntry
+	for i := start + 1; i <= start+header.count && i < b.recordCount(); i++ {
+		data := b.record(i)
+		h, ok := parseINDXHeader(data)
+		if !ok || h.start+4+h.count*2 > len(data) {
+			continue
+		}
+		positions := make([]int, h.count+1)
+		for j := 0; j < h.count; j++ {
+			positions[j] = int(binary.BigEndian.Uint16(data[h.start+4+j*2:]))
+		}
+		positions[h.count] = h.start
+
+		for j := 0; j < h.count; j++ {
+			from, to := positions[j], positions[j+1]
+			if from >= to || to > len(data) {
+				continue
+			}
+			rec := data[from:to]
+			identLen := int(rec[0])
+			if 1+identLen > len(rec) {
+				continue
+			}
+			entries = append(entries, mobiIndexEntry{
+				Ident: string(rec[1 : 1+identLen]),
+				Tags:  parseTagMap(controlBytes, tagx, rec[1+identLen:]),
+			})
+		}
+	}
+	return entries, cncx, nil
+}
+
+type indxHeader struct {
+	length    int // header length; the TAGX section follows it in the first record
+	start     int // offset of the IDXT block
+	count     int // entry records (first record) or entries (entry records)
+	cncxCount int
+}
+
+func parseINDXHeader(data []byte) (indxHeader, bool) {
+	if len(data) < 56 || string(data[:4]) != "INDX" {
+		return indxHeader{}, false
+	}
+	return indxHeader{
+		length:    int(binary.BigEndian.Uint32(data[4:])),
+		start:     int(binary.BigEndian.Uint32(data[20:])),
+		count:     int(binary.BigEndian.Uint32(data[24:])),
+		cncxCount: int(binary.BigEndian.Uint32(data[52:])),
+	}, true
+}
+
+func parseTAGX(data []byte) (int, []tagxEntry, bool) {
+	if len(data) < 12 || string(data[:4]) != "TAGX" {
+		return 0, nil, false
+	}
+	end := min(int(binary.BigEndian.Uint32(data[4:])), len(data))
+	controlBytes := int(binary.BigEndian.Uint32(data[8:]))
+
+	var tags []tagxEntry
+	for i := 12; i+4 <= end; i += 4 {
+		tags = append(tags, tagxEntry{tag: data[i], numValues: int(data[i+1]), mask: data[i+2], eof: data[i+3] == 1})
+	}
+	return controlBytes, tags, true
+}
+
+// parseTagMap decodes the tag values of one entry. Control bytes say which tags are present
+// and how many values (or bytes of values) follow.
+func parseTagMap(controlBytes int, tagx []tagxEntry, data []byte) map[uint8][]int {
+	tags := make(map[uint8][]int)
+	if len(data) < controlBytes {
+		return tags
+	}
+	control := data[:controlBytes]
+	data = data[controlBytes:]
+
+	type present struct {
+		tag        uint8
+		numValues  int
+		valueCount int // -1 when valueBytes applies
+		valueBytes int
+	}
+	var found []present
+	ci := 0
+	for _, t := range tagx {
+		if t.eof {
+			ci++
+			continue
+		}
+		if ci >= len(control) {
+			break
+		}
+		value := control[ci] & t.mask
+		if value == 0 {
+			continue
+		}
+		p := present{tag: t.tag, numValues: t.numValues}
+		switch {
+		case value == t.mask && bits.OnesCount8(t.mask) > 1:
+			v, n := forwardInt(data)
+			data = data[n:]
+			p.valueCount, p.valueBytes = -1, v
+		case value == t.mask:
+			p.valueCount = 1
+		default:
+			for mask := t.mask; mask&1 == 0; mask >>= 1 {
+				value >>= 1
+			}
+			p.valueCount = int(value)
+		}
+		found = append(found, p)
+	}
+
+	for _, p := range found {
+		var values []int
+		if p.valueCount >= 0 {
+			for i := 0; i < p.valueCount*p.numValues && len(data) > 0; i++ {
+				v, n := forwardInt(data)
+				data = data[n:]
+				values = append(values, v)
+			}
+		} else {
+			for consumed := 0; consumed < p.valueBytes && len(data) > 0; {
+				v, n := forwardInt(data)
+				data = data[n:]
+				consumed += n
+				values = append(values, v)
+			}
+		}
+		tags[p.tag] = values
+	}
+	return tags
+}
+
+// string returns the CNCX string at offset: the record is offset/0x10000,
+// and the string is stored as a length-prefixed run
+func (c mobiCNCX) string(offset int) []byte {
+	rec, pos := offset/0x10000, offset%0x10000
+	if rec >= len(c) || pos >= len(c[rec]) {
+		return nil
+	}
+	data := c[rec][pos:]
+	length, n := forwardInt(data)
+	if n+length > len(data) {
+		return nil
+	}
+	return data[n : n+length]
+}
@@ -0,0 +1,369 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNCXEntry struct {
+	Title string
+	Pos   int
+	Depth int
+}
+
+type testMobi struct {
+	Text       string
+	Exth       map[uint32][]byte
+	Encryption uint16
+	NCX        []testNCXEntry
+	Images     [][]byte
+	Huff       bool // compress the text with testHuffPhrases
+}
+
+// testHuffPhrases is the HUFF/CDIC dictionary of test books: every ASCII byte, two words
+// and a phrase that is itself compressed ("the ")
+var testHuffPhrases = func() [][]byte {
+	var phrases [][]byte
+	for c := 0; c < 128; c++ {
+		phrases = append(phrases, []byte{byte(c)})
+	}
+	the := []byte{testHuffCode('t'), testHuffCode('h'), testHuffCode('e'), testHuffCode(' ')}
+	return append(phrases, []byte("Chapter "), []byte("<mbp:pagebreak/>"), the)
+}()
+
+// testHuffCode is the 8-bit code of a phrase: the HUFF table below maps code c to phrase 255-c
+func testHuffCode(phrase int) byte {
+	return byte(255 - phrase)
+}
+
+// testHuffEncode compresses ASCII text, using the word phrases where they match
+func testHuffEncode(text []byte) []byte {
+	var out []byte
+	for len(text) > 0 {
+		code, n := testHuffCode(int(text[0])), 1
+		for i, word := range []string{"Chapter ", "<mbp:pagebreak/>", "the "} {
+			if bytes.HasPrefix(text, []byte(word)) {
+				code, n = testHuffCode(128+i), len(word)
+				break
+			}
+		}
+		out = append(out, code)
+		text = text[n:]
+	}
+	return out
+}
+
+// testHuffRecords builds the HUFF record, where every code is 8 bits long, and the CDIC
+// record holding testHuffPhrases
+func testHuffRecords() [][]byte {
+	huff := []byte("HUFF\x00\x00\x00\x18")
+	huff = binary.BigEndian.AppendUint32(huff, 24)
+	huff = binary.BigEndian.AppendUint32(huff, 24+256*4)
+	huff = append(huff, make([]byte, 8)...)
+	for c := 0; c < 256; c++ {
+		huff = binary.BigEndian.AppendUint32(huff, 255<<8|0x80|8)
+	}
+	huff = append(huff, make([]byte, 64*4)...)
+
+	cdic := []byte("CDIC\x00\x00\x00\x10")
+	cdic = binary.BigEndian.AppendUint32(cdic, uint32(len(testHuffPhrases)))
+	cdic = binary.BigEndian.AppendUint32(cdic, 8)
+	var table, data []byte
+	for i, phrase := range testHuffPhrases {
+		table = binary.BigEndian.AppendUint16(table, uint16(len(testHuffPhrases)*2+len(data)))
+		flags := uint16(0x8000)
+		if i == len(testHuffPhrases)-1 {
+			flags = 0 // compressed phrase
+		}
+		data = binary.BigEndian.AppendUint16(data, flags|uint16(len(phrase)))
+		data = append(data, phrase...)
+	}
+	return [][]byte{huff, append(append(cdic, table...), data...)}
+}
+
+// forwardBytes encodes a value as a forward variable-width integer
+func forwardBytes(v int) []byte {
+	out := []byte{byte(v&0x7F) | 0x80}
+	for v >>= 7; v > 0; v >>= 7 {
+		out = append([]byte{byte(v & 0x7F)}, out...)
+	}
+	return out
+}
+
+func testINDXHeader(length, idxt, count, cncx int) []byte {
+	h := make([]byte, length)
+	copy(h, "INDX")
+	binary.BigEndian.PutUint32(h[4:], uint32(length))
+	binary.BigEndian.PutUint32(h[20:], uint32(idxt))
+	binary.BigEndian.PutUint32(h[24:], uint32(count))
+	binary.BigEndian.PutUint32(h[52:], uint32(cncx))
+	return h
+}
+
+// writeTestMobi writes a UTF-8 MOBI with the given text, EXTH records, images and NCX index.
+// The text is uncompressed unless m.Huff is set.
+func writeTestMobi(t *testing.T, m testMobi) string {
+	t.Helper()
+
+	var records [][]byte
+	records = append(records, nil) // record 0 is built last
+	for i := 0; i < len(m.Text); i += 4096 {
+		rec := []byte(m.Text[i:min(i+4096, len(m.Text))])
+		if m.Huff {
+			rec = testHuffEncode(rec)
+		}
+		records = append(records, rec)
+	}
+	textRecords := len(records) - 1
+
+	firstImage := len(records)
+	records = append(records, m.Images...)
+
+	ncxIndex := uint32(0xFFFFFFFF)
+	if len(m.NCX) > 0 {
+		ncxIndex = uint32(len(records))
+
+		var cncx []byte
+		var entries [][]byte
+		for i, e := range m.NCX {
+			label := len(cncx)
+			cncx = append(cncx, forwardBytes(len(e.Title))...)
+			cncx = append(cncx, e.Title...)
+
+			ident := fmt.Sprintf("%d", i)
+			entry := append([]byte{byte(len(ident))}, ident...)
+			entry = append(entry, 0x0F) // offset, length, label, depth present
+			entry = append(entry, forwardBytes(e.Pos)...)
+			entry = append(entry, forwardBytes(0)...)
+			entry = append(entry, forwardBytes(label)...)
+			entry = append(entry, forwardBytes(e.Depth)...)
+			entries = append(entries, entry)
+		}
+
+		tagx := []byte("TAGX")
+		tagx = binary.BigEndian.AppendUint32(tagx, 12+5*4)
+		tagx = binary.BigEndian.AppendUint32(tagx, 1)
+		tagx = append(tagx, 1, 1, 0x01, 0, 2, 1, 0x02, 0, 3, 1, 0x04, 0, 4, 1, 0x08, 0, 0, 0, 0, 1)
+		records = append(records, append(testINDXHeader(192, 0, 1, 1), tagx...))
+
+		body := testINDXHeader(192, 0, len(entries), 0)
+		var positions []byte
+		for _, e := range entries {
+			positions = binary.BigEndian.AppendUint16(positions, uint16(len(body)))
+			body = append(body, e...)
+		}
+		binary.BigEndian.PutUint32(body[20:], uint32(len(body)))
+		body = append(append(body, "IDXT"...), positions...)
+		records = append(records, body, cncx)
+	}
+
+	compression, huffIndex := uint16(mobiNoCompression), 0
+	if m.Huff {
+		compression, huffIndex = mobiHuffCompression, len(records)
+		records = append(records, testHuffRecords()...)
+	}
+
+	var exth []byte
+	for typ, value := range m.Exth {
+		exth = binary.BigEndian.AppendUint32(exth, typ)
+		exth = binary.BigEndian.AppendUint32(exth, uint32(len(value)+8))
+		exth = append(exth, value...)
+	}
+	exth = append(append([]byte("EXTH"), binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(len(exth)+12)), uint32(len(m.Exth)))...), exth...)
+
+	const headerLength = 0xE8
+	rec0 := make([]byte, 16+headerLength)
+	binary.BigEndian.PutUint16(rec0[0:], compression)
+	binary.BigEndian.PutUint32(rec0[4:], uint32(len(m.Text)))
+	binary.BigEndian.PutUint16(rec0[8:], uint16(textRecords))
+	binary.BigEndian.PutUint16(rec0[10:], 4096)
+	binary.BigEndian.PutUint16(rec0[12:], m.Encryption)
+	copy(rec0[16:], "MOBI")
+	binary.BigEndian.PutUint32(rec0[0x14:], headerLength)
+	binary.BigEndian.PutUint32(rec0[0x18:], 2)
+	binary.BigEndian.PutUint32(rec0[0x1C:], 65001)
+	binary.BigEndian.PutUint32(rec0[0x24:], 6)
+	binary.BigEndian.PutUint32(rec0[0x6C:], uint32(firstImage))
+	binary.BigEndian.PutUint32(rec0[0x70:], uint32(huffIndex))
+	binary.BigEndian.PutUint32(rec0[0x74:], 2)
+	binary.BigEndian.PutUint32(rec0[0x80:], 0x40)
+	binary.BigEndian.PutUint32(rec0[0xF4:], ncxIndex)
+	rec0 = append(rec0, exth...)
+	title := "Test Book"
+	binary.BigEndian.PutUint32(rec0[0x54:], uint32(len(rec0)))
+	binary.BigEndian.PutUint32(rec0[0x58:], uint32(len(title)))
+	records[0] = append(rec0, title...)
+
+	var buf bytes.Buffer
+	header := make([]byte, 78)
+	copy(header, "test-book")
+	copy(header[60:], "BOOKMOBI")
+	binary.BigEndian.PutUint16(header[76:], uint16(len(records)))
+	buf.Write(header)
+	offset := 78 + len(records)*8 + 2
+	for i, rec := range records {
+		entry := make([]byte, 8)
+		binary.BigEndian.PutUint32(entry, uint32(offset))
+		binary.BigEndian.PutUint32(entry[4:], uint32(i))
+		buf.Write(entry)
+		offset += len(rec)
+	}
+	buf.Write([]byte{0, 0})
+	for _, rec := range records {
+		buf.Write(rec)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "test.mobi")
+	require.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0644))
+	return filePath
+}
+
+func TestMobiParser_ParseNCX(t *testing.T) {
+	part := `<h1>Part One</h1>`
+	ch1 := `<h2>Chapter 1</h2><p>First chapter text.</p><img recindex="00001">`
+	ch2 := `<h2>Chapter 2</h2><p>Second chapter text.</p>`
+	head := `<html><head><guide></guide></head><body><p>Title page</p><mbp:pagebreak/>`
+	text := head + part + ch1 + ch2 + `</body></html>`
+
+	partPos := len(head)
+	filePath := writeTestMobi(t, testMobi{
+		Text:   text,
+		Images: [][]byte{[]byte("\x89PNG\r\n\x1a\nimage")},
+		NCX: []testNCXEntry{
+			{Title: "Part One", Pos: partPos, Depth: 0},
+			{Title: "Chapter 1", Pos: partPos + len(part), Depth: 1},
+			{Title: "Chapter 2", Pos: partPos + len(part) + len(ch1), Depth: 1},
+		},
+	})
+
+	p := NewMobiParserWithOptions(Options{ResourceBaseURL: "/api/books/b1/resources/"})
+	chapters, err := p.Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 4)
+
+	assert.Equal(t, "Title page", chapters[0].Content)
+
+	assert.Equal(t, "Part One", chapters[1].Title)
+	assert.Equal(t, 2, chapters[1].VolumeNumber)
+	assert.Equal(t, 0, chapters[1].VolumeChapterNumber)
+
+	assert.Equal(t, "Chapter 1", chapters[2].Title)
+	assert.Contains(t, chapters[2].Content, "First chapter text.")
+	assert.Contains(t, chapters[2].ContentHTML, `src="/api/books/b1/resources/images/1"`)
+	assert.Equal(t, 1, chapters[2].VolumeChapterNumber)
+
+	assert.Equal(t, "Chapter 2", chapters[3].Title)
+	assert.Equal(t, 2, chapters[3].VolumeChapterNumber)
+
+	rc, err := p.OpenResource(filePath, "images/1")
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG\r\n\x1a\nimage", string(data))
+
+	_, err = p.OpenResource(filePath, "images/2")
+	assert.Error(t, err)
+}
+
+func TestMobiParser_ParsePageBreaks(t *testing.T) {
+	text := `<html><body><h1>One</h1><p>Alpha.</p><mbp:pagebreak/><h1>Two</h1><p>Beta.</p></body></html>`
+	filePath := writeTestMobi(t, testMobi{Text: text})
+
+	chapters, err := NewMobiParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "One", chapters[0].Title)
+	assert.Equal(t, "Two", chapters[1].Title)
+	assert.Contains(t, chapters[1].Content, "Beta.")
+}
+
+func TestMobiParser_ParseHuffCDIC(t *testing.T) {
+	text := `<html><body><h1>Chapter One</h1><p>Over the hills.</p><mbp:pagebreak/>` +
+		`<h1>Chapter Two</h1><p>Under the sea.</p></body></html>`
+	filePath := writeTestMobi(t, testMobi{Text: text, Huff: true})
+
+	chapters, err := NewMobiParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "Chapter One", chapters[0].Title)
+	assert.Contains(t, chapters[0].Content, "Over the hills.")
+	assert.Equal(t, "Chapter Two", chapters[1].Title)
+	assert.Contains(t, chapters[1].Content, "Under the sea.")
+}
+
+func TestMobiParser_DRMProtected(t *testing.T) {
+	filePath := writeTestMobi(t, testMobi{Text: "<p>secret</p>", Encryption: 2})
+
+	_, err := NewMobiParser().Parse(filePath)
+	assert.ErrorIs(t, err, ErrDRMProtected)
+}
+
+func TestMobiParser_MetadataAndCover(t *testing.T) {
+	cover := []byte("\xff\xd8\xffcover")
+	filePath := writeTestMobi(t, testMobi{
+		Text:   "<p>text</p>",
+		Images: [][]byte{[]byte("first"), cover},
+		Exth: map[uint32][]byte{
+			exthAuthor:      []byte("An Author"),
+			exthTitle:       []byte("Updated Title"),
+			exthSubject:     []byte("Fantasy; Adventure"),
+			exthPublishDate: []byte("2015-03-01T00:00:00+00:00"),
+			exthISBN:        []byte("978-0-306-40615-7"),
+			exthCoverOffset: binary.BigEndian.AppendUint32(nil, 1),
+		},
+	})
+
+	p := NewMobiParser()
+	meta, err := p.ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated Title", meta.Title)
+	assert.Equal(t, "An Author", meta.Author)
+	assert.Equal(t, []string{"Fantasy", "Adventure"}, meta.Subjects)
+	assert.Equal(t, "2015-03-01", meta.PublishedDate)
+	assert.Equal(t, "9780306406157", meta.ISBN)
+
+	data, err := p.ExtractCover(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, cover, data)
+}
+
+func TestMobiParser_OpenResource(t *testing.T) {
+	image := []byte("\x89PNG\r\n\x1a\nimage")
+	filePath := writeTestMobi(t, testMobi{Text: "<p>text</p>", Images: [][]byte{[]byte("not an image"), image}})
+	p := NewMobiParser()
+
+	r, err := p.OpenResource(filePath, "images/2")
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, image, data)
+
+	for _, name := range []string{"images/1", "images/3", "images/0", "text/1"} {
+		_, err := p.OpenResource(filePath, name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestPalmDocDecompress(t *testing.T) {
+	// "abcd" literal, back reference (distance 4, length 4), space + 'a', two literal bytes
+	input := []byte{'a', 'b', 'c', 'd', 0x80, 0x21, 0xE1, 0x02, 0xC3, 0xA9}
+	assert.Equal(t, "abcdabcd aé", string(palmDocDecompress(input)))
+}
+
+func TestTrailingEntriesSize(t *testing.T) {
+	// text, 2 bytes of multibyte overlap, then a 2-byte trailing entry
+	rec := []byte{'h', 'e', 'l', 'l', 'o', 0xAA, 0x01, 'X', 0x82}
+	assert.Equal(t, 4, trailingEntriesSize(rec, 0x3))
+	assert.Equal(t, 2, trailingEntriesSize(rec, 0x2))
+	assert.Equal(t, 0, trailingEntriesSize(rec, 0))
+}
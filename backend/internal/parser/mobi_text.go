@@ -0,0 +1,290 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var errInvalidHuff = errors.New("invalid HUFF/CDIC records")
+
+// text decompresses all text records into the raw book markup
+func (b *mobiBook) text() ([]byte, error) {
+	var decompress func([]byte) ([]byte, error)
+	switch b.compression {
+	case mobiNoCompression:
+		decompress = func(data []byte) ([]byte, error) { return data, nil }
+	case mobiPalmDocCompression:
+		decompress = func(data []byte) ([]byte, error) { return palmDocDecompress(data), nil }
+	case mobiHuffCompression:
+		first, count := int(b.u32(0x70)), int(b.u32(0x74))
+		if first <= 0 || count < 2 || first+count > b.recordCount() {
+			return nil, errInvalidHuff
+		}
+		var cdics [][]byte
+		for i := first + 1; i < first+count; i++ {
+			cdics = append(cdics, b.record(i))
+		}
+		huff, err := newHuffDecoder(b.record(first), cdics)
+		if err != nil {
+			return nil, err
+		}
+		decompress = func(data []byte) ([]byte, error) { return huff.unpack(data, 0) }
+	default:
+		return nil, fmt.Errorf("unsupported mobi compression type %d", b.compression)
+	}
+
+	// Only MOBI headers long enough to carry the field declare trailing entries
+	var extraFlags uint32
+	if b.mobiLength >= 0xE4 {
+		extraFlags = b.u32(0xF0) & 0xFFFF
+	}
+
+	var text []byte
+	for i := 1; i <= b.textRecords && i < b.recordCount(); i++ {
+		rec := b.record(i)
+		rec = rec[:len(rec)-trailingEntriesSize(rec, extraFlags)]
+		out, err := decompress(rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress text record %d: %w", i, err)
+		}
+		text = append(text, out...)
+	}
+	if b.textLength > 0 && len(text) > b.textLength {
+		text = text[:b.textLength]
+	}
+	return text, nil
+}
+
+// trailingEntriesSize returns how many bytes at the end of a text record are not text:
+// one backward-encoded entry per set flag bit above bit 0, then the multibyte overlap if bit 0 is set
+func trailingEntriesSize(rec []byte, flags uint32) int {
+	size := 0
+	for f := flags >> 1; f != 0; f >>= 1 {
+		if f&1 != 0 {
+			size += backwardInt(rec[:len(rec)-size])
+			if size >= len(rec) {
+				return len(rec)
+			}
+		}
+	}
+	if flags&1 != 0 && size < len(rec) {
+		size += int(rec[len(rec)-size-1]&0x3) + 1
+	}
+	if size > len(rec) {
+		return len(rec)
+	}
+	return size
+}
+
+// backwardInt reads a variable-width integer stored at the end of data, last byte first
+func backwardInt(data []byte) int {
+	value, shift := 0, 0
+	for i := len(data) - 1; i >= 0; i-- {
+		b := data[i]
+		value |= int(b&0x7F) << shift
+		shift += 7
+		if b&0x80 != 0 || shift >= 28 {
+			break
+		}
+	}
+	return value
+}
+
+// forwardInt reads a variable-width integer whose last byte has the high bit set.
+// It returns the value and the number of bytes consumed.
+func forwardInt(data []byte) (int, int) {
+	value := 0
+	for i, b := range data {
+		value = value<<7 | int(b&0x7F)
+		if b&0x80 != 0 {
+			return value, i + 1
+		}
+	}
+	return value, len(data)
+}
+
+// palmDocDecompress expands PalmDOC (LZ77 variant) compressed text
+func palmDocDecompress(data []byte) []byte {
+	out := make([]byte, 0, len(data)*2)
+	for i := 0; i < len(data); {
+		c := data[i]
+		i++
+		switch {
+		case c >= 0x01 && c <= 0x08:
+			// Next c bytes are literals
+			end := min(i+int(c), len(data))
+			out = append(out, data[i:end]...)
+			i = end
+		case c < 0x80:
+			out = append(out, c)
+		case c >= 0xC0:
+			out = append(out, ' ', c^0x80)
+		default:
+			// Back reference: 11 bits of distance, 3 bits of length - 3
+			if i >= len(data) {
+				return out
+			}
+			m := int(c)<<8 | int(data[i])
+			i++
+			dist, n := (m&0x3FFF)>>3, m&0x07+3
+			if dist == 0 || dist > len(out) {
+				continue
+			}
+			start := len(out) - dist
+			for j := 0; j < n; j++ {
+				out = append(out, out[start+j])
+			}
+		}
+	}
+	return out
+}
+
+// huffDecoder expands text compressed with the HUFF/CDIC dictionary scheme
+type huffDecoder struct {
+	dict1   [256]huffCode
+	minCode [33]uint64
+	maxCode [33]uint64
+	phrases []huffPhrase
+}
+
+type huffCode struct {
+	length  int
+	term    bool
+	maxCode uint64
+}
+
+// huffPhrase is a dictionary entry; entries that are themselves compressed are expanded on first use
+type huffPhrase struct {
+	data     []byte
+	expanded bool
+}
+
+func newHuffDecoder(huff []byte, cdics [][]byte) (*huffDecoder, error) {
+	if len(huff) < 24 || string(huff[:8]) != "HUFF\x00\x00\x00\x18" {
+		return nil, errInvalidHuff
+	}
+	off1, off2 := int(binary.BigEndian.Uint32(huff[8:])), int(binary.BigEndian.Uint32(huff[12:]))
+	if off1+256*4 > len(huff) || off2+64*4 > len(huff) {
+		return nil, errInvalidHuff
+	}
+
+	d := &huffDecoder{}
+	for i := range d.dict1 {
+		v := binary.BigEndian.Uint32(huff[off1+i*4:])
+		length := int(v & 0x1F)
+		if length == 0 {
+			return nil, errInvalidHuff
+		}
+		d.dict1[i] = huffCode{
+			length:  length,
+			term:    v&0x80 != 0,
+			maxCode: (uint64(v>>8)+1)<<(32-length) - 1,
+		}
+	}
+	d.maxCode[0] = 1<<32 - 1
+	for length := 1; length <= 32; length++ {
+		lo := binary.BigEndian.Uint32(huff[off2+(length-1)*8:])
+		hi := binary.BigEndian.Uint32(huff[off2+(length-1)*8+4:])
+		d.minCode[length] = uint64(lo) << (32 - length)
+		d.maxCode[length] = (uint64(hi)+1)<<(32-length) - 1
+	}
+
+	for _, cdic := range cdics {
+		if len(cdic) < 16 || string(cdic[:8]) != "CDIC\x00\x00\x00\x10" {
+			return nil, errInvalidHuff
+		}
+		total := int(binary.BigEndian.Uint32(cdic[8:]))
+		bits := binary.BigEndian.Uint32(cdic[12:])
+		if bits > 16 {
+			return nil, errInvalidHuff
+		}
+		n := min(1<<bits, total-len(d.phrases))
+		for i := 0; i < n; i++ {
+			if 16+i*2+2 > len(cdic) {
+				return nil, errInvalidHuff
+			}
+			off := 16 + int(binary.BigEndian.Uint16(cdic[16+i*2:]))
+			if off+2 > len(cdic) {
+				return nil, errInvalidHuff
+			}
+			blen := binary.BigEndian.Uint16(cdic[off:])
+			end := off + 2 + int(blen&0x7FFF)
+			if end > len(cdic) {
+				return nil, errInvalidHuff
+			}
+			d.phrases = append(d.phrases, huffPhrase{data: cdic[off+2 : end], expanded: blen&0x8000 != 0})
+		}
+	}
+	return d, nil
+}
+
+// unpack decodes one record. Dictionary phrases may be compressed too, hence the depth guard.
+func (d *huffDecoder) unpack(data []byte, depth int) ([]byte, error) {
+	if depth > 32 {
+		return nil, errInvalidHuff
+	}
+
+	bitsLeft := len(data) * 8
+	buf := append(append([]byte(nil), data...), make([]byte, 8)...)
+	pos := 0
+	x := binary.BigEndian.Uint64(buf)
+	n := 32
+
+	var out []byte
+	for {
+		if n <= 0 {
+			pos += 4
+			if pos+8 > len(buf) {
+				break
+			}
+			x = binary.BigEndian.Uint64(buf[pos:])
+			n += 32
+		}
+		code := (x >> uint(n)) & 0xFFFFFFFF
+
+		c := d.dict1[code>>24]
+		length, maxCode := c.length, c.maxCode
+		if !c.term {
+			for length < 32 && code < d.minCode[length] {
+				length++
+			}
+			maxCode = d.maxCode[length]
+		}
+		n -= length
+		bitsLeft -= length
+		if bitsLeft < 0 {
+			break
+		}
+
+		if maxCode < code {
+			return nil, errInvalidHuff
+		}
+		r := (maxCode - code) >> (32 - length)
+		if r >= uint64(len(d.phrases)) {
+			return nil, errInvalidHuff
+		}
+		phrase := &d.phrases[r]
+		if !phrase.expanded {
+			expanded, err := d.unpack(phrase.data, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			phrase.data, phrase.expanded = expanded, true
+		}
+		out = append(out, phrase.data...)
+	}
+	return out, nil
+}
+
+func sortedUnique(values []int) []int {
+	sort.Ints(values)
+	result := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
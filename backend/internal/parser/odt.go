@@ -0,0 +1,224 @@
+package parser
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// OdtParser parses OpenDocument text (.odt) documents
+type OdtParser struct{}
+
+// NewOdtParser creates a new OdtParser
+func NewOdtParser() *OdtParser {
+	return &OdtParser{}
+}
+
+// odtStyle is the part of an ODF style that matters for import
+type odtStyle struct {
+	parent       string
+	outlineLevel int
+	format       textFormat
+	hasFormat    bool
+}
+
+// Parse reads content.xml and splits it into chapters at headings
+func (p *OdtParser) Parse(filePath string) ([]models.Chapter, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open odt: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := readZipXML(&reader.Reader, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	doc := content.child("document-content")
+
+	// Named styles live in styles.xml, automatic ones in content.xml
+	o := &odtReader{styles: make(map[string]odtStyle)}
+	if styles, err := readZipXML(&reader.Reader, "styles.xml"); err == nil {
+		o.addStyles(styles.child("document-styles").child("styles"))
+		o.addStyles(styles.child("document-styles").child("automatic-styles"))
+	}
+	o.addStyles(doc.child("automatic-styles"))
+
+	blocks := o.blocks(doc.child("body").child("text"))
+
+	title := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	chapters := sectionsToChapters(headingSections(blocks, "content.xml", title), "", nil)
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no text found in odt")
+	}
+	return chapters, nil
+}
+
+// ExtractMetadata reads the document metadata (meta.xml)
+func (p *OdtParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open odt: %w", err)
+	}
+	defer reader.Close()
+
+	root, err := readZipXML(&reader.Reader, "meta.xml")
+	if err != nil {
+		return nil, err
+	}
+	meta := root.child("document-meta").child("meta")
+
+	var keywords []string
+	for _, k := range meta.children("keyword") {
+		keywords = append(keywords, k.text())
+	}
+	author := strings.TrimSpace(meta.child("creator").text())
+	if initial := strings.TrimSpace(meta.child("initial-creator").text()); initial != "" {
+		author = initial
+	}
+
+	return &BookMetadata{
+		Title:         strings.TrimSpace(meta.child("title").text()),
+		Author:        author,
+		Description:   strings.TrimSpace(meta.child("description").text()),
+		Language:      strings.TrimSpace(meta.child("language").text()),
+		PublishedDate: normalizeDate(strings.TrimSpace(meta.child("creation-date").text())),
+		Subjects:      splitSubjects(append([]string{meta.child("subject").text()}, keywords...)),
+	}, nil
+}
+
+type odtReader struct {
+	styles map[string]odtStyle
+}
+
+func (o *odtReader) addStyles(styles *xmlNode) {
+	for _, s := range styles.children("style") {
+		props := s.child("text-properties")
+		style := odtStyle{parent: s.attr("parent-style-name"), hasFormat: props != nil}
+		if props != nil {
+			style.format = textFormat{
+				bold:      props.attr("font-weight") == "bold",
+				italic:    props.attr("font-style") == "italic",
+				underline: props.attr("text-underline-style") != "" && props.attr("text-underline-style") != "none",
+				strike:    props.attr("text-line-through-style") != "" && props.attr("text-line-through-style") != "none",
+			}
+		}
+		style.outlineLevel, _ = strconv.Atoi(s.attr("default-outline-level"))
+		o.styles[s.attr("name")] = style
+	}
+}
+
+// resolve follows the parent chain: the nearest style with text properties decides the format,
+// the nearest with an outline level the heading level
+func (o *odtReader) resolve(name string) (textFormat, int) {
+	var format textFormat
+	formatSet, level := false, 0
+	for depth := 0; name != "" && depth < 16; depth++ {
+		s, ok := o.styles[name]
+		if !ok {
+			break
+		}
+		if !formatSet && s.hasFormat {
+			format, formatSet = s.format, true
+		}
+		if level == 0 {
+			level = s.outlineLevel
+		}
+		name = s.parent
+	}
+	// LibreOffice's built-in character styles, used when styles.xml lacks them
+	switch name {
+	case "Emphasis":
+		format.italic = true
+	case "Strong_20_Emphasis":
+		format.bold = true
+	}
+	return format, level
+}
+
+// blocks renders paragraphs, headings, lists and tables of office:text (or a section inside it)
+func (o *odtReader) blocks(n *xmlNode) []docBlock {
+	var blocks []docBlock
+	for _, c := range n.Children {
+		switch c.Name {
+		case "h":
+			level, err := strconv.Atoi(c.attr("outline-level"))
+			if err != nil || level < 1 {
+				level = 1
+			}
+			blocks = append(blocks, headingBlock(level, o.inline(c)))
+		case "p":
+			format, level := o.resolve(c.attr("style-name"))
+			blocks = append(blocks, headingBlock(level, format.wrap(o.inline(c))))
+		case "list":
+			for _, item := range c.children("list-item") {
+				blocks = append(blocks, o.blocks(item)...)
+			}
+		case "section", "list-item", "list-header", "index-body":
+			blocks = append(blocks, o.blocks(c)...)
+		case "table":
+			blocks = append(blocks, docBlock{HTML: o.table(c)})
+		}
+	}
+	return blocks
+}
+
+func (o *odtReader) table(tbl *xmlNode) string {
+	var sb strings.Builder
+	sb.WriteString("<table>")
+	var rows []*xmlNode
+	for _, c := range tbl.Children {
+		switch c.Name {
+		case "table-row":
+			rows = append(rows, c)
+		case "table-header-rows", "table-rows":
+			rows = append(rows, c.children("table-row")...)
+		}
+	}
+	for _, tr := range rows {
+		sb.WriteString("<tr>")
+		for _, tc := range tr.children("table-cell") {
+			sb.WriteString("<td>")
+			for _, b := range o.blocks(tc) {
+				sb.WriteString(b.HTML)
+			}
+			sb.WriteString("</td>")
+		}
+		sb.WriteString("</tr>")
+	}
+	sb.WriteString("</table>")
+	return sb.String()
+}
+
+// inline renders the text of a paragraph with span formatting, spaces, tabs and line breaks.
+// Footnote bodies and annotations are left out.
+func (o *odtReader) inline(n *xmlNode) string {
+	var sb strings.Builder
+	for _, c := range n.Children {
+		switch c.Name {
+		case "":
+			sb.WriteString(html.EscapeString(c.Text))
+		case "span":
+			format, _ := o.resolve(c.attr("style-name"))
+			sb.WriteString(format.wrap(o.inline(c)))
+		case "a", "meta", "ruby-base":
+			sb.WriteString(o.inline(c))
+		case "s":
+			count, err := strconv.Atoi(c.attr("c"))
+			if err != nil || count < 1 {
+				count = 1
+			}
+			sb.WriteString(strings.Repeat(" ", count))
+		case "tab":
+			sb.WriteString(" ")
+		case "line-break":
+			sb.WriteString("<br>")
+		}
+	}
+	return sb.String()
+}
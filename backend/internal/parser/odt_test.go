@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOdtContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0">
+  <office:automatic-styles>
+    <style:style style:name="T1" style:family="text"><style:text-properties fo:font-weight="bold"/></style:style>
+    <style:style style:name="P1" style:family="paragraph" style:parent-style-name="Chapter_20_Title"/>
+  </office:automatic-styles>
+  <office:body>
+    <office:text>
+      <text:sequence-decls/>
+      <text:p>Foreword text.</text:p>
+      <text:h text:outline-level="1">Part One</text:h>
+      <text:h text:outline-level="2">Chapter 1</text:h>
+      <text:p>It was a <text:span text:style-name="Emphasis">cold</text:span> and <text:span text:style-name="T1">dark</text:span><text:s text:c="2"/>morning.<text:note><text:note-body><text:p>Hidden note.</text:p></text:note-body></text:note></text:p>
+      <text:list><text:list-item><text:p>First item</text:p></text:list-item></text:list>
+      <text:p text:style-name="P1">Chapter 2</text:p>
+      <text:p>The end.</text:p>
+    </office:text>
+  </office:body>
+</office:document-content>`
+
+const testOdtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0">
+  <office:styles>
+    <style:style style:name="Chapter_20_Title" style:family="paragraph" style:default-outline-level="2"/>
+  </office:styles>
+</office:document-styles>`
+
+const testOdtMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <office:meta>
+    <dc:title>The Test Document</dc:title>
+    <meta:initial-creator>Jane Doe</meta:initial-creator>
+    <dc:creator>Last Editor</dc:creator>
+    <dc:description>A short document.</dc:description>
+    <meta:keyword>magic</meta:keyword>
+    <meta:keyword>dragons</meta:keyword>
+    <dc:language>en-US</dc:language>
+    <meta:creation-date>2020-05-06T07:08:09</meta:creation-date>
+  </office:meta>
+</office:document-meta>`
+
+func TestOdtParser_Parse(t *testing.T) {
+	filePath := writeTestZip(t, "test.odt", map[string]string{
+		"content.xml": testOdtContent,
+		"styles.xml":  testOdtStyles,
+	})
+
+	chapters, err := NewOdtParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 4)
+
+	assert.Equal(t, frontMatterTitle, chapters[0].Title)
+	assert.Equal(t, "Part One", chapters[1].Title)
+
+	ch1 := chapters[2]
+	assert.Equal(t, "Chapter 1", ch1.Title)
+	assert.Equal(t, 1, ch1.VolumeChapterNumber)
+	assert.Contains(t, ch1.ContentHTML, "<em>cold</em>")
+	assert.Contains(t, ch1.ContentHTML, "<strong>dark</strong>")
+	assert.Contains(t, ch1.Content, "First item")
+	assert.NotContains(t, ch1.Content, "Hidden note.")
+
+	assert.Equal(t, "Chapter 2", chapters[3].Title)
+	assert.Equal(t, 2, chapters[3].VolumeChapterNumber)
+	assert.Contains(t, chapters[3].Content, "The end.")
+}
+
+func TestOdtParser_ExtractMetadata(t *testing.T) {
+	filePath := writeTestZip(t, "test.odt", map[string]string{
+		"content.xml": testOdtContent,
+		"meta.xml":    testOdtMeta,
+	})
+
+	meta, err := NewOdtParser().ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "The Test Document", meta.Title)
+	assert.Equal(t, "Jane Doe", meta.Author)
+	assert.Equal(t, "A short document.", meta.Description)
+	assert.Equal(t, "en-US", meta.Language)
+	assert.Equal(t, "2020-05-06", meta.PublishedDate)
+	assert.Equal(t, []string{"magic", "dragons"}, meta.Subjects)
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/whitecat/go-reader/internal/models"
@@ -12,17 +13,86 @@ type Parser interface {
 	Parse(filePath string) ([]models.Chapter, error)
 }
 
+// BookMetadata holds bibliographic information embedded in a book file
+type BookMetadata struct {
+	Title         string
+	Author        string
+	Description   string
+	Language      string
+	Publisher     string
+	PublishedDate string
+	ISBN          string
+	Identifier    string
+	Series        string
+	SeriesIndex   float64
+	Subjects      []string
+}
+
+// MetadataExtractor is implemented by parsers whose format carries book metadata
+type MetadataExtractor interface {
+	ExtractMetadata(filePath string) (*BookMetadata, error)
+}
+
+// CoverExtractor is implemented by parsers whose format can embed a cover image
+type CoverExtractor interface {
+	ExtractCover(filePath string) ([]byte, error)
+}
+
+// ResourceProvider is implemented by parsers whose format bundles images and other files
+type ResourceProvider interface {
+	OpenResource(filePath, name string) (io.ReadCloser, error)
+}
+
+// PageProvider is implemented by parsers of image-based formats whose pages can be streamed one by one
+type PageProvider interface {
+	OpenPage(filePath string, page int) (io.ReadCloser, error)
+}
+
+// Options configures how parsers render chapter content
+type Options struct {
+	// ResourceBaseURL is prepended to the in-file path of images referenced by chapters
+	// (e.g. "/api/books/<id>/resources/"). Images are dropped when it is empty.
+	ResourceBaseURL string
+	// ChapterRules are the ordered title rules TXT files are split with; empty means the default preset
+	ChapterRules []models.ChapterRule
+	// SplitChapterWords is the size, in words, oversized TXT, Markdown and EPUB chapters are split into;
+	// 0 means DefaultSplitChapterWords and a negative value disables splitting
+	SplitChapterWords int
+	// MarkdownHeadings picks how markdown headings map to volumes and chapters; see MarkdownHeadingsAuto
+	MarkdownHeadings string
+}
+
 // GetParser returns the appropriate parser for the given file format
 func GetParser(format string) (Parser, error) {
+	return GetParserWithOptions(format, Options{})
+}
+
+// GetParserWithOptions returns the parser for the given file format configured with opts
+// Aliases such as "azw3" or "htm" are resolved through formatFamilies.
+func GetParserWithOptions(format string, opts Options) (Parser, error) {
 	format = strings.ToLower(strings.TrimSpace(format))
 
-	switch format {
+	switch formatFamily(format) {
 	case "txt":
-		return NewTxtParser(), nil
-	case "md", "markdown":
-		return NewMarkdownParser(), nil
+		return NewTxtParserWithOptions(opts), nil
+	case "md":
+		return NewMarkdownParserWithOptions(opts), nil
 	case "epub":
-		return NewEpubParser(), nil
+		return NewEpubParserWithOptions(opts), nil
+	case "pdf":
+		return NewPdfParser(), nil
+	case "mobi":
+		return NewMobiParserWithOptions(opts), nil
+	case "fb2":
+		return NewFb2ParserWithOptions(opts), nil
+	case "html", "mhtml":
+		return NewHtmlParserWithOptions(opts), nil
+	case "cbz":
+		return NewCbzParserWithOptions(opts), nil
+	case "docx":
+		return NewDocxParser(), nil
+	case "odt":
+		return NewOdtParser(), nil
 	default:
 		return nil, fmt.Errorf("unsupported file format: %s", format)
 	}
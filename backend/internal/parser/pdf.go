@@ -0,0 +1,412 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ledongthuc/pdf"
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/textstat"
+)
+
+// ErrNoExtractableText is returned for PDFs whose pages carry no text layer (scans, image-only comics)
+var ErrNoExtractableText = errors.New("pdf has no extractable text: it looks like a scanned or image-only PDF, which needs OCR before it can be imported")
+
+var (
+	// pdfReferencesPattern matches the references leading an array's text form, "[12 0 R 13 0 R /XYZ ..."
+	pdfReferencesPattern = regexp.MustCompile(`^\[((?:\d+ \d+ R\s*)+)`)
+	// pdfReferencePattern matches one reference in that form
+	pdfReferencePattern = regexp.MustCompile(`(\d+) (\d+) R`)
+)
+
+// defaultPDFPagesPerChapter is the chunk size used when a PDF has no outline
+const defaultPDFPagesPerChapter = 10
+
+// PdfParser parses .pdf files with a text layer
+type PdfParser struct {
+	pagesPerChapter int
+}
+
+// NewPdfParser creates a new PdfParser
+func NewPdfParser() *PdfParser {
+	return &PdfParser{pagesPerChapter: defaultPDFPagesPerChapter}
+}
+
+// pdfMark is a flattened outline entry pointing at a page
+type pdfMark struct {
+	Title  string
+	Page   int // 0-based
+	Volume bool
+}
+
+// Parse extracts text page by page and splits it into chapters along the PDF outline
+// (bookmarks), or into fixed-size page chunks if the document has none
+func (p *PdfParser) Parse(filePath string) (chapters []models.Chapter, err error) {
+	f, r, err := openPDF(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defer func() {
+		if rec := recover(); rec != nil {
+			chapters, err = nil, fmt.Errorf("failed to read pdf: %v", rec)
+		}
+	}()
+
+	numPages := r.NumPage()
+	pages := make([][]string, numPages)
+	hasText := false
+	for i := 0; i < numPages; i++ {
+		lines, err := extractPageLines(r.Page(i + 1))
+		if err != nil {
+			continue
+		}
+		pages[i] = reflowLines(lines)
+		if len(pages[i]) > 0 {
+			hasText = true
+		}
+	}
+	if !hasText {
+		return nil, ErrNoExtractableText
+	}
+
+	marks := pdfOutlineMarks(r)
+	if len(marks) == 0 {
+		return p.chunkChapters(pages), nil
+	}
+	return outlineChapters(pages, marks), nil
+}
+
+// ExtractMetadata reads the document information dictionary
+func (p *PdfParser) ExtractMetadata(filePath string) (meta *BookMetadata, err error) {
+	f, r, err := openPDF(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defer func() {
+		if rec := recover(); rec != nil {
+			meta, err = nil, fmt.Errorf("failed to read pdf metadata: %v", rec)
+		}
+	}()
+
+	info := r.Trailer().Key("Info")
+	meta = &BookMetadata{
+		Title:       strings.TrimSpace(info.Key("Title").Text()),
+		Author:      strings.TrimSpace(info.Key("Author").Text()),
+		Description: strings.TrimSpace(info.Key("Subject").Text()),
+	}
+	if keywords := strings.TrimSpace(info.Key("Keywords").Text()); keywords != "" {
+		meta.Subjects = splitSubjects([]string{keywords})
+	}
+	if date := info.Key("CreationDate").Text(); len(date) >= 10 && strings.HasPrefix(date, "D:") {
+		// D:YYYYMMDDHHmmSS
+		meta.PublishedDate = date[2:6] + "-" + date[6:8] + "-" + date[8:10]
+	}
+	return meta, nil
+}
+
+// openPDF opens a PDF, turning the library's panics on malformed files into errors
+func openPDF(filePath string) (f *os.File, r *pdf.Reader, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to open pdf: %v", rec)
+		}
+	}()
+
+	file, reader, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pdf: %w", err)
+	}
+	return file, reader, nil
+}
+
+// chunkChapters groups pages into chapters of pagesPerChapter pages
+func (p *PdfParser) chunkChapters(pages [][]string) []models.Chapter {
+	size := p.pagesPerChapter
+	if size <= 0 {
+		size = defaultPDFPagesPerChapter
+	}
+
+	var chapters []models.Chapter
+	for start := 0; start < len(pages); start += size {
+		end := start + size
+		if end > len(pages) {
+			end = len(pages)
+		}
+		content := strings.Join(joinPageParagraphs(pages[start:end]), "\n")
+		if content == "" {
+			continue
+		}
+		chapters = append(chapters, newPDFChapter(len(chapters)+1, 1, len(chapters)+1,
+			fmt.Sprintf("Pages %d-%d", start+1, end), content))
+	}
+	return chapters
+}
+
+// outlineChapters cuts the page text at every outline entry. Entries that share a page
+// are split at the paragraph that starts with their title, when it can be found.
+func outlineChapters(pages [][]string, marks []pdfMark) []models.Chapter {
+	type cut struct {
+		mark      pdfMark
+		paragraph int // index into the flattened paragraph list
+	}
+
+	var paragraphs []string
+	pageStart := make([]int, len(pages)+1)
+	for i, page := range pages {
+		pageStart[i] = len(paragraphs)
+		paragraphs = append(paragraphs, page...)
+	}
+	pageStart[len(pages)] = len(paragraphs)
+
+	cuts := make([]cut, 0, len(marks))
+	for _, m := range marks {
+		at, from, end := pageStart[m.Page], pageStart[m.Page], pageStart[m.Page+1]
+		if len(cuts) > 0 {
+			// Several entries on one page: look for this title after the previous entry's
+			if prev := cuts[len(cuts)-1].paragraph; prev >= from && prev < end {
+				at, from = prev, prev+1
+			}
+		}
+		for i := from; i < end; i++ {
+			if titleMatches(paragraphs[i], m.Title) {
+				at = i
+				break
+			}
+		}
+		cuts = append(cuts, cut{mark: m, paragraph: at})
+	}
+	sort.SliceStable(cuts, func(i, j int) bool { return cuts[i].paragraph < cuts[j].paragraph })
+
+	var chapters []models.Chapter
+	volumeNumber := 1
+	volumeChapterNumber := 0
+	add := func(title string, volume bool, body []string) {
+		if len(body) > 0 && normalizeTitle(body[0]) == normalizeTitle(title) {
+			body = body[1:]
+		}
+		content := strings.Join(joinPageParagraphs([][]string{body}), "\n")
+
+		if volume {
+			if len(chapters) > 0 {
+				volumeNumber++
+			}
+			volumeChapterNumber = 0
+			chapters = append(chapters, newPDFChapter(len(chapters)+1, volumeNumber, 0, title, content))
+			return
+		}
+		if content == "" {
+			return
+		}
+		volumeChapterNumber++
+		chapters = append(chapters, newPDFChapter(len(chapters)+1, volumeNumber, volumeChapterNumber, title, content))
+	}
+
+	// Text before the first bookmark (title page, preface) is kept as its own chapter
+	if first := cuts[0].paragraph; first > 0 {
+		add(frontMatterTitle, false, paragraphs[:first])
+	}
+	for i, c := range cuts {
+		end := len(paragraphs)
+		if i+1 < len(cuts) {
+			end = cuts[i+1].paragraph
+		}
+		add(c.mark.Title, c.mark.Volume, paragraphs[c.paragraph:end])
+	}
+	return chapters
+}
+
+func newPDFChapter(number, volume, volumeChapter int, title, content string) models.Chapter {
+	return models.Chapter{
+		ID:                  uuid.New().String(),
+		ChapterNumber:       number,
+		VolumeNumber:        volume,
+		VolumeChapterNumber: volumeChapter,
+		Title:               title,
+		Content:             content,
+		WordCount:           textstat.WordCount(content),
+	}
+}
+
+// titleMatches reports whether a paragraph is (or starts with) an outline title, ignoring spacing
+func titleMatches(paragraph, title string) bool {
+	t := normalizeTitle(title)
+	return t != "" && strings.HasPrefix(normalizeTitle(paragraph), t)
+}
+
+func normalizeTitle(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+// pdfOutlineMarks flattens the document outline into page marks. Top-level entries whose
+// children point at later pages are treated as parts (volumes), like EPUB navigation.
+func pdfOutlineMarks(r *pdf.Reader) []pdfMark {
+	root := r.Trailer().Key("Root")
+	pageIndex := pdfPageIndex(r)
+	if len(pageIndex) == 0 {
+		return nil
+	}
+
+	resolve := func(item pdf.Value) (int, bool) {
+		dest := item.Key("Dest")
+		if dest.IsNull() {
+			if action := item.Key("A"); action.Key("S").Name() == "GoTo" {
+				dest = action.Key("D")
+			}
+		}
+		dest = resolveNamedDest(root, dest)
+		if dest.Kind() == pdf.Dict {
+			dest = dest.Key("D")
+		}
+		if dest.Kind() != pdf.Array || dest.Len() == 0 {
+			return 0, false
+		}
+		target := dest.Index(0)
+		if target.Kind() == pdf.Integer {
+			// Remote-style destinations use a page number instead of a page reference
+			n := int(target.Int64())
+			return n, n >= 0 && n < len(pageIndex)
+		}
+		if target.Kind() != pdf.Dict {
+			return 0, false
+		}
+		refs := pdfReferences(dest)
+		if len(refs) == 0 {
+			return 0, false
+		}
+		page, ok := pageIndex[refs[0]]
+		return page, ok
+	}
+
+	var marks []pdfMark
+	seen := make(map[string]bool)
+	var walk func(first pdf.Value, depth int)
+	walk = func(first pdf.Value, depth int) {
+		if depth > 32 {
+			return
+		}
+		for item, n := first, 0; item.Kind() == pdf.Dict && n < 10000; item, n = item.Key("Next"), n+1 {
+			// An item's dictionary holds the references to its neighbours, so it only repeats
+			// when /First or /Next lead back to an item already walked
+			key := item.String()
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+
+			title := strings.Join(strings.Fields(item.Key("Title").Text()), " ")
+			page, ok := resolve(item)
+			children := item.Key("First")
+
+			if ok && title != "" {
+				isPart := depth == 0 && children.Kind() == pdf.Dict && hasLaterChild(children, page, resolve)
+				marks = append(marks, pdfMark{Title: title, Page: page, Volume: isPart})
+			}
+			if children.Kind() == pdf.Dict {
+				walk(children, depth+1)
+			}
+		}
+	}
+	walk(root.Key("Outlines").Key("First"), 0)
+
+	sort.SliceStable(marks, func(i, j int) bool { return marks[i].Page < marks[j].Page })
+	return marks
+}
+
+func hasLaterChild(first pdf.Value, page int, resolve func(pdf.Value) (int, bool)) bool {
+	for item, n := first, 0; item.Kind() == pdf.Dict && n < 10000; item, n = item.Key("Next"), n+1 {
+		if p, ok := resolve(item); ok && p > page {
+			return true
+		}
+	}
+	return false
+}
+
+// pdfPageIndex walks the page tree in /Kids order and maps each page, by its object
+// reference, to its 0-based page number
+func pdfPageIndex(r *pdf.Reader) map[string]int {
+	index := make(map[string]int)
+	pages := 0
+	var walk func(node pdf.Value, depth int)
+	walk = func(node pdf.Value, depth int) {
+		if depth > 32 {
+			return
+		}
+		kids := node.Key("Kids")
+		refs := pdfReferences(kids)
+		for i := 0; i < kids.Len(); i++ {
+			kid := kids.Index(i)
+			switch kid.Key("Type").Name() {
+			case "Pages":
+				walk(kid, depth+1)
+			case "Page":
+				if len(refs) == kids.Len() {
+					if _, exists := index[refs[i]]; !exists {
+						index[refs[i]] = pages
+					}
+				}
+				pages++
+			}
+		}
+	}
+	walk(r.Trailer().Key("Root").Key("Pages"), 0)
+	return index
+}
+
+// pdfReferences returns the object references ("12 0 R") at the start of an array such as
+// /Kids or a destination. The pdf package resolves references when indexing an array, so
+// they are read from the array's text form, which prints them in PDF syntax.
+func pdfReferences(arr pdf.Value) []string {
+	m := pdfReferencesPattern.FindStringSubmatch(arr.String())
+	if m == nil {
+		return nil
+	}
+	var refs []string
+	for _, ref := range pdfReferencePattern.FindAllStringSubmatch(m[1], -1) {
+		refs = append(refs, ref[1]+" "+ref[2]+" R")
+	}
+	return refs
+}
+
+// resolveNamedDest looks up named destinations in the catalog's /Dests dictionary or /Names tree
+func resolveNamedDest(root, dest pdf.Value) pdf.Value {
+	var name string
+	switch dest.Kind() {
+	case pdf.Name:
+		name = dest.Name()
+	case pdf.String:
+		name = dest.RawString()
+	default:
+		return dest
+	}
+
+	if d := root.Key("Dests").Key(name); !d.IsNull() {
+		return d
+	}
+	return lookupNameTree(root.Key("Names").Key("Dests"), name, 0)
+}
+
+func lookupNameTree(node pdf.Value, name string, depth int) pdf.Value {
+	if node.Kind() != pdf.Dict || depth > 32 {
+		return pdf.Value{}
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if names.Index(i).RawString() == name {
+			return names.Index(i + 1)
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		if v := lookupNameTree(kids.Index(i), name, depth+1); !v.IsNull() {
+			return v
+		}
+	}
+	return pdf.Value{}
+}
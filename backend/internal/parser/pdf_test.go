@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOutline struct {
+	Title    string
+	Page     int // 0-based
+	Children []testOutline
+	Cycle    bool // /First and /Next point back at the item itself
+}
+
+// writeTestPDF writes a minimal uncompressed PDF with one content stream per page;
+// an empty stream makes a page that is identical to the one before it
+func writeTestPDF(t *testing.T, pageStreams []string, outline []testOutline, info string) string {
+	t.Helper()
+
+	// 1: catalog, 2: pages, 3: font, then page/content pairs, then outline items
+	objects := []string{"", "", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"}
+	pageIDs := make([]int, len(pageStreams))
+	contentID := 0
+	for i, stream := range pageStreams {
+		pageIDs[i] = len(objects) + 1
+		if stream == "" && contentID > 0 {
+			// An empty stream repeats the previous page, down to its content reference
+			objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentID))
+			continue
+		}
+		contentID = pageIDs[i] + 1
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentID),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+
+	var kids []string
+	for _, id := range pageIDs {
+		kids = append(kids, fmt.Sprintf("%d 0 R", id))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageIDs))
+
+	catalog := "<< /Type /Catalog /Pages 2 0 R"
+	if len(outline) > 0 {
+		var add func(items []testOutline, parent int) (int, int)
+		add = func(items []testOutline, parent int) (int, int) {
+			ids := make([]int, len(items))
+			for i := range items {
+				objects = append(objects, "")
+				ids[i] = len(objects)
+			}
+			for i, item := range items {
+				obj := fmt.Sprintf("<< /Title (%s) /Parent %d 0 R /Dest [%d 0 R /XYZ 0 792 0]", item.Title, parent, pageIDs[item.Page])
+				if i > 0 {
+					obj += fmt.Sprintf(" /Prev %d 0 R", ids[i-1])
+				}
+				switch {
+				case item.Cycle:
+					obj += fmt.Sprintf(" /Next %d 0 R /First %d 0 R", ids[i], ids[i])
+				case i+1 < len(items):
+					obj += fmt.Sprintf(" /Next %d 0 R", ids[i+1])
+				}
+				if len(item.Children) > 0 && !item.Cycle {
+					first, last := add(item.Children, ids[i])
+					obj += fmt.Sprintf(" /First %d 0 R /Last %d 0 R", first, last)
+				}
+				objects[ids[i]-1] = obj + " >>"
+			}
+			return ids[0], ids[len(ids)-1]
+		}
+		objects = append(objects, "")
+		outlinesID := len(objects)
+		first, last := add(outline, outlinesID)
+		objects[outlinesID-1] = fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R >>", first, last)
+		catalog += fmt.Sprintf(" /Outlines %d 0 R", outlinesID)
+	}
+	objects[0] = catalog + " >>"
+
+	trailer := fmt.Sprintf("<< /Size %d /Root 1 0 R", len(objects)+1)
+	if info != "" {
+		objects = append(objects, info)
+		trailer = fmt.Sprintf("<< /Size %d /Root 1 0 R /Info %d 0 R", len(objects)+1, len(objects))
+	}
+	trailer += " >>"
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer, xref)
+
+	filePath := filepath.Join(t.TempDir(), "test.pdf")
+	require.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0644))
+	return filePath
+}
+
+// textPage builds a content stream showing each line 14pt below the previous one;
+// an empty string leaves an extra blank line (paragraph gap)
+func textPage(lines ...string) string {
+	var sb strings.Builder
+	sb.WriteString("BT /F1 12 Tf 14 TL 72 720 Td\n")
+	for _, l := range lines {
+		if l != "" {
+			fmt.Fprintf(&sb, "(%s) Tj\n", l)
+		}
+		sb.WriteString("T*\n")
+	}
+	sb.WriteString("ET")
+	return sb.String()
+}
+
+func TestPdfParser_ParseOutline(t *testing.T) {
+	pages := []string{
+		textPage("My Novel", "", "by Someone"),
+		textPage("Part One", "", "Chapter 1", "", "The quick brown fox jumped over the lazy dog and then con-", "tinued running home."),
+		textPage("Chapter 2", "", "It was a dark night.", "", "12"),
+	}
+	outline := []testOutline{
+		{Title: "Part One", Page: 1, Children: []testOutline{
+			{Title: "Chapter 1", Page: 1},
+			{Title: "Chapter 2", Page: 2},
+		}},
+	}
+	filePath := writeTestPDF(t, pages, outline, "")
+
+	chapters, err := NewPdfParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 4)
+
+	assert.Equal(t, frontMatterTitle, chapters[0].Title)
+	assert.Equal(t, "My Novel\nby Someone", chapters[0].Content)
+
+	assert.Equal(t, "Part One", chapters[1].Title)
+	assert.Equal(t, 2, chapters[1].VolumeNumber)
+	assert.Equal(t, 0, chapters[1].VolumeChapterNumber)
+
+	assert.Equal(t, "Chapter 1", chapters[2].Title)
+	assert.Equal(t, "The quick brown fox jumped over the lazy dog and then continued running home.", chapters[2].Content)
+	assert.Equal(t, 1, chapters[2].VolumeChapterNumber)
+
+	assert.Equal(t, "Chapter 2", chapters[3].Title)
+	// The running page number is dropped
+	assert.Equal(t, "It was a dark night.", chapters[3].Content)
+	for i, c := range chapters {
+		assert.Equal(t, i+1, c.ChapterNumber)
+	}
+}
+
+func TestPdfParser_OutlineToIdenticalPages(t *testing.T) {
+	// Pages 1 and 2 are the same dictionary; only their object references tell them apart
+	pages := []string{
+		textPage("Chapter 1", "", "The same words on two pages."),
+		"",
+		textPage("Chapter 3", "", "Different words."),
+	}
+	outline := []testOutline{{Title: "One", Page: 0}, {Title: "Two", Page: 1}, {Title: "Three", Page: 2}}
+	filePath := writeTestPDF(t, pages, outline, "")
+
+	chapters, err := NewPdfParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 3)
+	assert.Equal(t, []string{"One", "Two", "Three"}, []string{chapters[0].Title, chapters[1].Title, chapters[2].Title})
+	assert.Equal(t, chapters[0].Content, chapters[1].Content)
+	assert.Contains(t, chapters[1].Content, "The same words on two pages.")
+}
+
+func TestPdfParser_CyclicOutline(t *testing.T) {
+	pages := []string{
+		textPage("Chapter 1", "", "First."),
+		textPage("Chapter 2", "", "Second."),
+	}
+	outline := []testOutline{{Title: "One", Page: 0}, {Title: "Two", Page: 1, Cycle: true}}
+	filePath := writeTestPDF(t, pages, outline, "")
+
+	chapters, err := NewPdfParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "One", chapters[0].Title)
+	assert.Equal(t, "Two", chapters[1].Title)
+}
+
+func TestPdfParser_ParseChunks(t *testing.T) {
+	var pages []string
+	for i := 1; i <= 12; i++ {
+		pages = append(pages, textPage(fmt.Sprintf("Text of page %d.", i)))
+	}
+	filePath := writeTestPDF(t, pages, nil, "")
+
+	chapters, err := NewPdfParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "Pages 1-10", chapters[0].Title)
+	assert.Equal(t, "Pages 11-12", chapters[1].Title)
+	assert.Equal(t, "Text of page 11.\nText of page 12.", chapters[1].Content)
+}
+
+func TestPdfParser_ImageOnly(t *testing.T) {
+	filePath := writeTestPDF(t, []string{"q 100 0 0 100 0 0 cm Q", "q Q"}, nil, "")
+
+	_, err := NewPdfParser().Parse(filePath)
+	assert.ErrorIs(t, err, ErrNoExtractableText)
+}
+
+func TestPdfParser_ExtractMetadata(t *testing.T) {
+	filePath := writeTestPDF(t, []string{textPage("Hello")}, nil,
+		"<< /Title (A Title) /Author (An Author) /Keywords (fantasy; adventure) /CreationDate (D:20200102030405Z) >>")
+
+	meta, err := NewPdfParser().ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "A Title", meta.Title)
+	assert.Equal(t, "An Author", meta.Author)
+	assert.Equal(t, []string{"fantasy", "adventure"}, meta.Subjects)
+	assert.Equal(t, "2020-01-02", meta.PublishedDate)
+}
+
+func TestJoinWrapped(t *testing.T) {
+	tests := []struct {
+		prev, next, expected string
+	}{
+		{"con-", "tinued", "continued"},
+		{"well-", "Known", "well-Known"},
+		{"first", "second", "first second"},
+		{"中文", "段落", "中文段落"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prev+"+"+tt.next, func(t *testing.T) {
+			assert.Equal(t, tt.expected, joinWrapped(tt.prev, tt.next))
+		})
+	}
+}
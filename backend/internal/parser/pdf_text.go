@@ -0,0 +1,399 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pageNumberPattern matches running page numbers such as "12", "- 12 -" or "Page 12"
+var pageNumberPattern = regexp.MustCompile(`(?i)^(?:page\s*)?[-–—\s]*\d{1,4}[-–—\s]*$|^第\s*\d+\s*页$`)
+
+// pdfLine is a run of text sharing a baseline, in content-stream order
+type pdfLine struct {
+	Y        float64
+	FontSize float64
+	Text     string
+}
+
+// pdfFont caches what is needed to decode and measure text shown with a font
+type pdfFont struct {
+	enc       pdf.TextEncoding
+	widths    []float64
+	firstChar int
+	twoByte   bool
+}
+
+type pdfMatrix [6]float64
+
+var identityMatrix = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+func (m pdfMatrix) mul(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func loadPDFFonts(page pdf.Page) map[string]*pdfFont {
+	fonts := make(map[string]*pdfFont)
+	for _, name := range page.Fonts() {
+		f := page.Font(name)
+		info := &pdfFont{
+			enc:       f.Encoder(),
+			widths:    f.Widths(),
+			firstChar: f.FirstChar(),
+			twoByte:   f.V.Key("Subtype").Name() == "Type0",
+		}
+		fonts[name] = info
+	}
+	return fonts
+}
+
+// advance estimates the width of raw (undecoded) text in unscaled text space units
+func (f *pdfFont) advance(raw string, size float64) float64 {
+	if f == nil {
+		return float64(len(raw)) * size * 0.5
+	}
+	if f.twoByte {
+		// CID fonts are mostly full-width CJK glyphs
+		return float64(len(raw)/2) * size
+	}
+	total := 0.0
+	for i := 0; i < len(raw); i++ {
+		w := 0.0
+		if idx := int(raw[i]) - f.firstChar; idx >= 0 && idx < len(f.widths) {
+			w = f.widths[idx]
+		}
+		if w == 0 {
+			w = 500
+		}
+		total += w / 1000 * size
+	}
+	return total
+}
+
+// extractPageLines interprets a page's content stream and groups the shown text into lines.
+// Text on the same baseline is joined, inserting spaces where the gap between runs is wide enough.
+func extractPageLines(page pdf.Page) (lines []pdfLine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read page content: %v", r)
+		}
+	}()
+
+	contents := page.V.Key("Contents")
+	if contents.IsNull() {
+		return nil, nil
+	}
+	fonts := loadPDFFonts(page)
+
+	var (
+		ctm      = identityMatrix
+		ctmStack []pdfMatrix
+		tm       = identityMatrix
+		tlm      = identityMatrix
+		leading  float64
+		size     float64 = 12
+		font     *pdfFont
+		line     strings.Builder
+		lineY    float64
+		lineSize float64
+		lineEndX float64
+		hasLine  bool
+	)
+
+	flush := func() {
+		if hasLine {
+			if text := strings.TrimSpace(line.String()); text != "" {
+				lines = append(lines, pdfLine{Y: lineY, FontSize: lineSize, Text: text})
+			}
+		}
+		line.Reset()
+		hasLine = false
+	}
+
+	show := func(raw string) {
+		text := raw
+		if font != nil && font.enc != nil {
+			text = font.enc.Decode(raw)
+		}
+		m := tm.mul(ctm)
+		x, y := m[4], m[5]
+		scale := math.Hypot(m[2], m[3])
+		if scale == 0 {
+			scale = 1
+		}
+		effSize := size * scale
+
+		switch {
+		case !hasLine || math.Abs(y-lineY) > effSize*0.5:
+			flush()
+			hasLine = true
+			lineY = y
+			lineSize = effSize
+		case x-lineEndX > effSize*0.2 && !strings.HasSuffix(line.String(), " "):
+			line.WriteByte(' ')
+		}
+		line.WriteString(text)
+
+		adv := font.advance(raw, size)
+		tm = pdfMatrix{1, 0, 0, 1, adv, 0}.mul(tm)
+		lineEndX = tm.mul(ctm)[4]
+	}
+
+	moveText := func(tx, ty float64) {
+		tlm = pdfMatrix{1, 0, 0, 1, tx, ty}.mul(tlm)
+		tm = tlm
+	}
+
+	pdf.Interpret(contents, func(stk *pdf.Stack, op string) {
+		n := stk.Len()
+		args := make([]pdf.Value, n)
+		for i := n - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+
+		switch op {
+		case "q":
+			ctmStack = append(ctmStack, ctm)
+		case "Q":
+			if len(ctmStack) > 0 {
+				ctm = ctmStack[len(ctmStack)-1]
+				ctmStack = ctmStack[:len(ctmStack)-1]
+			}
+		case "cm":
+			if n == 6 {
+				var m pdfMatrix
+				for i := range m {
+					m[i] = args[i].Float64()
+				}
+				ctm = m.mul(ctm)
+			}
+		case "BT":
+			tm, tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if n == 2 {
+				font = fonts[args[0].Name()]
+				size = args[1].Float64()
+			}
+		case "TL":
+			if n == 1 {
+				leading = args[0].Float64()
+			}
+		case "Td":
+			if n == 2 {
+				moveText(args[0].Float64(), args[1].Float64())
+			}
+		case "TD":
+			if n == 2 {
+				leading = -args[1].Float64()
+				moveText(args[0].Float64(), args[1].Float64())
+			}
+		case "Tm":
+			if n == 6 {
+				for i := range tlm {
+					tlm[i] = args[i].Float64()
+				}
+				tm = tlm
+			}
+		case "T*":
+			moveText(0, -leading)
+		case "'":
+			if n == 1 {
+				moveText(0, -leading)
+				show(args[0].RawString())
+			}
+		case "\"":
+			if n == 3 {
+				moveText(0, -leading)
+				show(args[2].RawString())
+			}
+		case "Tj":
+			if n == 1 {
+				show(args[0].RawString())
+			}
+		case "TJ":
+			if n != 1 {
+				return
+			}
+			for i := 0; i < args[0].Len(); i++ {
+				item := args[0].Index(i)
+				if item.Kind() == pdf.String {
+					show(item.RawString())
+					continue
+				}
+				// Numbers move the next glyph left (positive) or right (negative), in thousandths of the font size
+				tm = pdfMatrix{1, 0, 0, 1, -item.Float64() / 1000 * size, 0}.mul(tm)
+			}
+		}
+	})
+	flush()
+
+	return lines, nil
+}
+
+// reflowLines joins hard-wrapped lines into paragraphs. A paragraph ends at a larger than usual
+// vertical gap, or after a line that is clearly shorter than its neighbours.
+// Running page numbers are dropped.
+func reflowLines(lines []pdfLine) []string {
+	var kept []pdfLine
+	for _, l := range lines {
+		if !pageNumberPattern.MatchString(l.Text) {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	// Normal line spacing: the tighter gaps on the page, capped for pages with few lines
+	spacing := lowerQuartile(lineGaps(kept))
+	if limit := medianFloat(lineSizes(kept)) * 1.5; spacing == 0 || spacing > limit {
+		spacing = limit
+	}
+	width := medianInt(lineWidths(kept))
+
+	var paragraphs []string
+	var current string
+	for i, l := range kept {
+		if i == 0 {
+			current = l.Text
+			continue
+		}
+
+		prev := kept[i-1]
+		gap := prev.Y - l.Y
+		newParagraph := gap <= 0 || (spacing > 0 && gap > spacing*1.4) ||
+			math.Abs(l.FontSize-prev.FontSize) > 1 ||
+			(width > 0 && utf8.RuneCountInString(prev.Text) < width*3/4 && endsSentence(prev.Text))
+
+		if newParagraph {
+			paragraphs = append(paragraphs, current)
+			current = l.Text
+			continue
+		}
+		current = joinWrapped(current, l.Text)
+	}
+	return append(paragraphs, current)
+}
+
+// joinWrapped joins a line wrapped onto the next one, undoing end-of-line hyphenation
+func joinWrapped(prev, next string) string {
+	if prev == "" {
+		return next
+	}
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(next)
+
+	if last == '-' && len(prev) > 1 {
+		before, _ := utf8.DecodeLastRuneInString(prev[:len(prev)-1])
+		if unicode.IsLetter(before) && unicode.IsLower(first) {
+			return prev[:len(prev)-1] + next
+		}
+		// A hyphenated compound ("well-Known") keeps its hyphen
+		return prev + next
+	}
+	if isCJKRune(last) || isCJKRune(first) {
+		return prev + next
+	}
+	return prev + " " + next
+}
+
+// joinPageParagraphs concatenates the paragraphs of consecutive pages,
+// continuing a paragraph that was split by a page break
+func joinPageParagraphs(pages [][]string) []string {
+	var out []string
+	for _, paragraphs := range pages {
+		for i, p := range paragraphs {
+			if i == 0 && len(out) > 0 && continuesParagraph(out[len(out)-1], p) {
+				out[len(out)-1] = joinWrapped(out[len(out)-1], p)
+				continue
+			}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func continuesParagraph(prev, next string) bool {
+	if endsSentence(prev) {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(next)
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	return unicode.IsLower(first) || last == '-' || last == ','
+}
+
+func endsSentence(s string) bool {
+	last, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(s))
+	return strings.ContainsRune(".!?:;\"'”’)」』。！？…", last)
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+func lineGaps(lines []pdfLine) []float64 {
+	var gaps []float64
+	for i := 1; i < len(lines); i++ {
+		if gap := lines[i-1].Y - lines[i].Y; gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	return gaps
+}
+
+func lineSizes(lines []pdfLine) []float64 {
+	sizes := make([]float64, len(lines))
+	for i, l := range lines {
+		sizes[i] = l.FontSize
+	}
+	return sizes
+}
+
+func lineWidths(lines []pdfLine) []int {
+	widths := make([]int, len(lines))
+	for i, l := range lines {
+		widths[i] = utf8.RuneCountInString(l.Text)
+	}
+	return widths
+}
+
+func medianFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+func lowerQuartile(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/4]
+}
+
+func medianInt(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"html"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags are kept by sanitizeHTML; any other element is unwrapped (its children are kept)
+var allowedTags = map[string]bool{
+	"p": true, "br": true, "hr": true, "div": true, "span": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"em": true, "i": true, "strong": true, "b": true, "u": true, "s": true, "del": true, "ins": true,
+	"mark": true, "small": true, "sub": true, "sup": true, "q": true, "cite": true,
+	"blockquote": true, "pre": true, "code": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "caption": true, "thead": true, "tbody": true, "tfoot": true,
+	"tr": true, "th": true, "td": true, "colgroup": true, "col": true,
+	"ruby": true, "rb": true, "rt": true, "rp": true, "figure": true, "figcaption": true,
+}
+
+// droppedTags are removed together with everything inside them
+var droppedTags = map[string]bool{
+	"head": true, "title": true, "script": true, "style": true, "noscript": true, "template": true,
+	"iframe": true, "object": true, "embed": true, "math": true,
+	"form": true, "input": true, "button": true, "select": true, "textarea": true,
+}
+
+// allowedAttrs lists the attributes kept per element ("*" applies to all elements)
+var allowedAttrs = map[string]map[string]bool{
+	"*":        {"lang": true},
+	"img":      {"alt": true, "width": true, "height": true},
+	"td":       {"colspan": true, "rowspan": true},
+	"th":       {"colspan": true, "rowspan": true},
+	"ol":       {"start": true},
+	"col":      {"span": true},
+	"colgroup": {"span": true},
+}
+
+// blockTags start a new line in the plain-text rendering
+var blockTags = map[string]bool{
+	"p": true, "br": true, "hr": true, "div": true, "section": true, "article": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "caption": true, "tr": true, "figure": true, "figcaption": true,
+	"header": true, "footer": true, "aside": true, "nav": true, "main": true,
+}
+
+// parseHTMLFragment parses an HTML snippet (typically the inner HTML of <body>)
+func parseHTMLFragment(fragment string) []*xhtml.Node {
+	context := &xhtml.Node{Type: xhtml.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := xhtml.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return nil
+	}
+	return nodes
+}
+
+// sanitizeHTML reduces chapter HTML to a safe subset that keeps paragraphs, headings,
+// emphasis, lists, blockquotes, tables and images. Scripts, styles, event handlers and
+// unknown elements are removed; whitespace outside <pre> is collapsed.
+// resolveURL rewrites image sources; images it maps to "" (or all images, if it is nil) are dropped.
+func sanitizeHTML(fragment string, resolveURL func(string) string) string {
+	s := &sanitizer{resolveURL: resolveURL}
+	return s.sanitize(fragment)
+}
+
+type sanitizer struct {
+	sb         strings.Builder
+	resolveURL func(string) string
+	// skip drops matching elements with their content (e.g. footnote bodies)
+	skip func(*xhtml.Node) bool
+	// noteRef returns the footnote ID a link points to, or "" for ordinary links (which are unwrapped)
+	noteRef func(href, label string) string
+}
+
+func (s *sanitizer) sanitize(fragment string) string {
+	s.sb.Reset()
+	for _, n := range parseHTMLFragment(fragment) {
+		s.write(n, false)
+	}
+	return strings.TrimSpace(s.sb.String())
+}
+
+func (s *sanitizer) write(n *xhtml.Node, inPre bool) {
+	sb := &s.sb
+	switch n.Type {
+	case xhtml.TextNode:
+		text := n.Data
+		if !inPre {
+			text = collapseSpaces(text)
+		}
+		sb.WriteString(html.EscapeString(text))
+		return
+	case xhtml.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s.write(c, inPre)
+		}
+		return
+	}
+
+	tag := strings.ToLower(n.Data)
+	switch {
+	case droppedTags[tag], s.skip != nil && s.skip(n):
+		return
+	case tag == "a" && s.noteRef != nil:
+		if id := s.noteRef(attrValue(n, "href"), textContent(n)); id != "" {
+			sb.WriteString(`<a href="#` + html.EscapeString(id) + `" data-footnote="` + html.EscapeString(id) + `">`)
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				s.write(c, inPre)
+			}
+			sb.WriteString("</a>")
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s.write(c, inPre)
+		}
+		return
+	case tag == "img":
+		s.writeImage(n, attrValue(n, "src"))
+		return
+	case tag == "svg":
+		// Illustrations are often wrapped in <svg><image xlink:href="..."/></svg>; keep just the image
+		s.writeSVGImages(n)
+		return
+	case !allowedTags[tag]:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s.write(c, inPre)
+		}
+		return
+	}
+
+	s.writeStartTag(n, tag)
+	sb.WriteString(">")
+
+	if tag == "br" || tag == "hr" || tag == "col" {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.write(c, inPre || tag == "pre")
+	}
+	sb.WriteString("</" + tag + ">")
+}
+
+// writeStartTag writes an opening tag with its allowed attributes, without the closing ">"
+func (s *sanitizer) writeStartTag(n *xhtml.Node, tag string) {
+	s.sb.WriteString("<" + tag)
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !(allowedAttrs["*"][key] || allowedAttrs[tag][key]) {
+			continue
+		}
+		s.sb.WriteString(" " + key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+}
+
+func (s *sanitizer) writeImage(n *xhtml.Node, src string) {
+	if s.resolveURL == nil || src == "" {
+		return
+	}
+	if src = s.resolveURL(src); src == "" {
+		return
+	}
+	s.writeStartTag(n, "img")
+	s.sb.WriteString(` src="` + html.EscapeString(src) + `">`)
+}
+
+func (s *sanitizer) writeSVGImages(n *xhtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xhtml.ElementNode && strings.EqualFold(c.Data, "image") {
+			href := attrValue(c, "href")
+			if href == "" {
+				href = attrValue(c, "xlink:href")
+			}
+			s.writeImage(c, href)
+			continue
+		}
+		s.writeSVGImages(c)
+	}
+}
+
+// attrValue returns the value of the named attribute ignoring namespaces ("xlink:href" matches xlink-namespaced href)
+func attrValue(n *xhtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+		if a.Namespace != "" && strings.EqualFold(a.Namespace+":"+a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// htmlToText renders chapter HTML as plain text with one paragraph per line,
+// used for search, word counts and readers that don't render HTML
+func htmlToText(fragment string) string {
+	return renderText(fragment, nil)
+}
+
+// renderText is htmlToText leaving out elements matched by skip
+func renderText(fragment string, skip func(*xhtml.Node) bool) string {
+	var sb strings.Builder
+	for _, n := range parseHTMLFragment(fragment) {
+		writeText(&sb, n, false, skip)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeText(sb *strings.Builder, n *xhtml.Node, inPre bool, skip func(*xhtml.Node) bool) {
+	if n.Type == xhtml.TextNode {
+		text := n.Data
+		if !inPre {
+			text = strings.ReplaceAll(text, "\n", " ")
+		}
+		sb.WriteString(text)
+		return
+	}
+
+	tag := ""
+	if n.Type == xhtml.ElementNode {
+		tag = strings.ToLower(n.Data)
+		if droppedTags[tag] || tag == "svg" || (skip != nil && skip(n)) {
+			return
+		}
+	}
+
+	if blockTags[tag] {
+		sb.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(sb, c, inPre || tag == "pre", skip)
+	}
+	switch {
+	case blockTags[tag]:
+		sb.WriteString("\n")
+	case tag == "td" || tag == "th":
+		sb.WriteString(" ")
+	}
+}
+
+// textContent returns the whitespace-normalized text inside n
+func textContent(n *xhtml.Node) string {
+	var sb strings.Builder
+	var walk func(n *xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// collapseSpaces replaces runs of whitespace with a single space
+func collapseSpaces(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Keeps structure", `<h2 class="t">Title</h2><p>Some <em>emphasis</em> and <b>bold</b>.</p>`, `<h2>Title</h2><p>Some <em>emphasis</em> and <b>bold</b>.</p>`},
+		{"Drops scripts and styles", `<style>p{}</style><p onclick="x()">Hi</p><script>alert(1)</script>`, `<p>Hi</p>`},
+		{"Unwraps unknown elements", `<section><p>One</p><font color="red">Two</font></section>`, `<p>One</p>Two`},
+		{"Keeps tables", `<table><tr><td colspan="2" style="x">A</td></tr></table>`, `<table><tbody><tr><td colspan="2">A</td></tr></tbody></table>`},
+		{"Escapes text", `<p>a &lt; b &amp; c</p>`, `<p>a &lt; b &amp; c</p>`},
+		{"Collapses whitespace", "<p>Hello\n   world</p>", `<p>Hello world</p>`},
+		{"Self-closing breaks", `<p>Line one<br/>Line two</p>`, `<p>Line one<br>Line two</p>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeHTML(tt.input, nil))
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Paragraphs", "<p>First\nparagraph.</p>\n<p>Second.</p>", "First paragraph.\nSecond."},
+		{"Headings and quotes", "<h1>Title</h1><blockquote><p>Quote</p></blockquote>", "Title\nQuote"},
+		{"Inline markup", "<p>Some <em>emphasis</em> here</p>", "Some emphasis here"},
+		{"Line breaks", "<p>One<br/>Two</p>", "One\nTwo"},
+		{"Lists", "<ul><li>A</li><li>B</li></ul>", "A\nB"},
+		{"Entities", "<p>a &amp; b</p>", "a & b"},
+		{"Drops scripts", "<script>var x;</script><p>Text</p>", "Text"},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, htmlToText(tt.input))
+		})
+	}
+}
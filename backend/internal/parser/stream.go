@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// Progress tells how much of a book a parse has read: bytes for text files,
+// sections for EPUB and chapters for parsers that cannot stream. Total is 0 when unknown.
+type Progress struct {
+	Done  int64
+	Total int64
+}
+
+// ChapterFunc receives the chapters of a book in order; returning an error stops the parse
+type ChapterFunc func(chapter models.Chapter, progress Progress) error
+
+// StreamParser is implemented by parsers that read a book from any io.ReaderAt and hand over
+// chapters as they are produced, so large books are never held in memory as a whole and
+// parses can be cancelled through ctx
+type StreamParser interface {
+	ParseStream(ctx context.Context, r io.ReaderAt, size int64, emit ChapterFunc) error
+}
+
+// ParseFile streams the chapters of filePath to emit, through ParseStream when p supports it.
+// Other parsers parse the whole file first and report progress in chapters.
+func ParseFile(ctx context.Context, p Parser, filePath string, emit ChapterFunc) error {
+	if sp, ok := p.(StreamParser); ok {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		return sp.ParseStream(ctx, f, info.Size(), emit)
+	}
+
+	chapters, err := p.Parse(filePath)
+	if err != nil {
+		return err
+	}
+	for i, ch := range chapters {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := emit(ch, Progress{Done: int64(i + 1), Total: int64(len(chapters))}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectChapters runs a stream parse of filePath to the end and returns its chapters
+func collectChapters(p StreamParser, filePath string) ([]models.Chapter, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	var chapters []models.Chapter
+	err = p.ParseStream(context.Background(), f, info.Size(), func(ch models.Chapter, _ Progress) error {
+		chapters = append(chapters, ch)
+		return nil
+	})
+	return chapters, err
+}
+
+// chapterSink numbers chapters as a stream parser produces them, splits oversized ones
+// (see splitChapter; volume pages are kept whole) and passes them on. Untitled chapters are
+// named by their number.
+type chapterSink struct {
+	ctx        context.Context
+	splitWords int
+	emit       ChapterFunc
+	count      int
+	volume     int
+	inVolume   int
+}
+
+func newChapterSink(ctx context.Context, splitWords int, emit ChapterFunc) *chapterSink {
+	return &chapterSink{ctx: ctx, splitWords: splitWords, emit: emit}
+}
+
+func (s *chapterSink) add(ch models.Chapter, progress Progress) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	parts := []models.Chapter{ch}
+	if s.splitWords > 0 && ch.VolumeChapterNumber != 0 && ch.WordCount > s.splitWords*3/2 {
+		parts = splitChapter(ch, s.splitWords)
+	}
+	for _, part := range parts {
+		s.count++
+		part.ChapterNumber = s.count
+		if part.VolumeChapterNumber == 0 || part.VolumeNumber != s.volume {
+			s.volume, s.inVolume = part.VolumeNumber, 0
+		}
+		if part.VolumeChapterNumber != 0 {
+			s.inVolume++
+			part.VolumeChapterNumber = s.inVolume
+		}
+		if part.Title == "" {
+			part.Title = fmt.Sprintf("Chapter %d", s.count)
+		}
+		if err := s.emit(part, progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it, for progress reports
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
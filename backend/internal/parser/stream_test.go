@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+func TestParseFile_Stream(t *testing.T) {
+	text := "第一章 开始\n第一段。\n第二章 继续\n第二段。\n第三章 结束\n第三段。\n"
+	filePath := writeTestFile(t, "book.txt", []byte(text))
+
+	var titles []string
+	var last Progress
+	err := ParseFile(context.Background(), NewTxtParser(), filePath, func(ch models.Chapter, progress Progress) error {
+		titles = append(titles, ch.Title)
+		assert.Equal(t, len(titles), ch.ChapterNumber)
+		assert.GreaterOrEqual(t, progress.Done, last.Done)
+		last = progress
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"第一章 开始", "第二章 继续", "第三章 结束"}, titles)
+	assert.Equal(t, int64(len(text)), last.Total)
+	assert.Equal(t, last.Total, last.Done)
+}
+
+func TestParseFile_StreamsUntitledText(t *testing.T) {
+	// Without titles, parts are handed on while the file is still being read
+	var sb strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&sb, "Paragraph %d has exactly six words.\n", i)
+	}
+	filePath := writeTestFile(t, "book.txt", []byte(sb.String()))
+
+	var progress []Progress
+	var content strings.Builder
+	p := NewTxtParserWithOptions(Options{SplitChapterWords: 600})
+	err := ParseFile(context.Background(), p, filePath, func(ch models.Chapter, pr Progress) error {
+		assert.Equal(t, fmt.Sprintf("Chapter %d", ch.ChapterNumber), ch.Title)
+		progress = append(progress, pr)
+		content.WriteString(ch.Content)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, progress, 50)
+	assert.Less(t, progress[0].Done, progress[0].Total/2)
+	assert.Equal(t, sb.String(), content.String())
+}
+
+func TestParseFile_StopsOnError(t *testing.T) {
+	filePath := writeTestFile(t, "book.txt", []byte("Chapter 1\nOne.\nChapter 2\nTwo.\n"))
+
+	stop := errors.New("stop")
+	count := 0
+	err := ParseFile(context.Background(), NewTxtParser(), filePath, func(models.Chapter, Progress) error {
+		count++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, count)
+}
+
+func TestParseFile_Cancelled(t *testing.T) {
+	filePath := writeTestFile(t, "book.txt", []byte(strings.Repeat("Chapter 1\nOne.\n", 10)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ParseFile(ctx, NewTxtParser(), filePath, func(models.Chapter, Progress) error {
+		t.Fatal("no chapter expected after cancellation")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParseFile_NonStreaming(t *testing.T) {
+	filePath := writeTestFile(t, "book.html", []byte("<html><body><h1>One</h1><p>First.</p><h1>Two</h1><p>Second.</p></body></html>"))
+
+	var progress []Progress
+	err := ParseFile(context.Background(), NewHtmlParser(), filePath, func(_ models.Chapter, p Progress) error {
+		progress = append(progress, p)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Progress{{Done: 1, Total: 2}, {Done: 2, Total: 2}}, progress)
+}
@@ -2,17 +2,18 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 	runelib "unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/saintfish/chardet"
 	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/textstat"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/ianaindex"
@@ -23,245 +24,286 @@ import (
 )
 
 // TxtParser parses .txt files
-type TxtParser struct{}
+type TxtParser struct {
+	opts Options
+}
 
 // NewTxtParser creates a new TxtParser
 func NewTxtParser() *TxtParser {
 	return &TxtParser{}
 }
 
+// NewTxtParserWithOptions creates a TxtParser that finds chapter and volume titles with opts.ChapterRules
+func NewTxtParserWithOptions(opts Options) *TxtParser {
+	return &TxtParser{opts: opts}
+}
+
 // Parse parses a txt file and returns chapters
 func (p *TxtParser) Parse(filePath string) ([]models.Chapter, error) {
-	file, err := os.Open(filePath)
+	return collectChapters(p, filePath)
+}
+
+// ParseStream splits a text file into chapters at the lines the chapter rules mark as titles,
+// handing each chapter to emit as soon as its text is complete. Progress is in bytes.
+func (p *TxtParser) ParseStream(ctx context.Context, r io.ReaderAt, size int64, emit ChapterFunc) error {
+	titles, err := p.titleMatcher()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return err
 	}
-	defer file.Close()
 
 	// Detect and decode file encoding so Chinese text displays correctly
-	enc := detectFileEncoding(filePath)
-	reader := transform.NewReader(file, enc.NewDecoder())
+	enc := detectEncoding(r)
+	counter := &countingReader{r: io.NewSectionReader(r, 0, size)}
+	scanner := newTxtScanner(transform.NewReader(counter, enc.NewDecoder()))
+	splitWords := p.opts.splitWords()
+	sink := newChapterSink(ctx, splitWords, emit)
+	progress := func() Progress {
+		return Progress{Done: counter.n, Total: size}
+	}
 
-	var chapters []models.Chapter
-	var currentChapter *models.Chapter
-	chapterNumber := 0
+	// Text before the first title (title page, synopsis, author's notes) is kept as front matter;
+	// it is dropped like any other empty chapter if there is none
+	currentChapter := &models.Chapter{VolumeNumber: 1, VolumeChapterNumber: 1, Title: frontMatterTitle}
 	volumeNumber := 1
-	volumeChapterNumber := 0
 	started := false
-	awaitingChapter := false
-
-	scanner := bufio.NewScanner(reader)
-	scanner.Split(bufio.ScanLines)
-	// Increase buffer to handle long lines without scan errors
-	scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+	emitted := 0
+	untitledWords := 0
 
 	var contentBuilder strings.Builder
 
-	saveCurrentChapter := func(force bool) {
+	saveCurrentChapter := func() error {
 		if currentChapter == nil {
-			return
+			return nil
 		}
-
+		chapter := *currentChapter
 		content := contentBuilder.String()
-		if strings.TrimSpace(content) == "" && !force {
-			// Skip empty chapters (e.g., consecutive headers or volume markers)
-			if chapterNumber > 0 {
-				chapterNumber--
-			}
-			if volumeChapterNumber > 0 {
-				volumeChapterNumber--
-			}
-			currentChapter = nil
-			contentBuilder.Reset()
-			return
-		}
-
-		currentChapter.Content = content
-		currentChapter.WordCount = len(strings.Fields(content))
-		chapters = append(chapters, *currentChapter)
 		currentChapter = nil
 		contentBuilder.Reset()
+
+		// Skip empty chapters (e.g., consecutive headers or volume markers)
+		if strings.TrimSpace(content) == "" {
+			return nil
+		}
+		chapter.ID = uuid.New().String()
+		chapter.Content = content
+		chapter.WordCount = textstat.WordCount(content)
+		emitted++
+		return sink.add(chapter, progress())
 	}
 
+	lines := 0
 	for scanner.Scan() {
 		line := scanner.Text()
+		if lines == 0 {
+			// A byte order mark would otherwise turn an empty front matter into content
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		lines++
+		if lines%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		kind := titles.match(line)
 
 		// Detect volume markers (e.g., "第一卷", "卷一", "Volume 1")
-		if isVolumeTitle(line) {
+		if kind == volumeLine {
+			firstTitle := !started
 			started = true
-			awaitingChapter = true
 			// Save previous chapter if exists before switching volume
-			saveCurrentChapter(false)
+			if err := saveCurrentChapter(); err != nil {
+				return err
+			}
 
 			if parsedVol := parseVolumeNumber(line); parsedVol > 0 {
 				volumeNumber = parsedVol
-			} else if chapterNumber == 0 && volumeNumber == 1 {
+			} else if firstTitle {
 				// First volume marker with no explicit number defaults to 1
 				volumeNumber = 1
 			} else {
 				volumeNumber++
 			}
-			volumeChapterNumber = 0
 
 			// Add a standalone chapter entry for the volume page
-			chapterNumber++
+			emitted++
 			volumePage := models.Chapter{
 				ID:                  uuid.New().String(),
-				ChapterNumber:       chapterNumber,
 				VolumeNumber:        volumeNumber,
 				VolumeChapterNumber: 0,
 				Title:               strings.TrimSpace(line),
-				Content:             "",
-				WordCount:           0,
 			}
-			chapters = append(chapters, volumePage)
-			currentChapter = nil
-			contentBuilder.Reset()
+			if err := sink.add(volumePage, progress()); err != nil {
+				return err
+			}
 			continue
 		}
 
 		// Check if line is a chapter title (e.g., "Chapter 1", "第1章", etc.)
-		if isChapterTitle(line) {
+		if kind == chapterLine {
 			started = true
-			awaitingChapter = false
 			// Save previous chapter if exists
-			saveCurrentChapter(false)
+			if err := saveCurrentChapter(); err != nil {
+				return err
+			}
 
 			// Start new chapter
-			chapterNumber++
-			volumeChapterNumber++
 			currentChapter = &models.Chapter{
-				ID:                  uuid.New().String(),
-				ChapterNumber:       chapterNumber,
 				VolumeNumber:        volumeNumber,
-				VolumeChapterNumber: volumeChapterNumber,
+				VolumeChapterNumber: 1,
 				Title:               strings.TrimSpace(line),
 			}
-			contentBuilder.Reset()
 		} else if currentChapter != nil {
 			// Add content to current chapter
 			contentBuilder.WriteString(line)
 			contentBuilder.WriteString("\n")
-		} else if started && currentChapter != nil && !awaitingChapter {
-			// Only record content after we've seen a volume/chapter marker
-			contentBuilder.WriteString(line)
-			contentBuilder.WriteString("\n")
+
+			// Text before any title may run to the end of the file. Rather than holding it all,
+			// it is handed on in untitled parts, each ending at the first line past the split size.
+			if !started && splitWords > 0 {
+				if untitledWords += textstat.WordCount(line); untitledWords >= splitWords {
+					currentChapter.Title = ""
+					if err := saveCurrentChapter(); err != nil {
+						return err
+					}
+					currentChapter = &models.Chapter{VolumeNumber: volumeNumber, VolumeChapterNumber: 1}
+					untitledWords = 0
+				}
+			}
 		}
 	}
 
-	// Save last chapter
-	saveCurrentChapter(false)
-
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return fmt.Errorf("error reading file: %w", err)
 	}
 
-	// If no chapters found, create a single chapter with all content
-	if len(chapters) == 0 {
-		return []models.Chapter{
-			{
-				ID:                  uuid.New().String(),
-				ChapterNumber:       1,
-				VolumeNumber:        volumeNumber,
-				VolumeChapterNumber: 1,
-				Title:               "Chapter 1",
-				Content:             contentBuilder.String(),
-				WordCount:           len(strings.Fields(contentBuilder.String())),
-			},
-		}, nil
+	// Save last chapter; a file without titles is not front matter but untitled chapters,
+	// which the sink names "Chapter N"
+	if !started {
+		currentChapter.Title = ""
+	}
+	if err := saveCurrentChapter(); err != nil {
+		return err
 	}
 
-	return chapters, nil
+	// An empty file still gets a chapter
+	if emitted == 0 {
+		return sink.add(models.Chapter{
+			ID:                  uuid.New().String(),
+			VolumeNumber:        volumeNumber,
+			VolumeChapterNumber: 1,
+		}, progress())
+	}
+	return nil
 }
 
-// isChapterTitle checks if a line is likely a chapter title
-func isChapterTitle(line string) bool {
-	trimmed := strings.TrimSpace(line)
-	lineLower := strings.ToLower(trimmed)
-
-	if trimmed == "" {
-		return false
+// ExtractMetadata reads title, author and synopsis from the front matter before the first chapter,
+// where web-novel dumps usually put labelled lines such as "书名：", "作者：" and "简介："
+func (p *TxtParser) ExtractMetadata(filePath string) (*BookMetadata, error) {
+	titles, err := p.titleMatcher()
+	if err != nil {
+		return nil, err
 	}
 
-	// Common English chapter patterns
-	englishPatterns := []string{
-		"chapter ",
-		"chapter:",
-		"ch.",
-		"ch ",
+	file, scanner, err := openTxt(filePath)
+	if err != nil {
+		return nil, err
 	}
+	defer file.Close()
 
-	for _, pattern := range englishPatterns {
-		if strings.HasPrefix(lineLower, pattern) {
-			return true
+	var lines []string
+	for len(lines) < maxFrontMatterLines && scanner.Scan() {
+		line := scanner.Text()
+		if titles.match(line) != bodyLine {
+			break
 		}
+		lines = append(lines, line)
 	}
-
-	// Chinese chapter pattern: must contain both "第" and "章"
-	// Examples: "第一章", "第2章", "第二十三章"
-	// This prevents matching "第二天" (Day 2) which only has "第"
-	if strings.HasPrefix(trimmed, "第") && strings.Contains(trimmed, "章") {
-		return true
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
-	return false
+	meta := frontMatterMetadata(lines)
+	if meta.Title == "" && meta.Author == "" && meta.Description == "" {
+		return nil, fmt.Errorf("no metadata found in front matter")
+	}
+	return meta, nil
 }
 
-// isVolumeTitle checks if a line is likely a volume title (e.g., "第一卷", "卷一", "Volume 1")
-func isVolumeTitle(line string) bool {
-	trimmed := strings.TrimSpace(line)
-	lineLower := strings.ToLower(trimmed)
-
-	if trimmed == "" {
-		return false
+// titleMatcher compiles the configured chapter rules, falling back to the default preset
+func (p *TxtParser) titleMatcher() (*titleMatcher, error) {
+	if len(p.opts.ChapterRules) == 0 {
+		return defaultTitleMatcher, nil
 	}
-
-	// Bound length to avoid matching long sentences
-	if len([]rune(trimmed)) > 50 {
-		return false
+	m, err := newTitleMatcher(p.opts.ChapterRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chapter rules: %w", err)
 	}
+	return m, nil
+}
 
-	// English patterns with explicit keywords + digits
-	english := regexp.MustCompile(`^(volume|vol\.?)\s*\d+(?:\s+.+)?$`)
-	if english.MatchString(lineLower) {
-		return true
+// openTxt opens a text file and returns a line scanner that decodes it to UTF-8
+func openTxt(filePath string) (*os.File, *bufio.Scanner, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
-	// Chinese patterns: 第X卷 ... or 卷X ...
-	chPattern := regexp.MustCompile(`^(第[\p{Han}\d]+卷|卷[\p{Han}\d]+)(\s+.+)?$`)
-	if chPattern.MatchString(trimmed) {
-		return true
-	}
+	// Detect and decode file encoding so Chinese text displays correctly
+	enc := detectEncoding(file)
+	return file, newTxtScanner(transform.NewReader(file, enc.NewDecoder())), nil
+}
+
+func newTxtScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanLines)
+	// Increase buffer to handle long lines without scan errors
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
+	return scanner
+}
 
-	return false
+// isChapterTitle checks if a line is a chapter title under the default rules
+func isChapterTitle(line string) bool {
+	return defaultTitleMatcher.match(line) == chapterLine
 }
 
-// detectFileEncoding attempts to detect the file encoding and returns a decoder-compatible encoding.
+// detectEncoding attempts to detect the encoding of a text and returns a decoder-compatible encoding.
 // Defaults to UTF-8 if detection fails or the charset is unsupported.
-func detectFileEncoding(filePath string) encoding.Encoding {
+func detectEncoding(r io.ReaderAt) encoding.Encoding {
+	if enc := encodingFromName(detectCharset(r)); enc != nil {
+		return enc
+	}
+	return unicode.UTF8
+}
+
+// DetectEncoding returns the name of the charset a text file is decoded with ("UTF-8" if unknown)
+func DetectEncoding(filePath string) string {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return unicode.UTF8
+		return "UTF-8"
 	}
 	defer file.Close()
 
+	name := detectCharset(file)
+	if name == "" || encodingFromName(name) == nil {
+		return "UTF-8"
+	}
+	return name
+}
+
+// detectCharset guesses the charset of a text from its first 4 KB; "" if detection fails
+func detectCharset(r io.ReaderAt) string {
 	sample := make([]byte, 4096)
-	n, err := file.Read(sample)
+	n, err := r.ReadAt(sample, 0)
 	if err != nil && err != io.EOF {
-		return unicode.UTF8
+		return ""
 	}
 
 	detector := chardet.NewTextDetector()
 	result, err := detector.DetectBest(sample[:n])
 	if err != nil || result == nil {
-		return unicode.UTF8
+		return ""
 	}
-
-	if enc := encodingFromName(result.Charset); enc != nil {
-		return enc
-	}
-
-	return unicode.UTF8
+	return result.Charset
 }
 
 // encodingFromName maps common charset names to Go encodings.
@@ -294,7 +336,7 @@ func parseVolumeNumber(line string) int {
 		}
 	}
 	if digits.Len() > 0 {
-		if n, err := strconv.Atoi(digits.String()); err == nil && n > 0 {
+		if n := parseNumeral(digits.String()); n > 0 {
 			return n
 		}
 	}
@@ -318,51 +360,109 @@ func parseVolumeNumber(line string) int {
 	return 0
 }
 
-// chineseNumeralToInt converts a simple Chinese numeral string (e.g., "一", "十二", "一百零三") to int.
-// It is intentionally minimal to cover common volume/chapter numbering.
-func chineseNumeralToInt(s string) int {
-	digits := map[rune]int{
+// maxNumeral is the largest number parseNumeral reads; titles declaring more are not numbered chapters
+const maxNumeral = 100000
+
+// parseNumeral converts Arabic digits (including full-width ones such as "１２") or a Chinese numeral to int.
+// Returns 0 if s is neither or exceeds maxNumeral.
+func parseNumeral(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			n = n*10 + int(r-'0')
+		case r >= '０' && r <= '９':
+			n = n*10 + int(r-'０')
+		default:
+			// A long numeral read digit by digit ("一〇二…") could overflow
+			if utf8.RuneCountInString(s) > 16 {
+				return 0
+			}
+			n = chineseNumeralToInt(s)
+			if n > maxNumeral {
+				return 0
+			}
+			return n
+		}
+		if n > maxNumeral {
+			return 0
+		}
+	}
+	return n
+}
+
+var (
+	chineseDigits = map[rune]int{
 		'零': 0, '〇': 0,
 		'一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
 		'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+		'壹': 1, '贰': 2, '貳': 2, '叁': 3, '參': 3, '肆': 4,
+		'伍': 5, '陆': 6, '陸': 6, '柒': 7, '捌': 8, '玖': 9,
 	}
-	units := map[rune]int{
-		'十': 10,
-		'百': 100,
-		'千': 1000,
+	chineseUnits = map[rune]int{
+		'十': 10, '拾': 10,
+		'百': 100, '佰': 100,
+		'千': 1000, '仟': 1000,
 	}
+	// chineseLargeUnits multiply everything before them ("三万二千" = 32000)
+	chineseLargeUnits = map[rune]int{
+		'万': 10000, '萬': 10000,
+		'亿': 100000000, '億': 100000000,
+	}
+)
 
-	total := 0
-	current := 0
+// chineseNumeralToInt converts a Chinese numeral string (e.g., "一", "十二", "一百零三", "一万零五") to int.
+// Numerals written digit by digit ("一〇二") are read positionally. Unknown runes are ignored.
+func chineseNumeralToInt(s string) int {
 	runes := []rune(s)
 
-	for i, r := range runes {
-		if val, ok := digits[r]; ok {
+	positional := len(runes) > 1
+	for _, r := range runes {
+		if _, ok := chineseDigits[r]; !ok {
+			positional = false
+			break
+		}
+	}
+	if positional {
+		n := 0
+		for _, r := range runes {
+			n = n*10 + chineseDigits[r]
+		}
+		return n
+	}
+
+	total := 0
+	section := 0
+	current := 0
+	for _, r := range runes {
+		if val, ok := chineseDigits[r]; ok {
 			current = val
-			// If this is the last rune and it's a digit, add it
-			if i == len(runes)-1 {
-				total += current
-			}
 			continue
 		}
 
-		if unit, ok := units[r]; ok {
+		if unit, ok := chineseUnits[r]; ok {
+			// "十二" and "一百零十" leave the multiplier out
 			if current == 0 {
 				current = 1
 			}
-			total += current * unit
+			section += current * unit
+			current = 0
+			continue
+		}
+
+		if unit, ok := chineseLargeUnits[r]; ok {
+			total += (section + current) * unit
+			section = 0
 			current = 0
 		}
 	}
 
-	return total
+	return total + section + current
 }
 
 func isChineseNumeralRune(r rune) bool {
-	switch r {
-	case '零', '〇', '一', '二', '两', '三', '四', '五', '六', '七', '八', '九', '十', '百', '千':
-		return true
-	default:
-		return false
-	}
+	_, digit := chineseDigits[r]
+	_, unit := chineseUnits[r]
+	_, large := chineseLargeUnits[r]
+	return digit || unit || large
 }
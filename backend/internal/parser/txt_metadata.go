@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// frontMatterTitle names the chapter holding the text of a TXT file before its first chapter.
+// Other formats name the text before their first heading or bookmark the same way.
+const frontMatterTitle = "前言"
+
+// maxFrontMatterLines bounds how much of a TXT file is searched for metadata
+const maxFrontMatterLines = 100
+
+var (
+	// frontMatterLabelPattern matches labelled lines such as "书名：斗破苍穹" or "Author: Jane Doe"
+	frontMatterLabelPattern = regexp.MustCompile(`(?i)^[【\[]?(书名|書名|作品名|名称|名稱|title|作者|著者|author|内容简介|內容簡介|简介|簡介|文案|synopsis|summary|description)[】\]]?\s*[:：]\s*(.*)$`)
+	// bookTitlePattern matches a title in book-title marks, optionally followed by the author
+	bookTitlePattern = regexp.MustCompile(`^《(.+?)》\s*(?:(?:作者|著者)\s*[:：]\s*(.+))?$`)
+	bylinePattern    = regexp.MustCompile(`(?i)^(?:written\s+)?by\s+(.+)$`)
+)
+
+// frontMatterFields maps front matter labels to the metadata field they fill
+var frontMatterFields = map[string]string{
+	"书名": "title", "書名": "title", "作品名": "title", "名称": "title", "名稱": "title", "title": "title",
+	"作者": "author", "著者": "author", "author": "author",
+	"内容简介": "description", "內容簡介": "description", "简介": "description", "簡介": "description",
+	"文案": "description", "synopsis": "description", "summary": "description", "description": "description",
+}
+
+// frontMatterMetadata reads title, author and synopsis from the lines before the first chapter.
+// A synopsis runs from its label to the next labelled line. Without a title label, a short
+// first line followed by an author is taken as the title.
+func frontMatterMetadata(lines []string) *BookMetadata {
+	meta := &BookMetadata{}
+	var description []string
+	inDescription := false
+	firstLine := ""
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		if m := frontMatterLabelPattern.FindStringSubmatch(line); m != nil {
+			value := strings.TrimSpace(m[2])
+			inDescription = false
+			switch frontMatterFields[strings.ToLower(m[1])] {
+			case "title":
+				meta.Title = firstNonEmpty([]string{meta.Title, strings.Trim(value, "《》")})
+			case "author":
+				meta.Author = firstNonEmpty([]string{meta.Author, value})
+			case "description":
+				inDescription = meta.Description == "" && len(description) == 0
+				if inDescription && value != "" {
+					description = append(description, value)
+				}
+			}
+			continue
+		}
+		if inDescription {
+			description = append(description, line)
+			continue
+		}
+		if line == "" {
+			continue
+		}
+
+		if m := bookTitlePattern.FindStringSubmatch(line); m != nil {
+			meta.Title = firstNonEmpty([]string{meta.Title, m[1]})
+			meta.Author = firstNonEmpty([]string{meta.Author, m[2]})
+		} else if m := bylinePattern.FindStringSubmatch(line); m != nil && utf8.RuneCountInString(m[1]) <= 50 {
+			meta.Author = firstNonEmpty([]string{meta.Author, m[1]})
+		} else if firstLine == "" && meta.Author == "" {
+			firstLine = line
+		}
+	}
+
+	if meta.Title == "" && meta.Author != "" && utf8.RuneCountInString(firstLine) <= 50 {
+		meta.Title = firstLine
+	}
+	meta.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return meta
+}
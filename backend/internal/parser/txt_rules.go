@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// DefaultChapterRuleSet is the ID of the preset used when an import selects no rule set
+const DefaultChapterRuleSet = "default"
+
+// maxTitleLength bounds title lines; longer lines are always body text
+const maxTitleLength = 80
+
+// Building blocks of the built-in rules
+const (
+	cjkNumber = `[\d０-９零〇一二两三四五六七八九十百千万壹贰叁肆伍陆柒捌玖拾佰仟]+`
+	// titleTail allows a title after the marker, separated by whitespace or punctuation
+	titleTail = `(?:[\s:：.．、·\-—]+.*)?$`
+	// punctTail is stricter for English words that also start sentences: only punctuation separates a title
+	punctTail = `(?:\s*[:：.\-—]\s*.*)?$`
+)
+
+var (
+	volumeRules = []models.ChapterRule{
+		{Name: "Volume N", Pattern: `(?i)^(?:volume|vol\.?)\s*\d+(?:\s+.+)?$`, Kind: models.ChapterRuleVolume},
+		{Name: "第N卷 / 卷N", Pattern: `^(?:第[\p{Han}\d]+卷|卷[\p{Han}\d]+)(?:\s+.+)?$`, Kind: models.ChapterRuleVolume},
+	}
+	chineseChapterRules = []models.ChapterRule{
+		{Name: "第N章/回/节", Pattern: `^[【\[]?第` + cjkNumber + `\s*[章回节節话話集篇].*$`, Kind: models.ChapterRuleChapter},
+		{Name: "序章/楔子/番外/尾声", Pattern: `^[【\[]?(?:序章|序幕|楔子|引子|番外|尾声|尾聲|终章|終章|后记|後記)(?:` + cjkNumber + `)?(?:[】\]].*|` + titleTail + `)`, Kind: models.ChapterRuleChapter},
+	}
+	englishChapterRules = []models.ChapterRule{
+		{Name: "Chapter N", Pattern: `(?i)^chapter(?:\s+\S|\s*[:：]).*$`, Kind: models.ChapterRuleChapter},
+		// Roman numerals are upper case and, like words that start sentences ("Ch. I mean it"), only take a title after punctuation
+		{Name: "Ch. N", Pattern: `(?i)^ch(?:\.\s*|\s+)(?:\d+\b` + titleTail + `|(?-i:[IVXLCDM]+)\b` + punctTail + `)`, Kind: models.ChapterRuleChapter},
+		{Name: "Prologue/Epilogue", Pattern: `(?i)^(?:prologue|epilogue|interlude|preface|foreword|afterword)(?:\s+\d+)?` + punctTail, Kind: models.ChapterRuleChapter},
+	}
+	numberedChapterRules = []models.ChapterRule{
+		{Name: "001.", Pattern: `^\d{1,4}\s*[.．、]\s*(?:\S.{0,30})?$`, Kind: models.ChapterRuleChapter},
+	}
+)
+
+// builtInRuleSets are the presets offered next to user-defined rule sets
+var builtInRuleSets = []models.ChapterRuleSet{
+	{ID: DefaultChapterRuleSet, Name: "Chinese and English (default)", Rules: concatRules(volumeRules, chineseChapterRules, englishChapterRules)},
+	{ID: "numbered", Name: "Default + numbered lines (001.)", Rules: concatRules(volumeRules, chineseChapterRules, englishChapterRules, numberedChapterRules)},
+	{ID: "chinese", Name: "Chinese only", Rules: concatRules(volumeRules[1:], chineseChapterRules)},
+	{ID: "english", Name: "English only", Rules: concatRules(volumeRules[:1], englishChapterRules)},
+}
+
+// defaultTitleMatcher matches with the default preset
+var defaultTitleMatcher = mustTitleMatcher(builtInRuleSets[0].Rules)
+
+// BuiltInChapterRuleSets returns copies of the built-in presets
+func BuiltInChapterRuleSets() []models.ChapterRuleSet {
+	sets := make([]models.ChapterRuleSet, len(builtInRuleSets))
+	for i, set := range builtInRuleSets {
+		set.BuiltIn = true
+		set.Rules = append([]models.ChapterRule(nil), set.Rules...)
+		sets[i] = set
+	}
+	return sets
+}
+
+// ValidateChapterRules checks that every rule has a known kind and a pattern that compiles
+func ValidateChapterRules(rules []models.ChapterRule) error {
+	_, err := newTitleMatcher(rules)
+	return err
+}
+
+func concatRules(groups ...[]models.ChapterRule) []models.ChapterRule {
+	var rules []models.ChapterRule
+	for _, g := range groups {
+		rules = append(rules, g...)
+	}
+	return rules
+}
+
+// lineKind is what a rule set makes of a line
+type lineKind int
+
+const (
+	bodyLine lineKind = iota
+	chapterLine
+	volumeLine
+)
+
+type titleRule struct {
+	pattern *regexp.Regexp
+	kind    lineKind
+}
+
+// titleMatcher applies compiled rules in order
+type titleMatcher struct {
+	rules []titleRule
+}
+
+func newTitleMatcher(rules []models.ChapterRule) (*titleMatcher, error) {
+	m := &titleMatcher{}
+	for i, r := range rules {
+		var kind lineKind
+		switch r.Kind {
+		case models.ChapterRuleChapter:
+			kind = chapterLine
+		case models.ChapterRuleVolume:
+			kind = volumeLine
+		default:
+			return nil, fmt.Errorf("rule %d (%s): unknown kind %q", i+1, r.Name, r.Kind)
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i+1, r.Name, err)
+		}
+		m.rules = append(m.rules, titleRule{pattern: pattern, kind: kind})
+	}
+	return m, nil
+}
+
+func mustTitleMatcher(rules []models.ChapterRule) *titleMatcher {
+	m, err := newTitleMatcher(rules)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// match returns the kind of the first rule matching the trimmed line
+func (m *titleMatcher) match(line string) lineKind {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || len([]rune(trimmed)) > maxTitleLength {
+		return bodyLine
+	}
+	for _, r := range m.rules {
+		if r.pattern.MatchString(trimmed) {
+			return r.kind
+		}
+	}
+	return bodyLine
+}
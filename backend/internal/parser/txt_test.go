@@ -1,7 +1,12 @@
 package parser
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/whitecat/go-reader/internal/models"
 )
 
 func TestIsChapterTitle(t *testing.T) {
@@ -28,12 +33,31 @@ func TestIsChapterTitle(t *testing.T) {
 		{"English chapter with colon", "Chapter: Introduction", true},
 		{"Ch. abbreviation", "Ch. 5", true},
 		{"Ch space", "Ch 10", true},
+		{"Ch. roman numeral", "Ch. IV", true},
+		{"Ch. roman numeral with title", "Ch. XII: The Storm", true},
+		{"Ch. followed by a question", "Ch. Did you see that?", false},
+		{"Ch. followed by I", "Ch. I mean it", false},
+		{"Ch. followed by a roman-looking word", "Ch. Mild weather", false},
+		{"Prologue", "Prologue", true},
+		{"Epilogue with title", "Epilogue: Home Again", true},
+
+		// Other Chinese chapter markers
+		{"Chinese hui", "第十二回 风雪山神庙", true},
+		{"Chinese jie", "第3节", true},
+		{"Bracketed chapter", "【第一章】初入江湖", true},
+		{"Prelude", "序章", true},
+		{"Wedge", "楔子 风起", true},
+		{"Side story", "番外二 旧梦", true},
+		{"Volume is not a chapter", "第一卷 风起", false},
 
 		// Invalid cases
 		{"Empty string", "", false},
 		{"Just spaces", "   ", false},
 		{"Random text", "This is some text", false},
 		{"Contains chapter in middle", "The chapter is here", false},
+		{"Ch. in dialogue", "Ch. that was close", false},
+		{"Word starting with ch", "Chi was here", false},
+		{"Prologue in a sentence", "Prologue is what he called it, and nobody argued.", false},
 	}
 
 	for _, tt := range tests {
@@ -45,3 +69,123 @@ func TestIsChapterTitle(t *testing.T) {
 		})
 	}
 }
+
+func TestTxtParser_ChapterRules(t *testing.T) {
+	text := strings.Join([]string{
+		"001.",
+		"It begins.",
+		"002. The Road",
+		"It goes on.",
+	}, "\n")
+	filePath := writeTestFile(t, "book.txt", []byte(text))
+
+	chapters, err := NewTxtParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1, "numbered lines are not titles by default")
+
+	var numbered []models.ChapterRule
+	for _, set := range BuiltInChapterRuleSets() {
+		if set.ID == "numbered" {
+			numbered = set.Rules
+		}
+	}
+	chapters, err = NewTxtParserWithOptions(Options{ChapterRules: numbered}).Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "001.", chapters[0].Title)
+	assert.Equal(t, "It begins.\n", chapters[0].Content)
+	assert.Equal(t, "002. The Road", chapters[1].Title)
+}
+
+func TestTxtParser_CustomRules(t *testing.T) {
+	text := "PART ONE\n== Arrival ==\nThey came.\n== Departure ==\nThey left.\n"
+	filePath := writeTestFile(t, "book.txt", []byte(text))
+
+	rules := []models.ChapterRule{
+		{Name: "part", Pattern: `^PART \w+$`, Kind: models.ChapterRuleVolume},
+		{Name: "scene", Pattern: `^== .+ ==$`, Kind: models.ChapterRuleChapter},
+	}
+	chapters, err := NewTxtParserWithOptions(Options{ChapterRules: rules}).Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 3)
+	assert.Equal(t, "PART ONE", chapters[0].Title)
+	assert.Equal(t, 0, chapters[0].VolumeChapterNumber)
+	assert.Equal(t, "== Arrival ==", chapters[1].Title)
+	assert.Equal(t, 1, chapters[1].VolumeChapterNumber)
+	assert.Equal(t, "== Departure ==", chapters[2].Title)
+	assert.Equal(t, "They left.\n", chapters[2].Content)
+}
+
+func TestValidateChapterRules(t *testing.T) {
+	for _, set := range BuiltInChapterRuleSets() {
+		assert.NoError(t, ValidateChapterRules(set.Rules), set.ID)
+	}
+	assert.Error(t, ValidateChapterRules([]models.ChapterRule{{Name: "bad", Pattern: `(`, Kind: models.ChapterRuleChapter}}))
+	assert.Error(t, ValidateChapterRules([]models.ChapterRule{{Name: "bad", Pattern: `x`, Kind: "part"}}))
+}
+
+func TestTxtParser_FrontMatter(t *testing.T) {
+	text := strings.Join([]string{
+		"\ufeff书名：斗破苍穹",
+		"作者：天蚕土豆",
+		"",
+		"简介：",
+		"这里是属于斗气的世界。",
+		"没有花俏艳丽的魔法。",
+		"",
+		"第一章 陨落的天才",
+		"“斗之力，三段！”",
+		"第二章 斗气大陆",
+		"月如银盘。",
+	}, "\n")
+	filePath := writeTestFile(t, "book.txt", []byte(text))
+
+	chapters, err := NewTxtParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 3)
+	assert.Equal(t, frontMatterTitle, chapters[0].Title)
+	assert.Equal(t, 1, chapters[0].ChapterNumber)
+	assert.Contains(t, chapters[0].Content, "作者：天蚕土豆")
+	assert.Equal(t, "第一章 陨落的天才", chapters[1].Title)
+	assert.Equal(t, 2, chapters[1].ChapterNumber)
+	assert.Equal(t, "第二章 斗气大陆", chapters[2].Title)
+	assert.Equal(t, "月如银盘。\n", chapters[2].Content)
+
+	meta, err := NewTxtParser().ExtractMetadata(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "斗破苍穹", meta.Title)
+	assert.Equal(t, "天蚕土豆", meta.Author)
+	assert.Equal(t, "这里是属于斗气的世界。\n没有花俏艳丽的魔法。", meta.Description)
+}
+
+func TestTxtParser_NoFrontMatter(t *testing.T) {
+	filePath := writeTestFile(t, "book.txt", []byte("\n\nChapter 1\nOne.\nChapter 2\nTwo.\n"))
+
+	chapters, err := NewTxtParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+	assert.Equal(t, "Chapter 1", chapters[0].Title)
+	assert.Equal(t, 1, chapters[0].ChapterNumber)
+	assert.Equal(t, 1, chapters[0].VolumeChapterNumber)
+
+	_, err = NewTxtParser().ExtractMetadata(filePath)
+	assert.Error(t, err)
+
+	filePath = writeTestFile(t, "notes.txt", []byte("Just a note.\nNothing more.\n"))
+	chapters, err = NewTxtParser().Parse(filePath)
+	require.NoError(t, err)
+	require.Len(t, chapters, 1)
+	assert.Equal(t, "Chapter 1", chapters[0].Title)
+	assert.Equal(t, "Just a note.\nNothing more.\n", chapters[0].Content)
+}
+
+func TestFrontMatterMetadata(t *testing.T) {
+	meta := frontMatterMetadata([]string{"《凡人修仙传》作者：忘语", "", "本书由某网站整理"})
+	assert.Equal(t, "凡人修仙传", meta.Title)
+	assert.Equal(t, "忘语", meta.Author)
+
+	meta = frontMatterMetadata([]string{"The Long Road", "by Jane Doe", "", "Synopsis: A walk.", "It is long."})
+	assert.Equal(t, "The Long Road", meta.Title)
+	assert.Equal(t, "Jane Doe", meta.Author)
+	assert.Equal(t, "A walk.\nIt is long.", meta.Description)
+}
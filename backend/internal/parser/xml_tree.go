@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// xmlNode is an element (or, with an empty Name, a text node) of an XML document.
+// Element and attribute names are local names; namespace prefixes are ignored.
+type xmlNode struct {
+	Name     string
+	Attrs    map[string]string // by local name, so l:href and xlink:href are both "href"
+	Children []*xmlNode
+	Text     string
+}
+
+// parseXMLTree parses an XML document into a tree of xmlNodes, honouring the declared encoding.
+// The returned root has the document element among its children.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{Name: t.Name.Local, Attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				n.Attrs[a.Name.Local] = a.Value
+			}
+			parent.Children = append(parent.Children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.Children = append(parent.Children, &xmlNode{Text: string(t)})
+		}
+	}
+
+	return root, nil
+}
+
+func (n *xmlNode) child(name string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) children(name string) []*xmlNode {
+	if n == nil {
+		return nil
+	}
+	var result []*xmlNode
+	for _, c := range n.Children {
+		if c.Name == name {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (n *xmlNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.Attrs[name]
+}
+
+// text returns the concatenated text of the node and its descendants
+func (n *xmlNode) text() string {
+	if n == nil {
+		return ""
+	}
+	if n.Name == "" {
+		return n.Text
+	}
+	var sb strings.Builder
+	for _, c := range n.Children {
+		sb.WriteString(c.text())
+	}
+	return sb.String()
+}
@@ -21,8 +21,10 @@ func NewBookRepository(db *sqlx.DB) *BookRepository {
 // Create creates a new book in the database
 func (r *BookRepository) Create(book *models.Book) error {
 	query := `
-		INSERT INTO books (id, title, author, description, cover_path, file_path, file_format, file_size, created_at, updated_at)
-		VALUES (:id, :title, :author, :description, :cover_path, :file_path, :file_format, :file_size, :created_at, :updated_at)
+		INSERT INTO books (id, title, author, description, cover_path, file_path, file_format, file_size,
+		                   language, publisher, published_date, isbn, identifier, series, series_index, created_at, updated_at)
+		VALUES (:id, :title, :author, :description, :cover_path, :file_path, :file_format, :file_size,
+		        :language, :publisher, :published_date, :isbn, :identifier, :series, :series_index, :created_at, :updated_at)
 	`
 	_, err := r.db.NamedExec(query, book)
 	if err != nil {
@@ -61,7 +63,9 @@ func (r *BookRepository) Update(book *models.Book) error {
 	query := `
 		UPDATE books
 		SET title = :title, author = :author, description = :description,
-		    cover_path = :cover_path, updated_at = :updated_at
+		    cover_path = :cover_path, language = :language, publisher = :publisher,
+		    published_date = :published_date, isbn = :isbn, series = :series,
+		    series_index = :series_index, updated_at = :updated_at
 		WHERE id = :id
 	`
 	result, err := r.db.NamedExec(query, book)
@@ -99,6 +103,16 @@ func (r *BookRepository) Delete(id string) error {
 	return nil
 }
 
+// CountByCoverPath returns how many books use the given cover path
+func (r *BookRepository) CountByCoverPath(coverPath string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM books WHERE cover_path = ?`
+	if err := r.db.Get(&count, query, coverPath); err != nil {
+		return 0, fmt.Errorf("failed to count books by cover: %w", err)
+	}
+	return count, nil
+}
+
 // GetBooksByTag retrieves books by tag ID
 func (r *BookRepository) GetBooksByTag(tagID string) ([]models.Book, error) {
 	var books []models.Book
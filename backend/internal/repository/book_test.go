@@ -26,6 +26,8 @@ func TestBookRepository_Create(t *testing.T) {
 		FilePath:   "/test.txt",
 		FileFormat: "txt",
 		FileSize:   12345,
+		Language:   "en",
+		ISBN:       "9780000000002",
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
@@ -37,6 +39,8 @@ func TestBookRepository_Create(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, createdBook)
 	assert.Equal(t, book.Title, createdBook.Title)
+	assert.Equal(t, "en", createdBook.Language)
+	assert.Equal(t, "9780000000002", createdBook.ISBN)
 }
 
 func TestBookRepository_GetByID(t *testing.T) {
@@ -113,6 +117,8 @@ func TestBookRepository_Update(t *testing.T) {
 
 	book.Title = "Updated Title"
 	book.Author = "Updated Author"
+	book.Series = "Updated Series"
+	book.SeriesIndex = 2.5
 	book.UpdatedAt = time.Now()
 
 	err = repo.Update(book)
@@ -122,6 +128,8 @@ func TestBookRepository_Update(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Title", updatedBook.Title)
 	assert.Equal(t, "Updated Author", updatedBook.Author)
+	assert.Equal(t, "Updated Series", updatedBook.Series)
+	assert.Equal(t, 2.5, updatedBook.SeriesIndex)
 }
 
 func TestBookRepository_Delete(t *testing.T) {
@@ -183,3 +191,26 @@ func TestBookRepository_TagManagement(t *testing.T) {
 	assert.Len(t, tags, 1)
 	assert.Equal(t, tag2.Name, tags[0].Name)
 }
+
+func TestBookRepository_CountByCoverPath(t *testing.T) {
+	repo := setupTestDB(t)
+
+	coverPath := "/covers/shared.jpg"
+	book1 := &models.Book{ID: uuid.NewString(), Title: "Book 1", CoverPath: coverPath, FilePath: "/1.txt", FileFormat: "txt"}
+	book2 := &models.Book{ID: uuid.NewString(), Title: "Book 2", CoverPath: coverPath, FilePath: "/2.txt", FileFormat: "txt"}
+	assert.NoError(t, repo.Create(book1))
+	assert.NoError(t, repo.Create(book2))
+
+	count, err := repo.CountByCoverPath(coverPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, repo.Delete(book1.ID))
+	count, err = repo.CountByCoverPath(coverPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = repo.CountByCoverPath("/covers/unused.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -21,8 +21,8 @@ func NewChapterRepository(db *sqlx.DB) *ChapterRepository {
 // Create creates a new chapter in the database
 func (r *ChapterRepository) Create(chapter *models.Chapter) error {
 	query := `
-		INSERT INTO chapters (id, book_id, chapter_number, volume_number, volume_chapter_number, title, content, word_count, created_at)
-		VALUES (:id, :book_id, :chapter_number, :volume_number, :volume_chapter_number, :title, :content, :word_count, :created_at)
+		INSERT INTO chapters (id, book_id, chapter_number, volume_number, volume_chapter_number, title, content, content_html, footnotes, word_count, created_at)
+		VALUES (:id, :book_id, :chapter_number, :volume_number, :volume_chapter_number, :title, :content, :content_html, :footnotes, :word_count, :created_at)
 	`
 	_, err := r.db.NamedExec(query, chapter)
 	if err != nil {
@@ -94,8 +94,8 @@ func (r *ChapterRepository) BatchCreate(chapters []models.Chapter) error {
 	defer tx.Rollback()
 
 	query := `
-		INSERT INTO chapters (id, book_id, chapter_number, volume_number, volume_chapter_number, title, content, word_count, created_at)
-		VALUES (:id, :book_id, :chapter_number, :volume_number, :volume_chapter_number, :title, :content, :word_count, :created_at)
+		INSERT INTO chapters (id, book_id, chapter_number, volume_number, volume_chapter_number, title, content, content_html, footnotes, word_count, created_at)
+		VALUES (:id, :book_id, :chapter_number, :volume_number, :volume_chapter_number, :title, :content, :content_html, :footnotes, :word_count, :created_at)
 	`
 
 	for _, chapter := range chapters {
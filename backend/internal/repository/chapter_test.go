@@ -38,6 +38,8 @@ func TestChapterRepository_Create(t *testing.T) {
 		ChapterNumber: 1,
 		Title:         "Chapter 1",
 		Content:       "This is the content.",
+		ContentHTML:   "<p>This is the <em>content</em>.</p>",
+		Footnotes:     models.Footnotes{{ID: "fn1", Label: "1", Content: "A note."}},
 		WordCount:     4,
 		CreatedAt:     time.Now(),
 	}
@@ -49,6 +51,8 @@ func TestChapterRepository_Create(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, createdChapter)
 	assert.Equal(t, chapter.Title, createdChapter.Title)
+	assert.Equal(t, chapter.ContentHTML, createdChapter.ContentHTML)
+	assert.Equal(t, chapter.Footnotes, createdChapter.Footnotes)
 }
 
 func TestChapterRepository_GetByID(t *testing.T) {
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+// ErrSettingNotFound is returned by Get for a key that has never been set
+var ErrSettingNotFound = errors.New("setting not found")
+
+// SettingsRepository handles database operations for key/value application settings
+type SettingsRepository struct {
+	db *sqlx.DB
+}
+
+// NewSettingsRepository creates a new SettingsRepository
+func NewSettingsRepository(db *sqlx.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// Get retrieves a setting by its key
+func (r *SettingsRepository) Get(key string) (*models.Settings, error) {
+	var setting models.Settings
+	query := `SELECT * FROM settings WHERE key = ?`
+	err := r.db.Get(&setting, query, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSettingNotFound
+		}
+		return nil, fmt.Errorf("failed to get setting: %w", err)
+	}
+	return &setting, nil
+}
+
+// Set creates or updates a setting
+func (r *SettingsRepository) Set(setting *models.Settings) error {
+	query := `
+		INSERT INTO settings (key, value, updated_at)
+		VALUES (:key, :value, :updated_at)
+		ON CONFLICT(key) DO UPDATE SET
+			value = :value,
+			updated_at = :updated_at
+	`
+	_, err := r.db.NamedExec(query, setting)
+	if err != nil {
+		return fmt.Errorf("failed to set setting: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a setting
+func (r *SettingsRepository) Delete(key string) error {
+	query := `DELETE FROM settings WHERE key = ?`
+	_, err := r.db.Exec(query, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete setting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/whitecat/go-reader/internal/config"
+	"github.com/whitecat/go-reader/internal/models"
+)
+
+func setupSettingsTestDB(t *testing.T) *SettingsRepository {
+	db := config.NewTestDatabase(t)
+	return NewSettingsRepository(db)
+}
+
+func TestSettingsRepository_SetAndGet(t *testing.T) {
+	repo := setupSettingsTestDB(t)
+
+	_, err := repo.Get("theme")
+	assert.ErrorIs(t, err, ErrSettingNotFound)
+
+	err = repo.Set(&models.Settings{Key: "theme", Value: "dark", UpdatedAt: time.Now()})
+	assert.NoError(t, err)
+
+	setting, err := repo.Get("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", setting.Value)
+
+	err = repo.Set(&models.Settings{Key: "theme", Value: "light", UpdatedAt: time.Now()})
+	assert.NoError(t, err)
+
+	setting, err = repo.Get("theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "light", setting.Value)
+}
+
+func TestSettingsRepository_Delete(t *testing.T) {
+	repo := setupSettingsTestDB(t)
+
+	err := repo.Set(&models.Settings{Key: "theme", Value: "dark", UpdatedAt: time.Now()})
+	assert.NoError(t, err)
+
+	err = repo.Delete("theme")
+	assert.NoError(t, err)
+
+	_, err = repo.Get("theme")
+	assert.ErrorIs(t, err, ErrSettingNotFound)
+}
@@ -1,11 +1,19 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/whitecat/go-reader/internal/cover"
 	"github.com/whitecat/go-reader/internal/models"
 	"github.com/whitecat/go-reader/internal/parser"
 	"github.com/whitecat/go-reader/internal/repository"
@@ -16,6 +24,11 @@ type BookService struct {
 	bookRepo    *repository.BookRepository
 	chapterRepo *repository.ChapterRepository
 	tagRepo     *repository.TagRepository
+	covers      *cover.Store
+	settings    *SettingsService
+
+	mu   sync.Mutex
+	jobs map[string]*ImportJob
 }
 
 // NewBookService creates a new BookService
@@ -23,20 +36,89 @@ func NewBookService(
 	bookRepo *repository.BookRepository,
 	chapterRepo *repository.ChapterRepository,
 	tagRepo *repository.TagRepository,
+	covers *cover.Store,
 ) *BookService {
 	return &BookService{
 		bookRepo:    bookRepo,
 		chapterRepo: chapterRepo,
 		tagRepo:     tagRepo,
+		covers:      covers,
+		jobs:        make(map[string]*ImportJob),
+	}
+}
+
+// NewBookServiceWithSettings creates a BookService that splits TXT imports with the chapter rules from settings
+func NewBookServiceWithSettings(
+	bookRepo *repository.BookRepository,
+	chapterRepo *repository.ChapterRepository,
+	tagRepo *repository.TagRepository,
+	covers *cover.Store,
+	settings *SettingsService,
+) *BookService {
+	s := NewBookService(bookRepo, chapterRepo, tagRepo, covers)
+	s.settings = settings
+	return s
+}
+
+// importBatchSize is how many chapters are inserted per transaction while importing
+const importBatchSize = 100
+
+// errStoreChapters marks import failures caused by the database rather than the file
+var errStoreChapters = errors.New("failed to create chapters")
+
+// ErrFileNotFound is returned when the file to import or preview cannot be read
+var ErrFileNotFound = errors.New("file not found")
+
+// ErrUnsupportedFormat is returned for a file whose format is neither known nor detectable from its content
+var ErrUnsupportedFormat = errors.New("unsupported file format")
+
+// errNoChapters is the reason reported for a book whose file parsed without any chapters
+var errNoChapters = errors.New("no chapters found in file")
+
+// PartialImportError is returned along with the book when parsing failed after some
+// chapters were already stored, or when the book was kept without any chapters
+type PartialImportError struct {
+	Chapters int
+	Err      error
+}
+
+func (e *PartialImportError) Error() string {
+	if e.Chapters == 0 {
+		return fmt.Sprintf("no chapters imported: %v", e.Err)
 	}
+	return fmt.Sprintf("import stopped after %d chapters: %v", e.Chapters, e.Err)
 }
 
-// CreateBook creates a new book and parses its content
+func (e *PartialImportError) Unwrap() error {
+	return e.Err
+}
+
+// ImportProgressFunc receives the progress of a local import: how much of the file has been
+// parsed (see parser.Progress) and how many chapters have been produced so far
+type ImportProgressFunc func(progress parser.Progress, chapters int)
+
+// CreateBook creates a new book and parses its content.
+// A book that could only be imported in part is returned with a warning and no error.
 func (s *BookService) CreateBook(req *models.CreateBookRequest) (*models.Book, error) {
+	book, err := s.ImportBook(context.Background(), req, nil)
+	var partial *PartialImportError
+	if errors.As(err, &partial) {
+		return book, nil
+	}
+	return book, err
+}
+
+// ImportBook creates a new book and streams its chapters into the database in batches,
+// reporting progress to onProgress if it is not nil. Cancelling ctx stops the import and
+// removes the book again, as does a parse that fails before the first chapter. If parsing
+// fails part way, the chapters read so far are kept: the book is returned with a warning
+// and a *PartialImportError. A book kept without chapters, because no parser handles its
+// format or its file has none, is reported the same way.
+func (s *BookService) ImportBook(ctx context.Context, req *models.CreateBookRequest, onProgress ImportProgressFunc) (*models.Book, error) {
 	// Validate file exists
 	fileInfo, err := os.Stat(req.FilePath)
 	if err != nil {
-		return nil, fmt.Errorf("file not found: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrFileNotFound, err)
 	}
 
 	// Create book entity
@@ -46,48 +128,275 @@ func (s *BookService) CreateBook(req *models.CreateBookRequest) (*models.Book, e
 		Author:      req.Author,
 		Description: req.Description,
 		FilePath:    req.FilePath,
-		FileFormat:  req.FileFormat,
 		FileSize:    fileInfo.Size(),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	book.FileFormat, book.Warnings, err = resolveFormat(req.FilePath, req.FileFormat)
+	if err != nil {
+		return nil, err
+	}
 
-	// Save book to database
+	// Pick the parser up front so embedded metadata can be stored with the book
+	var subjects []string
+	opts, err := s.importOptions(book, req.ChapterRuleSet, req.SplitChapterWords, req.MarkdownHeadings)
+	if err != nil {
+		return nil, err
+	}
+	p, parserErr := parser.GetParserWithOptions(book.FileFormat, opts)
+	if parserErr == nil {
+		subjects = s.applyMetadata(book, p, req.FilePath)
+		book.CoverPath = s.extractCover(p, req.FilePath)
+	}
+	if book.Title == "" {
+		book.Title = strings.TrimSuffix(filepath.Base(req.FilePath), filepath.Ext(req.FilePath))
+	}
+	if book.CoverPath == "" {
+		book.CoverPath = s.placeholderCover(book.Title, book.Author, req.TagIDs)
+	}
+
+	// Save book to database; chapters reference it
 	if err := s.bookRepo.Create(book); err != nil {
 		return nil, fmt.Errorf("failed to create book: %w", err)
 	}
 
-	// Parse book content into chapters
-	p, err := parser.GetParser(req.FileFormat)
+	// If the parser is not available, still return the book
+	if parserErr != nil {
+		s.tagBook(book.ID, req.TagIDs, subjects)
+		partial := &PartialImportError{Err: parserErr}
+		book.Warnings = append(book.Warnings, partial.Error())
+		return book, partial
+	}
+
+	stored, err := s.importChapters(ctx, p, book, onProgress)
+	if err != nil && (stored == 0 || errors.Is(err, errStoreChapters) || ctx.Err() != nil) {
+		// Importing would only produce an empty or incomplete book
+		if delErr := s.DeleteBook(book.ID); delErr != nil {
+			logrus.Warnf("remove book %s after failed import: %v", book.ID, delErr)
+		}
+		if errors.Is(err, errStoreChapters) || ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to parse book: %w", err)
+	}
+	var partial error
+	switch {
+	case err != nil:
+		logrus.Warnf("parse %s failed: %v", req.FilePath, err)
+		partial = &PartialImportError{Chapters: stored, Err: err}
+		book.Warnings = append(book.Warnings, partial.Error())
+	case stored == 0:
+		// A parser may find nothing to import without failing; the book is kept and tagged all the same
+		partial = &PartialImportError{Err: errNoChapters}
+		book.Warnings = append(book.Warnings, partial.Error())
+	}
+
+	s.tagBook(book.ID, req.TagIDs, subjects)
+	return book, partial
+}
+
+// tagBook adds the requested tags to a book, or tags it with its embedded subjects when none were requested
+func (s *BookService) tagBook(bookID string, tagIDs, subjects []string) {
+	if len(tagIDs) == 0 {
+		s.tagSubjects(bookID, subjects)
+		return
+	}
+	for _, tagID := range tagIDs {
+		s.bookRepo.AddTag(bookID, tagID)
+	}
+}
+
+// importChapters parses a book's file and inserts its chapters in batches of importBatchSize.
+// It returns how many chapters were stored; after a parse error the chapters before it are kept.
+func (s *BookService) importChapters(ctx context.Context, p parser.Parser, book *models.Book, onProgress ImportProgressFunc) (int, error) {
+	var batch []models.Chapter
+	stored := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.chapterRepo.BatchCreate(batch); err != nil {
+			return fmt.Errorf("%w: %v", errStoreChapters, err)
+		}
+		stored += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := parser.ParseFile(ctx, p, book.FilePath, func(ch models.Chapter, progress parser.Progress) error {
+		ch.BookID = book.ID
+		ch.CreatedAt = time.Now()
+		batch = append(batch, ch)
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if onProgress != nil {
+			onProgress(progress, stored+len(batch))
+		}
+		return nil
+	})
+	if err != nil && (errors.Is(err, errStoreChapters) || ctx.Err() != nil) {
+		return stored, err
+	}
+	if flushErr := flush(); flushErr != nil {
+		return stored, flushErr
+	}
+	return stored, err
+}
+
+// resolveFormat picks the format to parse a file with. The client's file_format (usually derived
+// from the extension) is only trusted when the content agrees; otherwise the detected format wins
+// and a warning is returned. A file whose format is neither known nor detectable is an error.
+func resolveFormat(filePath, claimed string) (string, []string, error) {
+	claimed = strings.ToLower(strings.TrimSpace(claimed))
+	if claimed == "" {
+		claimed = strings.ToLower(strings.TrimPrefix(filepath.Ext(filePath), "."))
+	}
+
+	detected, err := parser.DetectFormat(filePath)
 	if err != nil {
-		// If parser not available, still create the book
-		return book, nil
+		logrus.Warnf("detect format of %s failed: %v", filePath, err)
+	}
+	switch {
+	case detected == "":
+		if _, err := parser.GetParser(claimed); err != nil {
+			return "", nil, fmt.Errorf("%w %q: the content matches no known format", ErrUnsupportedFormat, claimed)
+		}
+		return claimed, nil, nil
+	case parser.SameFormat(claimed, detected):
+		return claimed, nil, nil
+	case claimed == "":
+		return detected, nil, nil
+	}
+
+	if _, err := parser.GetParser(claimed); err != nil {
+		// An unknown extension is not worth a warning
+		return detected, nil, nil
 	}
+	return detected, []string{fmt.Sprintf("file_format %q does not match the file content; imported as %s", claimed, detected)}, nil
+}
 
-	chapters, err := p.Parse(req.FilePath)
+// importOptions returns the parser options for importing book, with the chapter rules of ruleSet for TXT files
+// and the part size oversized chapters are split into
+func (s *BookService) importOptions(book *models.Book, ruleSet string, splitWords int, markdownHeadings string) (parser.Options, error) {
+	opts := bookParserOptions(book)
+	opts.SplitChapterWords = splitWords
+	switch markdownHeadings {
+	case parser.MarkdownHeadingsAuto, parser.MarkdownHeadingsVolumes, parser.MarkdownHeadingsChapters:
+		opts.MarkdownHeadings = markdownHeadings
+	default:
+		return opts, fmt.Errorf("unknown markdown_headings %q", markdownHeadings)
+	}
+	if s.settings != nil && (book.FileFormat == "txt" || ruleSet != "") {
+		rules, err := s.settings.ChapterRules(ruleSet)
+		if err != nil {
+			return opts, err
+		}
+		opts.ChapterRules = rules
+	}
+	return opts, nil
+}
+
+// applyMetadata fills fields the request left empty from the file's embedded metadata
+// and returns the file's subjects
+func (s *BookService) applyMetadata(book *models.Book, p parser.Parser, filePath string) []string {
+	extractor, ok := p.(parser.MetadataExtractor)
+	if !ok {
+		return nil
+	}
+
+	meta, err := extractor.ExtractMetadata(filePath)
 	if err != nil {
-		// If parsing fails, still return the book
-		return book, nil
+		logrus.Debugf("metadata: extraction failed for %s: %v", filePath, err)
+		return nil
 	}
 
-	// Save chapters
-	for i := range chapters {
-		chapters[i].BookID = book.ID
-		chapters[i].CreatedAt = time.Now()
+	fill := func(dst *string, value string) {
+		if *dst == "" {
+			*dst = value
+		}
+	}
+	fill(&book.Title, meta.Title)
+	fill(&book.Author, meta.Author)
+	fill(&book.Description, meta.Description)
+	fill(&book.Language, meta.Language)
+	fill(&book.Publisher, meta.Publisher)
+	fill(&book.PublishedDate, meta.PublishedDate)
+	fill(&book.ISBN, meta.ISBN)
+	fill(&book.Identifier, meta.Identifier)
+	fill(&book.Series, meta.Series)
+	if book.SeriesIndex == 0 {
+		book.SeriesIndex = meta.SeriesIndex
 	}
 
-	if err := s.chapterRepo.BatchCreate(chapters); err != nil {
-		return nil, fmt.Errorf("failed to create chapters: %w", err)
+	return meta.Subjects
+}
+
+// tagSubjects adds subject tags to a book, creating tags that do not exist yet
+func (s *BookService) tagSubjects(bookID string, subjects []string) {
+	for _, subject := range subjects {
+		tag, err := s.tagRepo.GetByName(subject)
+		if err != nil {
+			tag = &models.Tag{
+				ID:        uuid.New().String(),
+				Name:      subject,
+				CreatedAt: time.Now(),
+			}
+			if err := s.tagRepo.Create(tag); err != nil {
+				logrus.Warnf("metadata: create tag %q failed: %v", subject, err)
+				continue
+			}
+		}
+		s.bookRepo.AddTag(bookID, tag.ID)
 	}
+}
 
-	// Add tags if provided
-	if len(req.TagIDs) > 0 {
-		for _, tagID := range req.TagIDs {
-			s.bookRepo.AddTag(book.ID, tagID)
+// extractCover stores the cover embedded in a book file and returns its public path.
+// Returns empty string if the format has no embedded cover or extraction fails.
+func (s *BookService) extractCover(p parser.Parser, filePath string) string {
+	extractor, ok := p.(parser.CoverExtractor)
+	if !ok || s.covers == nil {
+		return ""
+	}
+
+	data, err := extractor.ExtractCover(filePath)
+	if err != nil {
+		logrus.Debugf("cover: no embedded cover in %s: %v", filePath, err)
+		return ""
+	}
+
+	coverPath, err := s.covers.Save(data)
+	if err != nil {
+		logrus.Warnf("cover: save embedded cover failed file=%s err=%v", filePath, err)
+		return ""
+	}
+	return coverPath
+}
+
+// placeholderCover generates a cover for books without one.
+// The first tag with a color decides the background; otherwise a color is derived from the title.
+func (s *BookService) placeholderCover(title, author string, tagIDs []string) string {
+	if s.covers == nil {
+		return ""
+	}
+
+	color := ""
+	for _, tagID := range tagIDs {
+		tag, err := s.tagRepo.GetByID(tagID)
+		if err == nil && tag.Color != "" {
+			color = tag.Color
+			break
 		}
 	}
 
-	return book, nil
+	coverPath, err := s.covers.SavePlaceholder(title, author, color)
+	if err != nil {
+		logrus.Warnf("cover: placeholder failed title=%s err=%v", title, err)
+		return ""
+	}
+	return coverPath
 }
 
 // CreateRemoteBook creates a book using scraped chapters (no local file needed).
@@ -103,6 +412,7 @@ func (s *BookService) CreateRemoteBook(req *models.CreateRemoteBookRequest, chap
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	book.CoverPath = s.placeholderCover(book.Title, book.Author, nil)
 
 	if err := s.bookRepo.Create(book); err != nil {
 		return nil, fmt.Errorf("failed to create book: %w", err)
@@ -179,6 +489,24 @@ func (s *BookService) UpdateBook(id string, req *models.UpdateBookRequest) (*mod
 	if req.CoverPath != nil {
 		book.CoverPath = *req.CoverPath
 	}
+	if req.Language != nil {
+		book.Language = *req.Language
+	}
+	if req.Publisher != nil {
+		book.Publisher = *req.Publisher
+	}
+	if req.PublishedDate != nil {
+		book.PublishedDate = *req.PublishedDate
+	}
+	if req.ISBN != nil {
+		book.ISBN = *req.ISBN
+	}
+	if req.Series != nil {
+		book.Series = *req.Series
+	}
+	if req.SeriesIndex != nil {
+		book.SeriesIndex = *req.SeriesIndex
+	}
 
 	book.UpdatedAt = time.Now()
 
@@ -223,11 +551,35 @@ func (s *BookService) DeleteBook(id string) error {
 	// Optionally delete the book file (commented out for safety)
 	// os.Remove(book.FilePath)
 
-	_ = book // Use book to avoid unused variable warning
+	s.removeOrphanedCover(book.CoverPath)
 
 	return nil
 }
 
+// removeOrphanedCover deletes a stored cover once no book references it anymore.
+// Covers are deduplicated by content, so several books may share the same file.
+func (s *BookService) removeOrphanedCover(coverPath string) {
+	if s.covers == nil {
+		return
+	}
+	if _, ok := cover.IDFromPath(coverPath); !ok {
+		return
+	}
+
+	count, err := s.bookRepo.CountByCoverPath(coverPath)
+	if err != nil {
+		logrus.Warnf("cover: reference check failed path=%s err=%v", coverPath, err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	if err := s.covers.Remove(coverPath); err != nil {
+		logrus.Warnf("cover: remove failed path=%s err=%v", coverPath, err)
+	}
+}
+
 // GetBookContent retrieves the full content of a book
 func (s *BookService) GetBookContent(id string) ([]models.Chapter, error) {
 	book, err := s.bookRepo.GetByID(id)
@@ -239,7 +591,7 @@ func (s *BookService) GetBookContent(id string) ([]models.Chapter, error) {
 	chapters, err := s.chapterRepo.GetByBookID(book.ID)
 	if err != nil || len(chapters) == 0 {
 		// If no chapters in database, parse the file
-		p, err := parser.GetParser(book.FileFormat)
+		p, err := bookParser(book)
 		if err != nil {
 			return nil, fmt.Errorf("parser not available: %w", err)
 		}
@@ -271,6 +623,53 @@ func (s *BookService) GetBookContent(id string) ([]models.Chapter, error) {
 	return fullChapters, nil
 }
 
+// OpenBookResource opens an image or other file bundled inside a book (e.g. EPUB illustrations)
+func (s *BookService) OpenBookResource(id, name string) (io.ReadCloser, error) {
+	book, err := s.bookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := bookParser(book)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := p.(parser.ResourceProvider)
+	if !ok {
+		return nil, fmt.Errorf("resources not supported for format: %s", book.FileFormat)
+	}
+	return provider.OpenResource(book.FilePath, name)
+}
+
+// OpenBookPage opens a page image of an image-based book (e.g. a CBZ comic); page is 1-based
+func (s *BookService) OpenBookPage(id string, page int) (io.ReadCloser, error) {
+	book, err := s.bookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := bookParser(book)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := p.(parser.PageProvider)
+	if !ok {
+		return nil, fmt.Errorf("pages not supported for format: %s", book.FileFormat)
+	}
+	return provider.OpenPage(book.FilePath, page)
+}
+
+// bookParser returns the parser for a book's format, with chapter images pointing at the book's resource endpoint
+func bookParser(book *models.Book) (parser.Parser, error) {
+	return parser.GetParserWithOptions(book.FileFormat, bookParserOptions(book))
+}
+
+func bookParserOptions(book *models.Book) parser.Options {
+	return parser.Options{
+		ResourceBaseURL: "/api/books/" + book.ID + "/resources/",
+	}
+}
+
 // GetBooksByTag retrieves books by tag
 func (s *BookService) GetBooksByTag(tagID string) ([]models.Book, error) {
 	return s.bookRepo.GetBooksByTag(tagID)
@@ -281,7 +680,70 @@ func (s *BookService) GetBookChapters(id string) ([]models.ChapterSummary, error
 	return s.chapterRepo.GetByBookID(id)
 }
 
+// GetNumberingReport checks the numbers a book's chapter titles declare for gaps, repeats and
+// chapters out of order. It works from the stored chapters, so it covers scraped books too.
+func (s *BookService) GetNumberingReport(id string) (*models.NumberingReport, error) {
+	book, err := s.bookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters, err := s.chapterRepo.GetByBookID(book.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	report := parser.CheckNumbering(chapters)
+	report.BookID = book.ID
+	return report, nil
+}
+
 // GetChapter retrieves a specific chapter with full content
 func (s *BookService) GetChapter(bookID string, chapterNumber int) (*models.Chapter, error) {
 	return s.chapterRepo.GetByNumber(bookID, chapterNumber)
 }
+
+// Chapter formats for GetChapterAs
+const (
+	ChapterFormatHTML = "html"
+	ChapterFormatText = "text"
+)
+
+// GetChapterAs retrieves a chapter with only its HTML (ChapterFormatHTML) or only its plain
+// text (ChapterFormatText); an empty format returns both. Markdown chapters imported before
+// they were rendered on import are rendered from their source.
+func (s *BookService) GetChapterAs(bookID string, chapterNumber int, format string) (*models.Chapter, error) {
+	switch format {
+	case "", ChapterFormatHTML, ChapterFormatText:
+	default:
+		return nil, fmt.Errorf("unknown chapter format %q", format)
+	}
+
+	chapter, err := s.chapterRepo.GetByNumber(bookID, chapterNumber)
+	if err != nil {
+		return nil, err
+	}
+	if chapter.ContentHTML == "" && chapter.Content != "" {
+		book, err := s.bookRepo.GetByID(bookID)
+		if err != nil {
+			return nil, err
+		}
+		if book.FileFormat == "md" {
+			chapter.ContentHTML, chapter.Content, chapter.Footnotes = parser.RenderMarkdown(chapter.Content, bookParserOptions(book).ResourceBaseURL)
+		}
+	}
+
+	switch format {
+	case ChapterFormatHTML:
+		if chapter.ContentHTML == "" {
+			chapter.ContentHTML = parser.TextToHTML(chapter.Content)
+		}
+		chapter.Content = ""
+	case ChapterFormatText:
+		chapter.ContentHTML = ""
+		for i := range chapter.Footnotes {
+			chapter.Footnotes[i].ContentHTML = ""
+		}
+	}
+	return chapter, nil
+}
@@ -5,17 +5,16 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"github.com/whitecat/go-reader/internal/cover"
 	"github.com/whitecat/go-reader/internal/models"
 	"github.com/whitecat/go-reader/internal/repository"
 	"github.com/whitecat/go-reader/internal/scraper"
+	"github.com/whitecat/go-reader/internal/textstat"
 )
 
 // CrawlerService wraps the custom scraper and persists results to DB.
@@ -23,7 +22,7 @@ type CrawlerService struct {
 	bookRepo    *repository.BookRepository
 	chapterRepo *repository.ChapterRepository
 
-	coversDir string
+	covers *cover.Store
 
 	mu   sync.Mutex
 	jobs map[string]*CrawlerJob
@@ -52,7 +51,7 @@ func NewCrawlerService(bookRepo *repository.BookRepository, chapterRepo *reposit
 		bookRepo:    bookRepo,
 		chapterRepo: chapterRepo,
 		jobs:        make(map[string]*CrawlerJob),
-		coversDir:   "./data/covers",
+		covers:      cover.NewStore("./data/covers"),
 	}
 }
 
@@ -60,7 +59,16 @@ func NewCrawlerService(bookRepo *repository.BookRepository, chapterRepo *reposit
 func NewCrawlerServiceWithCoverDir(bookRepo *repository.BookRepository, chapterRepo *repository.ChapterRepository, coversDir string) *CrawlerService {
 	s := NewCrawlerService(bookRepo, chapterRepo)
 	if coversDir != "" {
-		s.coversDir = coversDir
+		s.covers = cover.NewStore(coversDir)
+	}
+	return s
+}
+
+// NewCrawlerServiceWithCovers allows sharing a cover store with other services.
+func NewCrawlerServiceWithCovers(bookRepo *repository.BookRepository, chapterRepo *repository.ChapterRepository, covers *cover.Store) *CrawlerService {
+	s := NewCrawlerService(bookRepo, chapterRepo)
+	if covers != nil {
+		s.covers = covers
 	}
 	return s
 }
@@ -90,7 +98,7 @@ func (s *CrawlerService) Import(novel NovelInput) (*models.Book, error) {
 			VolumeChapterNumber: i + 1,
 			Title:               info.Title,
 			Content:             contents[i],
-			WordCount:           len([]rune(contents[i])),
+			WordCount:           textstat.WordCount(contents[i]),
 			CreatedAt:           now,
 		})
 	}
@@ -100,7 +108,7 @@ func (s *CrawlerService) Import(novel NovelInput) (*models.Book, error) {
 		Title:       novel.Title,
 		Author:      novel.Author,
 		Description: novel.Latest,
-		CoverPath:   s.downloadCover(coverURL),
+		CoverPath:   s.resolveCover(coverURL, novel.Title, novel.Author),
 		FilePath:    novel.URL,
 		FileFormat:  "web",
 		FileSize:    0,
@@ -189,7 +197,7 @@ func (s *CrawlerService) importWithProgress(novel NovelInput, jobID string) (*mo
 			VolumeChapterNumber: i + 1,
 			Title:               info.Title,
 			Content:             contents[i],
-			WordCount:           len([]rune(contents[i])),
+			WordCount:           textstat.WordCount(contents[i]),
 			CreatedAt:           now,
 		})
 	}
@@ -199,7 +207,7 @@ func (s *CrawlerService) importWithProgress(novel NovelInput, jobID string) (*mo
 		Title:       novel.Title,
 		Author:      novel.Author,
 		Description: novel.Latest,
-		CoverPath:   s.downloadCover(coverURL),
+		CoverPath:   s.resolveCover(coverURL, novel.Title, novel.Author),
 		FilePath:    novel.URL,
 		FileFormat:  "web",
 		FileSize:    0,
@@ -240,7 +248,21 @@ func (s *CrawlerService) GetJob(id string) (*CrawlerJob, error) {
 	return job, nil
 }
 
-// downloadCover saves the cover locally and returns a path accessible by frontend ("/covers/xxx").
+// resolveCover downloads the site's cover, or generates a placeholder when the site has none
+// or the download fails.
+func (s *CrawlerService) resolveCover(coverURL, title, author string) string {
+	if path := s.downloadCover(coverURL); path != "" {
+		return path
+	}
+	path, err := s.covers.SavePlaceholder(title, author, "")
+	if err != nil {
+		logrus.Warnf("cover: placeholder failed title=%s err=%v", title, err)
+		return ""
+	}
+	return path
+}
+
+// downloadCover saves the cover in the cover store and returns a path accessible by frontend ("/covers/xxx").
 // On failure, returns empty string to let frontend fall back to default cover.
 func (s *CrawlerService) downloadCover(coverURL string) string {
 	if coverURL == "" {
@@ -285,27 +307,11 @@ func (s *CrawlerService) downloadCover(coverURL string) string {
 		return fallback()
 	}
 
-	ext := ".jpg"
-	ct := resp.Header.Get("Content-Type")
-	if strings.Contains(ct, "png") {
-		ext = ".png"
-	} else if strings.Contains(ct, "jpeg") || strings.Contains(ct, "jpg") {
-		ext = ".jpg"
-	}
-
-	filename := uuid.New().String() + ext
-	if s.coversDir == "" {
-		s.coversDir = "./data/covers"
-	}
-	if err := os.MkdirAll(s.coversDir, 0755); err != nil {
-		logrus.Warnf("cover: mkdir failed dir=%s err=%v", s.coversDir, err)
-		return fallback()
-	}
-	path := filepath.Join(s.coversDir, filename)
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		logrus.Warnf("cover: write failed path=%s err=%v", path, err)
+	path, err := s.covers.Save(data)
+	if err != nil {
+		logrus.Warnf("cover: save failed url=%s err=%v", coverURL, err)
 		return fallback()
 	}
-	logrus.Infof("cover: saved url=%s to %s", coverURL, path)
-	return "/covers/" + filename
+	logrus.Infof("cover: saved url=%s as %s", coverURL, path)
+	return path
 }
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/parser"
+)
+
+// Import job statuses
+const (
+	ImportPending = "pending"
+	ImportRunning = "running"
+	ImportSuccess = "success"
+	ImportPartial = "partial"
+	ImportError   = "error"
+)
+
+// ImportJob tracks a local book import started with StartImport. Done and Total follow
+// parser.Progress: bytes for text files, sections for EPUB, chapters otherwise.
+type ImportJob struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"` // pending, running, success, partial, error
+	Error     string    `json:"error,omitempty"`
+	Total     int64     `json:"total"`
+	Done      int64     `json:"done"`
+	Chapters  int       `json:"chapters"`
+	BookID    string    `json:"book_id,omitempty"`
+	Warnings  []string  `json:"warnings,omitempty"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StartImport kicks off an async import of a local file and returns the job id for polling.
+// A parse that fails part way, or a book kept without chapters, finishes as partial with
+// the reason in Error.
+func (s *BookService) StartImport(req *models.CreateBookRequest) string {
+	job := &ImportJob{
+		ID:        uuid.New().String(),
+		Status:    ImportPending,
+		StartedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.mu.Unlock()
+
+	go func() {
+		s.updateJob(job.ID, func(j *ImportJob) { j.Status = ImportRunning })
+		book, err := s.ImportBook(context.Background(), req, func(progress parser.Progress, chapters int) {
+			s.updateJob(job.ID, func(j *ImportJob) {
+				j.Done, j.Total, j.Chapters = progress.Done, progress.Total, chapters
+			})
+		})
+
+		var partial *PartialImportError
+		s.updateJob(job.ID, func(j *ImportJob) {
+			switch {
+			case errors.As(err, &partial):
+				j.Status, j.Error, j.Chapters = ImportPartial, partial.Err.Error(), partial.Chapters
+			case err != nil:
+				j.Status, j.Error, j.Chapters = ImportError, err.Error(), 0
+			default:
+				j.Status = ImportSuccess
+			}
+			if book != nil {
+				j.BookID = book.ID
+				j.Warnings = book.Warnings
+			}
+		})
+	}()
+
+	return job.ID
+}
+
+// updateJob applies fn to a job under the lock and refreshes its timestamps
+func (s *BookService) updateJob(id string, fn func(*ImportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+		job.UpdatedAt = time.Now()
+		job.ElapsedMs = job.UpdatedAt.Sub(job.StartedAt).Milliseconds()
+	}
+}
+
+// GetImportJob returns a copy of an import job's current state
+func (s *BookService) GetImportJob(id string) (*ImportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("job not found")
+	}
+	snapshot := *job
+	if snapshot.Status == ImportPending || snapshot.Status == ImportRunning {
+		snapshot.ElapsedMs = time.Since(snapshot.StartedAt).Milliseconds()
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/parser"
+)
+
+// textFormats are decoded with a detected charset, which previews report
+var textFormats = map[string]bool{"txt": true, "md": true}
+
+// PreviewBook parses a file the way CreateBook would and describes the resulting volumes
+// and chapters, without storing the book
+func (s *BookService) PreviewBook(req *models.PreviewBookRequest) (*models.BookPreview, error) {
+	if _, err := os.Stat(req.FilePath); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFileNotFound, err)
+	}
+
+	format, warnings, err := resolveFormat(req.FilePath, req.FileFormat)
+	if err != nil {
+		return nil, err
+	}
+	book := &models.Book{FilePath: req.FilePath, FileFormat: format}
+
+	opts, err := s.importOptions(book, req.ChapterRuleSet, req.SplitChapterWords, req.MarkdownHeadings)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parser.GetParserWithOptions(book.FileFormat, opts)
+	if err != nil {
+		return nil, fmt.Errorf("parser not available: %w", err)
+	}
+	chapters, err := p.Parse(req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse book: %w", err)
+	}
+	s.applyMetadata(book, p, req.FilePath)
+	if book.Title == "" {
+		book.Title = strings.TrimSuffix(filepath.Base(req.FilePath), filepath.Ext(req.FilePath))
+	}
+
+	preview := &models.BookPreview{
+		Title:        book.Title,
+		Author:       book.Author,
+		FileFormat:   book.FileFormat,
+		ChapterCount: len(chapters),
+		Volumes:      []models.PreviewVolume{},
+		Warnings:     append(warnings, parser.ChapterWarnings(chapters)...),
+	}
+	if textFormats[book.FileFormat] {
+		preview.Encoding = parser.DetectEncoding(req.FilePath)
+	}
+	if preview.Warnings == nil {
+		preview.Warnings = []string{}
+	}
+
+	var volume *models.PreviewVolume
+	for _, ch := range chapters {
+		preview.WordCount += ch.WordCount
+		// Volume pages (see TxtParser) open a new volume and are not listed as chapters
+		isVolumePage := ch.VolumeChapterNumber == 0 && ch.Content == "" && ch.ContentHTML == ""
+		if volume == nil || isVolumePage || ch.VolumeNumber != volume.Number {
+			preview.Volumes = append(preview.Volumes, models.PreviewVolume{Number: ch.VolumeNumber, Chapters: []models.PreviewChapter{}})
+			volume = &preview.Volumes[len(preview.Volumes)-1]
+			if isVolumePage {
+				volume.Title = ch.Title
+				continue
+			}
+		}
+		volume.Chapters = append(volume.Chapters, models.PreviewChapter{
+			ChapterNumber:       ch.ChapterNumber,
+			VolumeChapterNumber: ch.VolumeChapterNumber,
+			Title:               ch.Title,
+			WordCount:           ch.WordCount,
+		})
+	}
+
+	return preview, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whitecat/go-reader/internal/models"
+	"github.com/whitecat/go-reader/internal/parser"
+	"github.com/whitecat/go-reader/internal/repository"
+)
+
+// Setting keys
+const (
+	chapterRuleSetsKey       = "chapter_rule_sets"
+	defaultChapterRuleSetKey = "default_chapter_rule_set"
+)
+
+// ErrInvalidChapterRules is returned when chapter rule settings fail validation
+var ErrInvalidChapterRules = errors.New("invalid chapter rules")
+
+// SettingsService handles business logic for application settings
+type SettingsService struct {
+	settingsRepo *repository.SettingsRepository
+}
+
+// NewSettingsService creates a new SettingsService
+func NewSettingsService(settingsRepo *repository.SettingsRepository) *SettingsService {
+	return &SettingsService{
+		settingsRepo: settingsRepo,
+	}
+}
+
+// GetChapterRuleSettings returns the built-in TXT chapter rule presets followed by the user's rule sets
+func (s *SettingsService) GetChapterRuleSettings() (*models.ChapterRuleSettings, error) {
+	custom, err := s.customRuleSets()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.ChapterRuleSettings{
+		DefaultSet: parser.DefaultChapterRuleSet,
+		Sets:       append(parser.BuiltInChapterRuleSets(), custom...),
+	}
+	setting, err := s.settingsRepo.Get(defaultChapterRuleSetKey)
+	switch {
+	case errors.Is(err, repository.ErrSettingNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("failed to get default rule set: %w", err)
+	case findRuleSet(settings.Sets, setting.Value) != nil:
+		settings.DefaultSet = setting.Value
+	}
+	return settings, nil
+}
+
+// UpdateChapterRuleSettings validates and stores the user's rule sets and the default set.
+// Built-in presets in the request are ignored; they cannot be changed.
+func (s *SettingsService) UpdateChapterRuleSettings(req *models.ChapterRuleSettings) (*models.ChapterRuleSettings, error) {
+	sets := parser.BuiltInChapterRuleSets()
+	builtIn := make(map[string]bool)
+	for _, set := range sets {
+		builtIn[set.ID] = true
+	}
+
+	var custom []models.ChapterRuleSet
+	seen := make(map[string]bool)
+	for _, set := range req.Sets {
+		if set.BuiltIn && builtIn[set.ID] {
+			continue
+		}
+		set.ID = strings.TrimSpace(set.ID)
+		if set.ID == "" {
+			set.ID = uuid.New().String()
+		}
+		set.Name = strings.TrimSpace(set.Name)
+		switch {
+		case builtIn[set.ID]:
+			return nil, fmt.Errorf("%w: rule set id %q is reserved for a built-in preset", ErrInvalidChapterRules, set.ID)
+		case seen[set.ID]:
+			return nil, fmt.Errorf("%w: duplicate rule set id %q", ErrInvalidChapterRules, set.ID)
+		case set.Name == "":
+			return nil, fmt.Errorf("%w: rule set %q needs a name", ErrInvalidChapterRules, set.ID)
+		case len(set.Rules) == 0:
+			return nil, fmt.Errorf("%w: rule set %q has no rules", ErrInvalidChapterRules, set.Name)
+		}
+		if err := parser.ValidateChapterRules(set.Rules); err != nil {
+			return nil, fmt.Errorf("%w: rule set %q: %v", ErrInvalidChapterRules, set.Name, err)
+		}
+		seen[set.ID] = true
+		set.BuiltIn = false
+		custom = append(custom, set)
+	}
+
+	defaultSet := req.DefaultSet
+	if defaultSet == "" {
+		defaultSet = parser.DefaultChapterRuleSet
+	}
+	if findRuleSet(append(sets, custom...), defaultSet) == nil {
+		return nil, fmt.Errorf("%w: unknown default rule set %q", ErrInvalidChapterRules, defaultSet)
+	}
+
+	data, err := json.Marshal(custom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rule sets: %w", err)
+	}
+	if err := s.settingsRepo.Set(&models.Settings{Key: chapterRuleSetsKey, Value: string(data), UpdatedAt: time.Now()}); err != nil {
+		return nil, fmt.Errorf("failed to save rule sets: %w", err)
+	}
+	if err := s.settingsRepo.Set(&models.Settings{Key: defaultChapterRuleSetKey, Value: defaultSet, UpdatedAt: time.Now()}); err != nil {
+		return nil, fmt.Errorf("failed to save default rule set: %w", err)
+	}
+	return s.GetChapterRuleSettings()
+}
+
+// ChapterRules returns the rules of the rule set with the given ID, or of the default set when id is empty
+func (s *SettingsService) ChapterRules(id string) ([]models.ChapterRule, error) {
+	settings, err := s.GetChapterRuleSettings()
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		id = settings.DefaultSet
+	}
+	set := findRuleSet(settings.Sets, id)
+	if set == nil {
+		return nil, fmt.Errorf("chapter rule set not found: %s", id)
+	}
+	return set.Rules, nil
+}
+
+// customRuleSets reads the user's rule sets; none are stored until the settings are first saved
+func (s *SettingsService) customRuleSets() ([]models.ChapterRuleSet, error) {
+	setting, err := s.settingsRepo.Get(chapterRuleSetsKey)
+	if errors.Is(err, repository.ErrSettingNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule sets: %w", err)
+	}
+	var sets []models.ChapterRuleSet
+	if err := json.Unmarshal([]byte(setting.Value), &sets); err != nil {
+		return nil, fmt.Errorf("failed to decode rule sets: %w", err)
+	}
+	return sets, nil
+}
+
+func findRuleSet(sets []models.ChapterRuleSet, id string) *models.ChapterRuleSet {
+	for i := range sets {
+		if sets[i].ID == id {
+			return &sets[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package textstat counts words in chapter text the same way for every format and language.
+package textstat
+
+import "unicode"
+
+// Stats holds the counts of a text
+type Stats struct {
+	// CJKChars counts Han, kana and Hangul characters, each of which is read as a word
+	CJKChars int
+	// Words counts runs of letters and digits in other scripts, split by whitespace
+	Words int
+}
+
+// Total is the word count stored for chapters: CJK characters plus other words
+func (s Stats) Total() int {
+	return s.CJKChars + s.Words
+}
+
+// Count counts the CJK characters and words of text. Punctuation and symbols are skipped:
+// they neither count nor split words, so "don't" and "e-mail" are one word and "—" is none.
+func Count(text string) Stats {
+	var s Stats
+	inWord := false
+	for _, r := range text {
+		switch {
+		case IsCJK(r):
+			s.CJKChars++
+			inWord = false
+		case unicode.IsSpace(r):
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) && inWord:
+			if !inWord {
+				s.Words++
+				inWord = true
+			}
+		}
+	}
+	return s
+}
+
+// WordCount returns Count(text).Total()
+func WordCount(text string) int {
+	return Count(text).Total()
+}
+
+// IsCJK reports whether r is a Chinese, Japanese or Korean character
+func IsCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
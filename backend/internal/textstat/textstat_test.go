@@ -0,0 +1,35 @@
+package textstat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		stats Stats
+	}{
+		{"empty", "", Stats{}},
+		{"english", "The quick brown fox.", Stats{Words: 4}},
+		{"punctuation only", " — ... !! ", Stats{}},
+		{"contractions and hyphens", "Don't e-mail me, okay?", Stats{Words: 4}},
+		{"chinese", "“斗之力，三段！”", Stats{CJKChars: 5}},
+		{"mixed", "他用iPhone 12拍了照。", Stats{CJKChars: 5, Words: 2}},
+		{"japanese", "ひらがなとカタカナ", Stats{CJKChars: 9}},
+		{"korean", "안녕 하세요", Stats{CJKChars: 5}},
+		{"full-width digits", "第１２章", Stats{CJKChars: 2, Words: 1}},
+		{"accents", "café naïve", Stats{Words: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.stats, Count(tt.text))
+		})
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	assert.Equal(t, 7, WordCount("他用iPhone 12拍了照。"))
+}
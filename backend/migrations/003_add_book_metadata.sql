@@ -0,0 +1,8 @@
+-- Add publication metadata extracted from book files (EPUB OPF etc.)
+ALTER TABLE books ADD COLUMN language TEXT DEFAULT '';
+ALTER TABLE books ADD COLUMN publisher TEXT DEFAULT '';
+ALTER TABLE books ADD COLUMN published_date TEXT DEFAULT '';
+ALTER TABLE books ADD COLUMN isbn TEXT DEFAULT '';
+ALTER TABLE books ADD COLUMN identifier TEXT DEFAULT '';
+ALTER TABLE books ADD COLUMN series TEXT DEFAULT '';
+ALTER TABLE books ADD COLUMN series_index REAL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Keep sanitized HTML for chapters imported from EPUB/HTML; content stays plain text for search and word counts
+ALTER TABLE chapters ADD COLUMN content_html TEXT DEFAULT '';
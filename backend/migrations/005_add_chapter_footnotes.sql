@@ -0,0 +1,2 @@
+-- Footnotes/endnotes referenced by a chapter, stored as a JSON array
+ALTER TABLE chapters ADD COLUMN footnotes TEXT DEFAULT '';
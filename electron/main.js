@@ -135,10 +135,16 @@ ipcMain.handle('select-file', async (event, options) => {
   const result = await dialog.showOpenDialog(mainWindow, {
     properties: ['openFile'],
     filters: [
-      { name: 'Book Files', extensions: ['txt', 'md', 'epub'] },
+      { name: 'Book Files', extensions: ['txt', 'md', 'epub', 'pdf', 'mobi', 'azw', 'azw3', 'fb2', 'zip', 'docx', 'odt', 'html', 'htm', 'mhtml', 'mht', 'cbz', 'cbr'] },
       { name: 'Text Files', extensions: ['txt'] },
       { name: 'Markdown Files', extensions: ['md'] },
       { name: 'EPUB Files', extensions: ['epub'] },
+      { name: 'PDF Files', extensions: ['pdf'] },
+      { name: 'Kindle Files', extensions: ['mobi', 'azw', 'azw3'] },
+      { name: 'FictionBook Files', extensions: ['fb2', 'zip'] },
+      { name: 'Documents', extensions: ['docx', 'odt'] },
+      { name: 'Web Pages', extensions: ['html', 'htm', 'mhtml', 'mht'] },
+      { name: 'Comic Archives', extensions: ['cbz', 'cbr'] },
       { name: 'All Files', extensions: ['*'] },
     ],
     ...options,
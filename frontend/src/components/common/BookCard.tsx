@@ -29,6 +29,8 @@ export default function BookCard({
   const resolveCover = () => {
     if (!book.cover_path) return fallbackCover
     if (book.cover_path.startsWith('http')) return book.cover_path
+    // Local covers have pre-generated thumbnails sized for the library grid
+    if (book.cover_path.startsWith('/covers/')) return `${book.cover_path}?size=medium`
     if (book.cover_path.startsWith('/')) return book.cover_path
     return `/${book.cover_path}`
   }
@@ -1,8 +1,9 @@
-import { useState } from 'react'
-import { X, Upload, Loader2, FileText } from 'lucide-react'
+import { useEffect, useState } from 'react'
+import { X, Upload, Loader2, FileText, AlertTriangle } from 'lucide-react'
 import Button from '../common/Button'
 import { bookService } from '@/services/bookService'
-import type { CreateBookRequest } from '@/types'
+import { settingsService } from '@/services/settingsService'
+import type { ChapterRuleSet, CreateBookRequest, ImportJob, MarkdownHeadings } from '@/types'
 import { useI18n } from '@/i18n/useI18n'
 
 interface AddBookModalProps {
@@ -22,18 +23,49 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
   })
   const [selectedFileName, setSelectedFileName] = useState<string>('')
   const [isLoading, setIsLoading] = useState(false)
+  const [progress, setProgress] = useState<number | null>(null)
   const [error, setError] = useState<string | null>(null)
+  // A finished import that is incomplete or has warnings, kept on screen until the user closes the modal
+  const [finishedJob, setFinishedJob] = useState<ImportJob | null>(null)
+  const [ruleSets, setRuleSets] = useState<ChapterRuleSet[]>([])
   const { t } = useI18n()
 
-  const detectFormat = (fileName: string): 'txt' | 'md' | 'epub' => {
+  useEffect(() => {
+    if (!isOpen) return
+    settingsService
+      .getChapterRules()
+      .then((settings) => {
+        setRuleSets(settings.sets)
+        setFormData((prev) => ({ ...prev, chapter_rule_set: prev.chapter_rule_set || settings.default_set }))
+      })
+      .catch((err) => console.error('Error loading chapter rules:', err))
+  }, [isOpen])
+
+  const detectFormat = (fileName: string): 'txt' | 'md' | 'epub' | 'pdf' | 'mobi' | 'azw3' | 'fb2' | 'docx' | 'odt' | 'html' | 'mhtml' | 'cbz' | 'cbr' => {
     const ext = fileName.split('.').pop()?.toLowerCase()
     if (ext === 'md' || ext === 'markdown') return 'md'
     if (ext === 'epub') return 'epub'
+    if (ext === 'pdf') return 'pdf'
+    if (ext === 'mobi' || ext === 'azw') return 'mobi'
+    if (ext === 'azw3') return 'azw3'
+    if (ext === 'fb2' || fileName.toLowerCase().endsWith('.fb2.zip')) return 'fb2'
+    if (ext === 'docx') return 'docx'
+    if (ext === 'odt') return 'odt'
+    if (ext === 'html' || ext === 'htm') return 'html'
+    if (ext === 'mhtml' || ext === 'mht') return 'mhtml'
+    if (ext === 'cbz') return 'cbz'
+    if (ext === 'cbr') return 'cbr'
     return 'txt'
   }
 
   if (!isOpen) return null
 
+  const handleClose = () => {
+    setFinishedJob(null)
+    setError(null)
+    onClose()
+  }
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault()
     setError(null)
@@ -46,9 +78,24 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
     setIsLoading(true)
 
     try {
-      await bookService.createBook(formData)
+      const jobId = await bookService.startImport(formData)
+      let job = await bookService.getImportStatus(jobId)
+      while (job.status === 'pending' || job.status === 'running') {
+        setProgress(job.total > 0 ? Math.round((job.done / job.total) * 100) : null)
+        await new Promise((resolve) => setTimeout(resolve, 500))
+        job = await bookService.getImportStatus(jobId)
+      }
+      if (job.status === 'error') {
+        throw new Error(job.error)
+      }
+      // A partial import keeps the chapters read before the parse failed, so the book is listed
+      // either way; only a clean import closes the modal right away
       onSuccess()
-      onClose()
+      if (job.status === 'partial' || (job.warnings?.length ?? 0) > 0) {
+        setFinishedJob(job)
+      } else {
+        onClose()
+      }
       // Reset form
       setFormData({
         title: '',
@@ -63,6 +110,7 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
       setError((err as Error).message)
     } finally {
       setIsLoading(false)
+      setProgress(null)
     }
   }
 
@@ -75,15 +123,43 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
             {t('addBook.title')}
           </h2>
           <button
-            onClick={onClose}
+            onClick={handleClose}
             className="p-2 hover:bg-gray-100 dark:hover:bg-gray-800 rounded-lg transition-colors"
           >
             <X className="w-5 h-5" />
           </button>
         </div>
 
+        {/* Import result */}
+        {finishedJob && (
+          <div className="space-y-4">
+            <div className="p-3 rounded-lg bg-amber-100 dark:bg-amber-900/30 text-amber-800 dark:text-amber-200 text-sm space-y-2">
+              <div className="flex items-center gap-2 font-medium">
+                <AlertTriangle className="w-4 h-4 shrink-0" />
+                {finishedJob.status === 'partial'
+                  ? t('addBook.result.partial', { chapters: finishedJob.chapters })
+                  : t('addBook.result.warnings')}
+              </div>
+              {finishedJob.status === 'partial' && finishedJob.error && <p>{finishedJob.error}</p>}
+              {finishedJob.warnings && finishedJob.warnings.length > 0 && (
+                <ul className="list-disc pl-5 space-y-1">
+                  {finishedJob.warnings.map((warning, i) => (
+                    <li key={i}>{warning}</li>
+                  ))}
+                </ul>
+              )}
+            </div>
+
+            <div className="flex pt-2">
+              <Button type="button" variant="secondary" onClick={handleClose} className="flex-1 justify-center">
+                {t('actions.close')}
+              </Button>
+            </div>
+          </div>
+        )}
+
         {/* Form */}
-        <form onSubmit={handleSubmit} className="space-y-4">
+        <form onSubmit={handleSubmit} className={finishedJob ? 'hidden' : 'space-y-4'}>
           {/* Title */}
           <div>
             <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1">
@@ -176,6 +252,44 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
             </div>
           </div>
 
+          {/* Chapter rules (TXT only) */}
+          {formData.file_format === 'txt' && ruleSets.length > 0 && (
+            <div>
+              <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1">
+                {t('addBook.field.chapterRules')}
+              </label>
+              <select
+                value={formData.chapter_rule_set}
+                onChange={(e) => setFormData({ ...formData, chapter_rule_set: e.target.value })}
+                className="w-full px-3 py-2 rounded-lg bg-white/5 border border-white/10 focus:outline-none focus:ring-2 focus:ring-primary-500 text-gray-100"
+              >
+                {ruleSets.map((set) => (
+                  <option key={set.id} value={set.id}>
+                    {set.name}
+                  </option>
+                ))}
+              </select>
+            </div>
+          )}
+
+          {/* Heading mapping (Markdown only) */}
+          {formData.file_format === 'md' && (
+            <div>
+              <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1">
+                {t('addBook.field.markdownHeadings')}
+              </label>
+              <select
+                value={formData.markdown_headings ?? ''}
+                onChange={(e) => setFormData({ ...formData, markdown_headings: e.target.value as MarkdownHeadings })}
+                className="w-full px-3 py-2 rounded-lg bg-white/5 border border-white/10 focus:outline-none focus:ring-2 focus:ring-primary-500 text-gray-100"
+              >
+                <option value="">{t('addBook.markdownHeadings.auto')}</option>
+                <option value="volumes">{t('addBook.markdownHeadings.volumes')}</option>
+                <option value="chapters">{t('addBook.markdownHeadings.chapters')}</option>
+              </select>
+            </div>
+          )}
+
           {/* Error Message */}
           {error && (
             <div className="p-3 rounded-lg bg-red-100 dark:bg-red-900/30 text-red-700 dark:text-red-300 text-sm">
@@ -188,7 +302,7 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
             <Button
               type="button"
               variant="secondary"
-              onClick={onClose}
+              onClick={handleClose}
               className="flex-1 justify-center"
               disabled={isLoading}
             >
@@ -204,6 +318,7 @@ export default function AddBookModal({ isOpen, onClose, onSuccess }: AddBookModa
                 <>
                   <Loader2 className="w-4 h-4 mr-2 animate-spin" />
                   {t('actions.adding')}
+                  {progress !== null && ` ${progress}%`}
                 </>
               ) : (
                 <>
@@ -1,4 +1,4 @@
-import { useEffect, useMemo, useState, useLayoutEffect, useRef, useCallback } from 'react'
+import { useEffect, useMemo, useState, useLayoutEffect, useRef, useCallback, type MouseEvent } from 'react'
 import { ChevronLeft, ChevronRight, X, List } from 'lucide-react'
 import { useReaderStore } from '@/store/readerStore'
 import { useSettingsStore } from '@/store/settingsStore'
@@ -40,6 +40,7 @@ export default function ReaderView({ onClose }: ReaderViewProps) {
   const { t } = useI18n()
   const [isSidebarOpen, setIsSidebarOpen] = useState(false)
   const [isSettingsOpen, setIsSettingsOpen] = useState(false)
+  const [activeFootnoteId, setActiveFootnoteId] = useState<string | null>(null)
 
   const chapter = chapters[currentChapter]
   const isChapterLoading = loadingChapters[currentChapter]
@@ -71,9 +72,33 @@ export default function ReaderView({ onClose }: ReaderViewProps) {
     return converted.split('\n')
   }, [chapter?.content, contentLanguage])
 
+  // Sanitized HTML (EPUB chapters) keeps formatting and illustrations
+  const convertedHTML = useMemo(
+    () => (chapter?.content_html ? convertText(chapter.content_html, contentLanguage) : ''),
+    [chapter?.content_html, contentLanguage]
+  )
+  const hasContent = !!chapter?.content || !!chapter?.content_html
+
+  const activeFootnote = useMemo(
+    () => chapter?.footnotes?.find((note) => note.id === activeFootnoteId) ?? null,
+    [chapter?.footnotes, activeFootnoteId]
+  )
+
+  // Footnote references are rendered as <a data-footnote="id">; show the note instead of jumping
+  const handleContentClick = useCallback((event: MouseEvent<HTMLDivElement>) => {
+    const link = (event.target as HTMLElement).closest('a[data-footnote]')
+    if (!link) return
+    event.preventDefault()
+    setActiveFootnoteId(link.getAttribute('data-footnote'))
+  }, [])
+
+  useEffect(() => {
+    setActiveFootnoteId(null)
+  }, [chapter?.id])
+
   const isVolumePage =
     (chapter?.volume_chapter_number ?? -1) === 0 ||
-    (!!chapter?.volume_number && chapter?.word_count === 0 && (chapter?.content ?? '') === '')
+    (!!chapter?.volume_number && chapter?.word_count === 0 && (chapter?.content ?? '') === '' && !chapter?.content_html)
 
   // Scroll management for progress and chapter transitions
   const scrollContainerRef = useRef<HTMLDivElement>(null)
@@ -82,10 +107,10 @@ export default function ReaderView({ onClose }: ReaderViewProps) {
 
   // Ensure the current chapter content is loaded when needed
   useEffect(() => {
-    if (chapter && !chapter.content && !isVolumePage) {
+    if (chapter && !hasContent && !isVolumePage) {
       loadChapterContent(currentChapter)
     }
-  }, [chapter, currentChapter, loadChapterContent, isVolumePage])
+  }, [chapter, currentChapter, loadChapterContent, isVolumePage, hasContent])
 
   const volumeTitles = useMemo(() => {
     const map = new Map<number, string>()
@@ -510,14 +535,34 @@ export default function ReaderView({ onClose }: ReaderViewProps) {
                   <p className={textMuted}>{t('reader.loading')}</p>
                 )}
 
-                {!isChapterLoading && chapter.content &&
+                {!isChapterLoading && convertedHTML && (
+                  <div
+                    className={`chapter-html ${textMain}`}
+                    onClick={handleContentClick}
+                    dangerouslySetInnerHTML={{ __html: convertedHTML }}
+                  />
+                )}
+
+                {activeFootnote && (
+                  <div
+                    className={`fixed bottom-24 left-1/2 -translate-x-1/2 w-[min(90vw,32rem)] p-4 rounded-lg shadow-lg border ${themeClass.panel} ${isNight ? 'border-[#1d2332]' : 'border-gray-200'}`}
+                    onClick={() => setActiveFootnoteId(null)}
+                  >
+                    <p className={`text-sm ${textMain}`}>
+                      <span className="font-semibold mr-2">{activeFootnote.label}</span>
+                      {convertText(activeFootnote.content, contentLanguage)}
+                    </p>
+                  </div>
+                )}
+
+                {!isChapterLoading && !convertedHTML && chapter.content &&
                   convertedParagraphs.map((paragraph, index) => (
                     <p key={index} className={`mb-4 ${textMain}`}>
                       {paragraph}
                     </p>
                   ))}
 
-                {!isChapterLoading && !chapter.content && (
+                {!isChapterLoading && !hasContent && (
                   <p className={textMuted}>
                     {t('reader.preparing')}
                   </p>
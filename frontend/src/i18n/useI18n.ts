@@ -13,6 +13,7 @@ type TranslationKey =
   | 'actions.cancel'
   | 'actions.add'
   | 'actions.adding'
+  | 'actions.close'
   | 'actions.previous'
   | 'actions.next'
   | 'reader.loading'
@@ -29,9 +30,16 @@ type TranslationKey =
   | 'addBook.chooseFile'
   | 'addBook.manualPathPlaceholder'
   | 'addBook.fileHint'
+  | 'addBook.field.chapterRules'
+  | 'addBook.field.markdownHeadings'
+  | 'addBook.markdownHeadings.auto'
+  | 'addBook.markdownHeadings.volumes'
+  | 'addBook.markdownHeadings.chapters'
   | 'addBook.error.required'
   | 'addBook.error.selectFileFailed'
   | 'addBook.error.fileUnavailable'
+  | 'addBook.result.partial'
+  | 'addBook.result.warnings'
   | 'bookCard.unknownAuthor'
   | 'bookCard.deleting'
 
@@ -50,6 +58,7 @@ const translations: Record<Language, Record<TranslationKey, string>> = {
     'actions.cancel': '取消',
     'actions.add': '新增',
     'actions.adding': '新增中...',
+    'actions.close': '關閉',
     'actions.previous': '上一章',
     'actions.next': '下一章',
     'reader.loading': '章節載入中...',
@@ -65,10 +74,17 @@ const translations: Record<Language, Record<TranslationKey, string>> = {
     'addBook.field.format': '格式 *',
     'addBook.chooseFile': '選擇檔案',
     'addBook.manualPathPlaceholder': '或貼上檔案路徑',
-    'addBook.fileHint': '點擊「選擇檔案」或貼上完整路徑（支援 .txt、.md、.epub）',
+    'addBook.fileHint': '點擊「選擇檔案」或貼上完整路徑（支援 .txt、.md、.epub、.pdf、.mobi、.azw、.azw3、.fb2、.docx、.odt、.html、.htm、.mhtml、.mht、.cbz、.cbr）',
+    'addBook.field.chapterRules': '章節辨識規則',
+    'addBook.field.markdownHeadings': '標題層級',
+    'addBook.markdownHeadings.auto': '自動（同時有 # 與 ## 時，# 為卷）',
+    'addBook.markdownHeadings.volumes': '# 為卷，## 為章',
+    'addBook.markdownHeadings.chapters': '# 與 ## 皆為章',
     'addBook.error.required': '書名與檔案路徑為必填',
     'addBook.error.selectFileFailed': '選擇檔案失敗',
     'addBook.error.fileUnavailable': '目前環境無法選擇檔案',
+    'addBook.result.partial': '匯入未完成，僅保留前 {{chapters}} 章',
+    'addBook.result.warnings': '已匯入，但有以下警告',
     'bookCard.unknownAuthor': '未知作者',
     'bookCard.deleting': '刪除中...',
   },
@@ -84,6 +100,7 @@ const translations: Record<Language, Record<TranslationKey, string>> = {
     'actions.cancel': '取消',
     'actions.add': '新增',
     'actions.adding': '新增中...',
+    'actions.close': '关闭',
     'actions.previous': '上一章',
     'actions.next': '下一章',
     'reader.loading': '章节加载中...',
@@ -99,10 +116,17 @@ const translations: Record<Language, Record<TranslationKey, string>> = {
     'addBook.field.format': '格式 *',
     'addBook.chooseFile': '选择文件',
     'addBook.manualPathPlaceholder': '或粘贴文件路径',
-    'addBook.fileHint': '点击「选择文件」或粘贴完整路径（支持 .txt、.md、.epub）',
+    'addBook.fileHint': '点击「选择文件」或粘贴完整路径（支持 .txt、.md、.epub、.pdf、.mobi、.azw、.azw3、.fb2、.docx、.odt、.html、.htm、.mhtml、.mht、.cbz、.cbr）',
+    'addBook.field.chapterRules': '章节识别规则',
+    'addBook.field.markdownHeadings': '标题层级',
+    'addBook.markdownHeadings.auto': '自动（同时有 # 与 ## 时，# 为卷）',
+    'addBook.markdownHeadings.volumes': '# 为卷，## 为章',
+    'addBook.markdownHeadings.chapters': '# 与 ## 均为章',
     'addBook.error.required': '书名与文件路径为必填',
     'addBook.error.selectFileFailed': '选择文件失败',
     'addBook.error.fileUnavailable': '当前环境无法选择文件',
+    'addBook.result.partial': '导入未完成，仅保留前 {{chapters}} 章',
+    'addBook.result.warnings': '已导入，但有以下警告',
     'bookCard.unknownAuthor': '未知作者',
     'bookCard.deleting': '删除中...',
   },
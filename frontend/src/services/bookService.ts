@@ -1,5 +1,5 @@
 import api from './api'
-import type { Book, Chapter, ChapterSummary, CreateBookRequest, UpdateBookRequest } from '../types'
+import type { Book, BookPreview, Chapter, ChapterSummary, CreateBookRequest, ImportJob, NumberingReport, PreviewBookRequest, UpdateBookRequest } from '../types'
 
 export const bookService = {
   // Get all books
@@ -20,6 +20,23 @@ export const bookService = {
     return response.data
   },
 
+  // Import a book in the background; poll getImportStatus with the returned job id
+  async startImport(data: CreateBookRequest): Promise<string> {
+    const response = await api.post('/books/import/start', data)
+    return response.data?.job_id
+  },
+
+  async getImportStatus(jobId: string): Promise<ImportJob> {
+    const response = await api.get('/books/import/status', { params: { id: jobId } })
+    return response.data
+  },
+
+  // Dry-run an import: how the file would be split, without saving anything
+  async previewBook(data: PreviewBookRequest): Promise<BookPreview> {
+    const response = await api.post('/books/preview', data)
+    return response.data
+  },
+
   // Update a book
   async updateBook(id: string, data: UpdateBookRequest): Promise<Book> {
     const response = await api.put(`/books/${id}`, data)
@@ -43,9 +60,15 @@ export const bookService = {
     return response.data || []
   },
 
-  // Get a specific chapter
-  async getChapter(bookId: string, chapterNumber: number): Promise<Chapter> {
-    const response = await api.get(`/books/${bookId}/chapters/${chapterNumber}`)
+  // Check chapter titles for missing, repeated or out-of-order numbers
+  async getNumberingReport(id: string): Promise<NumberingReport> {
+    const response = await api.get(`/books/${id}/numbering`)
+    return response.data
+  },
+
+  // Get a specific chapter; format limits it to its HTML or its plain text
+  async getChapter(bookId: string, chapterNumber: number, format?: 'html' | 'text'): Promise<Chapter> {
+    const response = await api.get(`/books/${bookId}/chapters/${chapterNumber}`, { params: format ? { format } : undefined })
     return response.data
   },
 }
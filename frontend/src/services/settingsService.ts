@@ -0,0 +1,16 @@
+import api from './api'
+import type { ChapterRuleSettings } from '../types'
+
+export const settingsService = {
+  // Get TXT chapter detection rule sets (built-in presets first)
+  async getChapterRules(): Promise<ChapterRuleSettings> {
+    const response = await api.get('/settings/chapter-rules')
+    return response.data
+  },
+
+  // Save custom rule sets and the default set
+  async updateChapterRules(data: ChapterRuleSettings): Promise<ChapterRuleSettings> {
+    const response = await api.put('/settings/chapter-rules', data)
+    return response.data
+  },
+}
@@ -6,20 +6,120 @@ export interface Book {
   description: string
   cover_path: string
   file_path: string
-  file_format: 'txt' | 'md' | 'epub' | 'web'
+  file_format: 'txt' | 'md' | 'epub' | 'pdf' | 'mobi' | 'azw3' | 'fb2' | 'docx' | 'odt' | 'html' | 'mhtml' | 'cbz' | 'cbr' | 'web'
   file_size: number
+  language?: string
+  publisher?: string
+  published_date?: string
+  isbn?: string
+  identifier?: string
+  series?: string
+  series_index?: number
   created_at: string
   updated_at: string
   tags?: Tag[]
+  warnings?: string[]
 }
 
 export interface CreateBookRequest {
-  title: string
+  title?: string
   author?: string
   description?: string
   file_path: string
-  file_format: 'txt' | 'md' | 'epub' | 'web'
+  file_format: 'txt' | 'md' | 'epub' | 'pdf' | 'mobi' | 'azw3' | 'fb2' | 'docx' | 'odt' | 'html' | 'mhtml' | 'cbz' | 'cbr' | 'web'
   tag_ids?: string[]
+  chapter_rule_set?: string
+  split_chapter_words?: number
+  markdown_headings?: MarkdownHeadings
+}
+
+export interface PreviewBookRequest {
+  file_path: string
+  file_format?: CreateBookRequest['file_format']
+  chapter_rule_set?: string
+  split_chapter_words?: number
+  markdown_headings?: MarkdownHeadings
+}
+
+// How markdown headings map to volumes and chapters; '' uses volumes only when a file has both # and ##
+export type MarkdownHeadings = '' | 'volumes' | 'chapters'
+
+// A background import of a local file; done/total count bytes, EPUB sections or chapters
+export interface ImportJob {
+  id: string
+  status: 'pending' | 'running' | 'success' | 'partial' | 'error'
+  error?: string
+  total: number
+  done: number
+  chapters: number
+  book_id?: string
+  warnings?: string[]
+  elapsed_ms: number
+  started_at: string
+  updated_at: string
+}
+
+// How a file would be split into volumes and chapters, before importing it
+export interface BookPreview {
+  title: string
+  author: string
+  file_format: string
+  encoding?: string
+  chapter_count: number
+  word_count: number
+  volumes: PreviewVolume[]
+  warnings: string[]
+}
+
+export interface PreviewVolume {
+  number: number
+  title: string
+  chapters: PreviewChapter[]
+}
+
+export interface PreviewChapter {
+  chapter_number: number
+  volume_chapter_number: number
+  title: string
+  word_count: number
+}
+
+// Gaps, repeats and steps back in the numbers chapter titles declare
+export interface NumberingReport {
+  book_id: string
+  chapter_count: number
+  numbered_count: number
+  issues: NumberingIssue[]
+}
+
+export interface NumberingIssue {
+  kind: 'missing' | 'duplicate' | 'out_of_order'
+  chapter_number: number
+  volume_number: number
+  title: string
+  declared?: number
+  missing_from?: number
+  missing_to?: number
+  message: string
+}
+
+// Chapter detection rules for TXT imports
+export interface ChapterRule {
+  name: string
+  pattern: string
+  kind: 'chapter' | 'volume'
+}
+
+export interface ChapterRuleSet {
+  id: string
+  name: string
+  built_in: boolean
+  rules: ChapterRule[]
+}
+
+export interface ChapterRuleSettings {
+  default_set: string
+  sets: ChapterRuleSet[]
 }
 
 export interface UpdateBookRequest {
@@ -27,6 +127,12 @@ export interface UpdateBookRequest {
   author?: string
   description?: string
   cover_path?: string
+  language?: string
+  publisher?: string
+  published_date?: string
+  isbn?: string
+  series?: string
+  series_index?: number
   tag_ids?: string[]
 }
 
@@ -39,10 +145,20 @@ export interface Chapter {
   volume_chapter_number?: number
   title: string
   content?: string
+  content_html?: string
+  footnotes?: Footnote[]
   word_count: number
   created_at: string
 }
 
+// Footnote referenced from content_html via <a data-footnote="id">
+export interface Footnote {
+  id: string
+  label: string
+  content: string
+  content_html?: string
+}
+
 export interface ChapterSummary {
   id: string
   book_id: string